@@ -0,0 +1,102 @@
+package schemalex
+
+import (
+	"context"
+	"database/sql"
+	"strings"
+
+	"github.com/schemalex/schemalex/internal/errors"
+)
+
+// SplitStatements splits the SQL into the statements that it consists
+// of, in the same way as the mysql client does before sending them to the
+// server. The statements are separated by semicolons, or by the delimiter
+// set by DELIMITER. The delimiters, the DELIMITER directives, and the
+// comments between the statements are not included
+func SplitStatements(src []byte) []string {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	var stmts []string
+	start := -1
+	for t := range lex(ctx, src) {
+		switch t.Type {
+		case SPACE, COMMENT_IDENT:
+			continue
+		case SEMICOLON, EOF:
+			if start >= 0 {
+				stmts = append(stmts, strings.TrimSpace(string(src[start:t.Pos])))
+				start = -1
+			}
+		default:
+			if start < 0 {
+				start = t.Pos
+			}
+		}
+	}
+	return stmts
+}
+
+// Execer executes a single statement. MySQLConn is an Execer
+type Execer interface {
+	Exec(ctx context.Context, stmt string) error
+}
+
+// ExecStatements executes the statements in order, stopping at the first
+// one that fails. It returns the statements that were executed, and the
+// statement that failed, if any, along with its error. As MySQL cannot
+// roll back changes to the schema, the executed statements stay in effect
+// even if a later one fails
+func ExecStatements(ctx context.Context, e Execer, stmts []string) (executed []string, failed string, err error) {
+	for i, stmt := range stmts {
+		if err := e.Exec(ctx, stmt); err != nil {
+			return stmts[:i:i], stmt, errors.Wrapf(err, `failed to apply statement %d of %d`, i+1, len(stmts))
+		}
+	}
+	return stmts, "", nil
+}
+
+// MySQLConn is a connection to a MySQL instance, on which statements are
+// executed one by one. Session state, such as the database selected by
+// USE, is kept between the statements
+type MySQLConn struct {
+	db   *sql.DB
+	conn *sql.Conn
+}
+
+// OpenMySQL opens a connection to the MySQL instance that the source,
+// which must be created by NewMySQLSource, retrieves the schema from
+func OpenMySQL(src SchemaSource) (*MySQLConn, error) {
+	s, ok := src.(mysqlSource)
+	if !ok {
+		return nil, errors.Errorf(`expected a mysql source, got %T`, src)
+	}
+
+	db, _, err := s.open()
+	if err != nil {
+		return nil, errors.Wrap(err, `failed to open connection to database`)
+	}
+	conn, err := db.Conn(context.Background())
+	if err != nil {
+		db.Close()
+		return nil, errors.Wrap(err, `failed to open connection to database`)
+	}
+	return &MySQLConn{
+		db:   db,
+		conn: conn,
+	}, nil
+}
+
+// Exec executes the statement
+func (c *MySQLConn) Exec(ctx context.Context, stmt string) error {
+	if _, err := c.conn.ExecContext(ctx, stmt); err != nil {
+		return errors.Wrap(err, `failed to execute statement`)
+	}
+	return nil
+}
+
+// Close closes the connection
+func (c *MySQLConn) Close() error {
+	c.conn.Close()
+	return c.db.Close()
+}
@@ -0,0 +1,70 @@
+package schemalex
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSplitStatements(t *testing.T) {
+	input := "-- WARNING: comment\nCREATE TABLE `a` (\n`id` INT (11) NOT NULL COMMENT 'a;b'\n);\n" +
+		"-- DROP TABLE `b`;\n\n" +
+		"DELIMITER ;;\nCREATE TRIGGER `t` BEFORE INSERT ON `a` FOR EACH ROW BEGIN SET NEW.id = 1; END;;\nDELIMITER ;\n\n" +
+		"/* comment */ ALTER TABLE `a` ADD COLUMN `b` INT (11) DEFAULT NULL;\n;\nDROP TABLE `c`"
+	expect := []string{
+		"CREATE TABLE `a` (\n`id` INT (11) NOT NULL COMMENT 'a;b'\n)",
+		"CREATE TRIGGER `t` BEFORE INSERT ON `a` FOR EACH ROW BEGIN SET NEW.id = 1; END",
+		"ALTER TABLE `a` ADD COLUMN `b` INT (11) DEFAULT NULL",
+		"DROP TABLE `c`",
+	}
+	assert.Equal(t, expect, SplitStatements([]byte(input)))
+	assert.Empty(t, SplitStatements([]byte("-- nothing to do\n")))
+}
+
+func TestOpenMySQL(t *testing.T) {
+	_, err := OpenMySQL(NewLocalFileSource("schema.sql"))
+	assert.Error(t, err, "OpenMySQL should fail for sources other than mysql")
+}
+
+// failingExecer records the statements that it executes, and fails on the
+// statement whose index is fail
+type failingExecer struct {
+	fail     int
+	executed []string
+}
+
+func (e *failingExecer) Exec(_ context.Context, stmt string) error {
+	if len(e.executed) == e.fail {
+		return errors.New("boom")
+	}
+	e.executed = append(e.executed, stmt)
+	return nil
+}
+
+func TestExecStatements(t *testing.T) {
+	stmts := []string{"CREATE TABLE a (id INT)", "CREATE TABLE b (id INT)", "DROP TABLE c"}
+
+	e := &failingExecer{fail: 1}
+	executed, failed, err := ExecStatements(context.Background(), e, stmts)
+	if assert.Error(t, err, "ExecStatements should fail") {
+		assert.Contains(t, err.Error(), "statement 2 of 3")
+	}
+	assert.Equal(t, []string{"CREATE TABLE a (id INT)"}, executed)
+	assert.Equal(t, "CREATE TABLE b (id INT)", failed)
+	assert.Equal(t, executed, e.executed, "the statements after the failed one should not be executed")
+
+	e = &failingExecer{fail: 0}
+	executed, failed, err = ExecStatements(context.Background(), e, stmts)
+	assert.Error(t, err, "ExecStatements should fail")
+	assert.Empty(t, executed)
+	assert.Equal(t, "CREATE TABLE a (id INT)", failed)
+
+	e = &failingExecer{fail: -1}
+	executed, failed, err = ExecStatements(context.Background(), e, stmts)
+	assert.NoError(t, err, "ExecStatements should succeed")
+	assert.Equal(t, stmts, executed)
+	assert.Empty(t, failed)
+	assert.Equal(t, stmts, e.executed)
+}
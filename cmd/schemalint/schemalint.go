@@ -12,6 +12,7 @@ import (
 	"github.com/pkg/errors"
 	"github.com/schemalex/schemalex"
 	"github.com/schemalex/schemalex/lint"
+	_ "github.com/schemalex/schemalex/migrations"
 )
 
 var version string
@@ -39,8 +40,8 @@ schemalint [options...] source
 -i number     Number of spaces to insert as indent (default: 2)
 
 "source" may be a file path, or a URI.
-Special URI schemes "mysql" and "local-git" are supported on top of
-"file". If the special path "-" is used, it is treated as stdin.
+Special URI schemes "mysql", "local-git" and "migrations" are supported
+on top of "file". If the special path "-" is used, it is treated as stdin.
 
 Examples:
 
@@ -54,6 +55,9 @@ Examples:
 * Lint a file in local git repository 
   schemalint "local-git:///path/to/repo?file=foo.sql&commitish=deadbeaf"
 
+* Lint the schema produced by replaying migration files
+  schemalint "migrations:///path/to/dir?pattern=*.up.sql"
+
 * Lint schema from stdin against local file
 	.... | schemalint -
 
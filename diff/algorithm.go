@@ -0,0 +1,379 @@
+package diff
+
+import (
+	"strings"
+
+	"github.com/schemalex/schemalex"
+	"github.com/schemalex/schemalex/model"
+)
+
+// AlterAlgorithmPolicy specifies how the ALGORITHM and LOCK clauses are
+// added to the generated ALTER TABLE statements (see WithAlterAlgorithm)
+type AlterAlgorithmPolicy struct {
+	// Version is the version of the MySQL server that the statements
+	// are executed on, such as "5.7" or "8.0.28". If the patch version
+	// is omitted, the latest release of the series is assumed. If
+	// unspecified, the version given by schemalex.WithServerVersion is
+	// used, or "8.0" if that is not given either
+	Version string
+
+	// Copy is the action to take for the statements that can only be
+	// performed by copying the table
+	Copy CopyAction
+}
+
+// CopyAction describes what to do with the statements that require
+// ALGORITHM=COPY
+type CopyAction int
+
+// List of possible CopyAction values. CopyActionWarn writes the statements
+// with ALGORITHM=COPY, preceded by a warning. CopyActionFail makes the diff
+// fail with an error
+const (
+	CopyActionWarn CopyAction = iota
+	CopyActionFail
+)
+
+// algorithm is the value of the ALGORITHM clause. The values are ordered
+// from the weakest (the one that supports every change) to the strongest
+type algorithm int
+
+const (
+	algorithmCopy algorithm = iota
+	algorithmInplace
+	algorithmInstant
+)
+
+func (a algorithm) String() string {
+	switch a {
+	case algorithmInplace:
+		return "INPLACE"
+	case algorithmInstant:
+		return "INSTANT"
+	}
+	return "COPY"
+}
+
+// lock is the value of the LOCK clause. The values are ordered from the
+// one that allows the most concurrent access to the one that allows the
+// least
+type lock int
+
+const (
+	lockNone lock = iota
+	lockShared
+)
+
+func (l lock) String() string {
+	if l == lockShared {
+		return "SHARED"
+	}
+	return "NONE"
+}
+
+// onlineDDL describes how MySQL can perform the changes in a single
+// ALTER TABLE statement, based on the online DDL support of InnoDB.
+// When in doubt, the weaker algorithm is chosen, as MySQL refuses to
+// execute a statement with an ALGORITHM that is not supported
+type onlineDDL struct {
+	version schemalex.ServerVersion
+
+	// INPLACE is only supported for adding foreign keys when
+	// foreign_key_checks is disabled
+	foreignKeyChecks bool
+}
+
+// statement returns the strongest algorithm and the weakest lock that can
+// be used to perform the changes
+func (o onlineDDL) statement(changes []Change) (algorithm, lock) {
+	// the primary key can be replaced in place, but not dropped
+	var addsPrimaryKey bool
+	for _, change := range changes {
+		if c, ok := change.(*IndexAdded); ok && c.Index.IsPrimaryKey() {
+			addsPrimaryKey = true
+		}
+	}
+
+	a, l := algorithmInstant, lockNone
+	for _, change := range changes {
+		ca, cl := o.change(change)
+		if c, ok := change.(*IndexDropped); ok && c.Index.IsPrimaryKey() && addsPrimaryKey {
+			ca, cl = algorithmInplace, lockNone
+		}
+		if ca < a {
+			a = ca
+		}
+		if cl > l {
+			l = cl
+		}
+	}
+
+	// LOCK=NONE is not supported with COPY
+	if a == algorithmCopy {
+		l = lockShared
+	}
+	return a, l
+}
+
+func (o onlineDDL) change(change Change) (algorithm, lock) {
+	// metadata only changes are instant as of 8.0
+	metadata := algorithmInplace
+	if o.version.AtLeast(8, 0, 0) {
+		metadata = algorithmInstant
+	}
+
+	switch c := change.(type) {
+	case *ColumnAdded:
+		switch {
+		case c.Column.GenerationStorage() == model.GenerationStorageStored:
+			return algorithmCopy, lockShared
+		case c.Column.GenerationStorage() == model.GenerationStorageVirtual:
+			return metadata, lockNone
+		case c.Column.IsAutoIncrement():
+			return algorithmInplace, lockShared
+		case o.version.AtLeast(8, 0, 29):
+			// columns can be added instantly at any position as of 8.0.29
+			return algorithmInstant, lockNone
+		}
+		return algorithmInplace, lockNone
+	case *ColumnDropped:
+		switch {
+		case c.Column.GenerationStorage() == model.GenerationStorageVirtual:
+			return metadata, lockNone
+		case c.Column.IsGenerated():
+			return algorithmInplace, lockNone
+		case o.version.AtLeast(8, 0, 29):
+			return algorithmInstant, lockNone
+		}
+		return algorithmInplace, lockNone
+	case *ColumnModified:
+		return o.columnModification(c.Before, c.After)
+	case *IndexAdded:
+		switch {
+		case c.Index.IsForeignKey():
+			if o.foreignKeyChecks {
+				return algorithmCopy, lockShared
+			}
+			return algorithmInplace, lockNone
+		case c.Index.IsFullText(), c.Index.IsSpatial():
+			return algorithmInplace, lockShared
+		}
+		return algorithmInplace, lockNone
+	case *IndexDropped:
+		if c.Index.IsPrimaryKey() {
+			return algorithmCopy, lockShared
+		}
+		return algorithmInplace, lockNone
+	case *IndexRenamed:
+		return algorithmInplace, lockNone
+	case *CheckConstraintAdded:
+		// the existing rows are validated by copying the table
+		return algorithmCopy, lockShared
+	case *CheckConstraintDropped:
+		return algorithmInplace, lockNone
+	case *TableOptionsModified:
+		a, l := algorithmInplace, lockNone
+		for _, opt := range c.Options {
+			switch opt.Key() {
+			case "AUTO_INCREMENT", "COMMENT", "KEY_BLOCK_SIZE", "ROW_FORMAT",
+				"STATS_AUTO_RECALC", "STATS_PERSISTENT", "STATS_SAMPLE_PAGES":
+			case "DEFAULT CHARACTER SET", "DEFAULT COLLATE":
+				l = lockShared
+			default:
+				// this includes ENGINE, as we do not know if the engine
+				// is actually changed
+				return algorithmCopy, lockShared
+			}
+		}
+		return a, l
+	}
+	return algorithmCopy, lockShared
+}
+
+func (o onlineDDL) columnModification(before, after model.TableColumn) (algorithm, lock) {
+	metadata := algorithmInplace
+	if o.version.AtLeast(8, 0, 0) {
+		metadata = algorithmInstant
+	}
+
+	a, l := algorithmInstant, lockNone
+	weaken := func(ca algorithm, cl lock) {
+		if ca < a {
+			a = ca
+		}
+		if cl > l {
+			l = cl
+		}
+	}
+
+	if before.Name() != after.Name() {
+		if o.version.AtLeast(8, 0, 28) {
+			weaken(algorithmInstant, lockNone)
+		} else {
+			weaken(algorithmInplace, lockNone)
+		}
+	}
+
+	if before.HasDefault() != after.HasDefault() || before.Default() != after.Default() || before.IsQuotedDefault() != after.IsQuotedDefault() {
+		weaken(metadata, lockNone)
+	}
+
+	if before.HasComment() != after.HasComment() || before.Comment() != after.Comment() {
+		weaken(algorithmInplace, lockNone)
+	}
+
+	// NULL and the absence of NOT NULL mean the same thing
+	if (before.NullState() == model.NullStateNotNull) != (after.NullState() == model.NullStateNotNull) {
+		weaken(algorithmInplace, lockNone)
+	}
+
+	if !sameColumnType(before, after) {
+		weaken(o.columnTypeModification(before, after))
+	}
+	return a, l
+}
+
+// sameColumnType returns true if the columns store the same kind of data
+// in the same format
+func sameColumnType(before, after model.TableColumn) bool {
+	return before.Type().SynonymType() == after.Type().SynonymType() &&
+		lengthString(before) == lengthString(after) &&
+		before.IsUnsigned() == after.IsUnsigned() &&
+		before.IsZeroFill() == after.IsZeroFill() &&
+		before.IsBinary() == after.IsBinary() &&
+		before.IsAutoIncrement() == after.IsAutoIncrement() &&
+		before.AutoUpdate() == after.AutoUpdate() &&
+		before.CharacterSet() == after.CharacterSet() &&
+		before.Collation() == after.Collation() &&
+		before.GenerationExpression() == after.GenerationExpression() &&
+		before.GenerationStorage() == after.GenerationStorage() &&
+		equalValues(before.EnumValues(), after.EnumValues()) &&
+		equalValues(before.SetValues(), after.SetValues())
+}
+
+func (o onlineDDL) columnTypeModification(before, after model.TableColumn) (algorithm, lock) {
+	copyColumn := func() (algorithm, lock) { return algorithmCopy, lockShared }
+
+	// only the length or the list of values may differ
+	b, a := before.Clone(), after.Clone()
+	b.SetLength(nil).SetEnumValues(nil).SetSetValues(nil)
+	a.SetLength(nil).SetEnumValues(nil).SetSetValues(nil)
+	if !sameColumnType(b, a) {
+		return copyColumn()
+	}
+
+	switch before.Type().SynonymType() {
+	case model.ColumnTypeVarChar, model.ColumnTypeVarBinary:
+		// VARCHAR columns can be extended in place, as long as the number
+		// of bytes used to store the length does not change
+		beforeLen, bok := lengthOf(before)
+		afterLen, aok := lengthOf(after)
+		if !bok || !aok || afterLen < beforeLen {
+			return copyColumn()
+		}
+		for _, n := range maxBytesPerChar(after) {
+			if (beforeLen*n < 256) != (afterLen*n < 256) {
+				return copyColumn()
+			}
+		}
+		return algorithmInplace, lockNone
+	case model.ColumnTypeEnum, model.ColumnTypeSet:
+		// members can be added to the end of the list, as long as the
+		// storage size does not change
+		beforeValues, afterValues := collectValues(before), collectValues(after)
+		if len(afterValues) < len(beforeValues) {
+			return copyColumn()
+		}
+		for i, v := range beforeValues {
+			if afterValues[i] != v {
+				return copyColumn()
+			}
+		}
+		if enumSetStorage(before.Type().SynonymType(), len(beforeValues)) != enumSetStorage(before.Type().SynonymType(), len(afterValues)) {
+			return copyColumn()
+		}
+		if o.version.AtLeast(8, 0, 0) {
+			return algorithmInstant, lockNone
+		}
+		return algorithmInplace, lockNone
+	}
+	return copyColumn()
+}
+
+func lengthString(col model.TableColumn) string {
+	if !col.HasLength() {
+		return ""
+	}
+	l := col.Length()
+	if l.HasDecimal() {
+		return l.Length() + "," + l.Decimal()
+	}
+	return l.Length()
+}
+
+// maxBytesPerChar returns the possible numbers of bytes used to store a
+// character in the column. If the character set is not known, all of
+// the possibilities are returned
+func maxBytesPerChar(col model.TableColumn) []int {
+	if col.Type().SynonymType() == model.ColumnTypeVarBinary {
+		return []int{1}
+	}
+	switch strings.ToLower(col.CharacterSet()) {
+	case "ascii", "binary", "latin1":
+		return []int{1}
+	case "utf8", "utf8mb3":
+		return []int{3}
+	case "utf8mb4":
+		return []int{4}
+	}
+	return []int{1, 2, 3, 4}
+}
+
+func collectValues(col model.TableColumn) []string {
+	ch := col.EnumValues()
+	if col.Type().SynonymType() == model.ColumnTypeSet {
+		ch = col.SetValues()
+	}
+	var l []string
+	for v := range ch {
+		l = append(l, v)
+	}
+	return l
+}
+
+func equalValues(a, b chan string) bool {
+	var l []string
+	for v := range a {
+		l = append(l, v)
+	}
+	var i int
+	for v := range b {
+		if i >= len(l) || l[i] != v {
+			return false
+		}
+		i++
+	}
+	return i == len(l)
+}
+
+// enumSetStorage returns the number of bytes used to store a value of
+// an ENUM or SET column with n members
+func enumSetStorage(typ model.ColumnType, n int) int {
+	if typ == model.ColumnTypeEnum {
+		if n <= 255 {
+			return 1
+		}
+		return 2
+	}
+	switch {
+	case n <= 8:
+		return 1
+	case n <= 16:
+		return 2
+	case n <= 24:
+		return 3
+	case n <= 32:
+		return 4
+	}
+	return 8
+}
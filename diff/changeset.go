@@ -0,0 +1,290 @@
+package diff
+
+import (
+	"fmt"
+
+	"github.com/schemalex/schemalex/model"
+)
+
+// Changeset describes the changes required to migrate from one schema
+// to another. The changes are listed in the order that they must be
+// applied in.
+type Changeset struct {
+	Changes  []Change
+	Warnings []Warning
+}
+
+// Warning describes something about a change that needs the attention
+// of the user, such as data that cannot be recovered by the change
+type Warning struct {
+	Change  Change
+	Message string
+}
+
+// Change describes a single change in a Changeset. Use a type switch
+// to find out the kind of the change.
+type Change interface {
+	// TableName returns the name of the table that the change applies
+	// to. For tables that have been renamed, this is the new name. For
+	// changes to views, routines, events and databases, this is the
+	// name of the view, the routine, the event or the database
+	TableName() string
+	// SchemaName returns the name of the database that the table belongs
+	// to, or an empty string if the table name is not qualified. It is
+	// always empty for the changes to other kinds of objects
+	SchemaName() string
+}
+
+func (cs *Changeset) add(changes ...Change) {
+	cs.Changes = append(cs.Changes, changes...)
+}
+
+func (cs *Changeset) warn(change Change, format string, args ...interface{}) {
+	cs.Warnings = append(cs.Warnings, Warning{
+		Change:  change,
+		Message: fmt.Sprintf(format, args...),
+	})
+}
+
+// Len returns the number of changes in the changeset
+func (cs *Changeset) Len() int {
+	return len(cs.Changes)
+}
+
+// TableRenamed is the change where a table is renamed
+type TableRenamed struct {
+	Before model.Table
+	After  model.Table
+}
+
+// TableDropped is the change where a table is dropped
+type TableDropped struct {
+	Table model.Table
+}
+
+// TableAdded is the change where a table is created
+type TableAdded struct {
+	Table model.Table
+}
+
+// TableOptionsModified is the change where table options are modified.
+// Options lists the new values of the options that were changed. Options
+// that were removed are listed with the value that resets them
+type TableOptionsModified struct {
+	Table   string
+	Schema  string
+	Options []model.TableOption
+}
+
+// ColumnDropped is the change where a column is dropped from a table
+type ColumnDropped struct {
+	Table  string
+	Schema string
+	Column model.TableColumn
+}
+
+// ColumnAdded is the change where a column is added to a table.
+// AfterColumn is the name of the column that the new column follows,
+// or an empty string if the new column is the first one
+type ColumnAdded struct {
+	Table       string
+	Schema      string
+	Column      model.TableColumn
+	AfterColumn string
+}
+
+// ColumnModified is the change where the definition of a column is
+// modified. If the column has been renamed, the names of Before and
+// After differ
+type ColumnModified struct {
+	Table  string
+	Schema string
+	Before model.TableColumn
+	After  model.TableColumn
+}
+
+// IndexDropped is the change where an index is dropped from a table
+type IndexDropped struct {
+	Table  string
+	Schema string
+	Index  model.Index
+}
+
+// IndexAdded is the change where an index is added to a table
+type IndexAdded struct {
+	Table  string
+	Schema string
+	Index  model.Index
+}
+
+// IndexRenamed is the change where an index is renamed
+type IndexRenamed struct {
+	Table  string
+	Schema string
+	Before model.Index
+	After  model.Index
+}
+
+// CheckConstraintDropped is the change where a check constraint is
+// dropped from a table
+type CheckConstraintDropped struct {
+	Table           string
+	Schema          string
+	CheckConstraint model.CheckConstraint
+}
+
+// CheckConstraintAdded is the change where a check constraint is added
+// to a table
+type CheckConstraintAdded struct {
+	Table           string
+	Schema          string
+	CheckConstraint model.CheckConstraint
+}
+
+// PartitioningModified is the change where the partitioning of a table
+// is modified. Before is nil if the table was not partitioned, and After
+// is nil if the partitioning was removed
+type PartitioningModified struct {
+	Table  string
+	Schema string
+	Before model.Partitioning
+	After  model.Partitioning
+}
+
+// SystemVersioningModified is the change where the system versioning of
+// a table (MariaDB) is enabled or disabled
+type SystemVersioningModified struct {
+	Table   string
+	Schema  string
+	Enabled bool
+}
+
+// ViewDropped is the change where a view is dropped
+type ViewDropped struct {
+	View model.View
+}
+
+// ViewAdded is the change where a view is created
+type ViewAdded struct {
+	View model.View
+}
+
+// ViewModified is the change where the definition of a view is modified.
+// The view is replaced by the new definition
+type ViewModified struct {
+	Before model.View
+	After  model.View
+}
+
+// TriggerDropped is the change where a trigger is dropped. Triggers that
+// are modified are dropped and created again
+type TriggerDropped struct {
+	Trigger model.Trigger
+}
+
+// TriggerAdded is the change where a trigger is created
+type TriggerAdded struct {
+	Trigger model.Trigger
+}
+
+// RoutineDropped is the change where a stored procedure or function is
+// dropped. Routines that are modified are dropped and created again
+type RoutineDropped struct {
+	Routine model.Routine
+}
+
+// RoutineAdded is the change where a stored procedure or function is
+// created
+type RoutineAdded struct {
+	Routine model.Routine
+}
+
+// EventDropped is the change where an event is dropped
+type EventDropped struct {
+	Event model.Event
+}
+
+// EventAdded is the change where an event is created
+type EventAdded struct {
+	Event model.Event
+}
+
+// EventModified is the change where the definition of an event is
+// modified by ALTER EVENT
+type EventModified struct {
+	Before model.Event
+	After  model.Event
+}
+
+// DatabaseDropped is the change where a database is dropped
+type DatabaseDropped struct {
+	Database model.Database
+}
+
+// DatabaseAdded is the change where a database is created
+type DatabaseAdded struct {
+	Database model.Database
+}
+
+// DatabaseModified is the change where the options of a database are
+// modified by ALTER DATABASE
+type DatabaseModified struct {
+	Before model.Database
+	After  model.Database
+}
+
+func (c *TableRenamed) TableName() string             { return c.After.Name() }
+func (c *TableDropped) TableName() string             { return c.Table.Name() }
+func (c *TableAdded) TableName() string               { return c.Table.Name() }
+func (c *TableOptionsModified) TableName() string     { return c.Table }
+func (c *ColumnDropped) TableName() string            { return c.Table }
+func (c *ColumnAdded) TableName() string              { return c.Table }
+func (c *ColumnModified) TableName() string           { return c.Table }
+func (c *IndexDropped) TableName() string             { return c.Table }
+func (c *IndexAdded) TableName() string               { return c.Table }
+func (c *IndexRenamed) TableName() string             { return c.Table }
+func (c *CheckConstraintDropped) TableName() string   { return c.Table }
+func (c *CheckConstraintAdded) TableName() string     { return c.Table }
+func (c *PartitioningModified) TableName() string     { return c.Table }
+func (c *SystemVersioningModified) TableName() string { return c.Table }
+func (c *ViewDropped) TableName() string              { return c.View.Name() }
+func (c *ViewAdded) TableName() string                { return c.View.Name() }
+func (c *ViewModified) TableName() string             { return c.After.Name() }
+func (c *TriggerDropped) TableName() string           { return c.Trigger.TableName() }
+func (c *TriggerAdded) TableName() string             { return c.Trigger.TableName() }
+func (c *RoutineDropped) TableName() string           { return c.Routine.Name() }
+func (c *RoutineAdded) TableName() string             { return c.Routine.Name() }
+func (c *EventDropped) TableName() string             { return c.Event.Name() }
+func (c *EventAdded) TableName() string               { return c.Event.Name() }
+func (c *EventModified) TableName() string            { return c.After.Name() }
+func (c *DatabaseDropped) TableName() string          { return c.Database.Name() }
+func (c *DatabaseAdded) TableName() string            { return c.Database.Name() }
+func (c *DatabaseModified) TableName() string         { return c.After.Name() }
+
+func (c *TableRenamed) SchemaName() string             { return c.After.Schema() }
+func (c *TableDropped) SchemaName() string             { return c.Table.Schema() }
+func (c *TableAdded) SchemaName() string               { return c.Table.Schema() }
+func (c *TableOptionsModified) SchemaName() string     { return c.Schema }
+func (c *ColumnDropped) SchemaName() string            { return c.Schema }
+func (c *ColumnAdded) SchemaName() string              { return c.Schema }
+func (c *ColumnModified) SchemaName() string           { return c.Schema }
+func (c *IndexDropped) SchemaName() string             { return c.Schema }
+func (c *IndexAdded) SchemaName() string               { return c.Schema }
+func (c *IndexRenamed) SchemaName() string             { return c.Schema }
+func (c *CheckConstraintDropped) SchemaName() string   { return c.Schema }
+func (c *CheckConstraintAdded) SchemaName() string     { return c.Schema }
+func (c *PartitioningModified) SchemaName() string     { return c.Schema }
+func (c *SystemVersioningModified) SchemaName() string { return c.Schema }
+func (c *ViewDropped) SchemaName() string              { return "" }
+func (c *ViewAdded) SchemaName() string                { return "" }
+func (c *ViewModified) SchemaName() string             { return "" }
+func (c *TriggerDropped) SchemaName() string           { return "" }
+func (c *TriggerAdded) SchemaName() string             { return "" }
+func (c *RoutineDropped) SchemaName() string           { return "" }
+func (c *RoutineAdded) SchemaName() string             { return "" }
+func (c *EventDropped) SchemaName() string             { return "" }
+func (c *EventAdded) SchemaName() string               { return "" }
+func (c *EventModified) SchemaName() string            { return "" }
+func (c *DatabaseDropped) SchemaName() string          { return "" }
+func (c *DatabaseAdded) SchemaName() string            { return "" }
+func (c *DatabaseModified) SchemaName() string         { return "" }
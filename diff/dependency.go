@@ -0,0 +1,282 @@
+package diff
+
+import (
+	"github.com/schemalex/schemalex/internal/util"
+	"github.com/schemalex/schemalex/model"
+)
+
+// referencedTables returns the keys (see tableKey) of the tables that the
+// foreign keys of the table refer to, excluding the table itself
+func referencedTables(table model.Table) map[string]struct{} {
+	names := make(map[string]struct{})
+	for idx := range table.Indexes() {
+		if !idx.IsForeignKey() || idx.Reference() == nil {
+			continue
+		}
+		name := referenceKey(table, idx.Reference())
+		if name == tableKey(table) {
+			continue
+		}
+		names[name] = struct{}{}
+	}
+	return names
+}
+
+// sortCreatedTables sorts the tables so that the tables that are referenced
+// by foreign keys are created before the tables that refer to them. Otherwise
+// the given order is kept.
+//
+// Tables that form a cycle cannot be ordered this way. The cycle is broken
+// by creating the first table in it without the foreign keys that refer
+// to the tables that have not been created yet. These foreign keys are
+// returned separately, so that they can be added after all of the tables
+// have been created
+func sortCreatedTables(tables []model.Table) ([]model.Table, []*IndexAdded) {
+	pending := make(map[string]struct{}, len(tables))
+	for _, table := range tables {
+		pending[tableKey(table)] = struct{}{}
+	}
+
+	isReady := func(table model.Table) bool {
+		for name := range referencedTables(table) {
+			if _, ok := pending[name]; ok {
+				return false
+			}
+		}
+		return true
+	}
+
+	var sorted []model.Table
+	var deferred []*IndexAdded
+	remaining := tables
+	for len(remaining) > 0 {
+		i := 0
+		for ; i < len(remaining); i++ {
+			if isReady(remaining[i]) {
+				break
+			}
+		}
+
+		table := remaining[0]
+		if i < len(remaining) {
+			table = remaining[i]
+		} else {
+			i = 0
+			var fks []model.Index
+			table, fks = withoutForeignKeys(table, pending)
+			for _, fk := range fks {
+				deferred = append(deferred, &IndexAdded{Table: table.Name(), Schema: table.Schema(), Index: fk})
+			}
+		}
+
+		sorted = append(sorted, table)
+		delete(pending, tableKey(table))
+		remaining = append(remaining[:i:i], remaining[i+1:]...)
+	}
+	return sorted, deferred
+}
+
+// sortDroppedTables sorts the tables so that the tables that refer to
+// other tables by foreign keys are dropped before the tables that they
+// refer to. Otherwise the given order is kept.
+//
+// Tables that form a cycle cannot be ordered this way. The cycle is broken
+// by dropping the foreign keys that refer to the first table in it. These
+// foreign keys are returned separately, so that they can be dropped before
+// any of the tables
+func sortDroppedTables(tables []model.Table) ([]model.Table, []*IndexDropped) {
+	pending := make(map[string]model.Table, len(tables))
+	for _, table := range tables {
+		pending[tableKey(table)] = table
+	}
+	// foreign keys that have already been dropped, by table key and
+	// index ID
+	dropped := make(map[string]map[string]struct{})
+
+	isReferenced := func(table model.Table) bool {
+		for key, other := range pending {
+			if key == tableKey(table) {
+				continue
+			}
+			for idx := range other.Indexes() {
+				if _, ok := dropped[key][idx.ID()]; ok {
+					continue
+				}
+				if idx.IsForeignKey() && idx.Reference() != nil && referenceKey(other, idx.Reference()) == tableKey(table) {
+					return true
+				}
+			}
+		}
+		return false
+	}
+
+	var sorted []model.Table
+	var predrops []*IndexDropped
+	remaining := tables
+	for len(remaining) > 0 {
+		i := 0
+		for ; i < len(remaining); i++ {
+			if !isReferenced(remaining[i]) {
+				break
+			}
+		}
+
+		if i == len(remaining) {
+			i = 0
+			name := tableKey(remaining[0])
+			for _, other := range remaining[1:] {
+				for idx := range other.Indexes() {
+					if !idx.IsForeignKey() || idx.Reference() == nil || referenceKey(other, idx.Reference()) != name {
+						continue
+					}
+					key := tableKey(other)
+					if dropped[key] == nil {
+						dropped[key] = make(map[string]struct{})
+					}
+					dropped[key][idx.ID()] = struct{}{}
+					predrops = append(predrops, &IndexDropped{Table: other.Name(), Schema: other.Schema(), Index: idx})
+				}
+			}
+		}
+
+		sorted = append(sorted, remaining[i])
+		delete(pending, tableKey(remaining[i]))
+		remaining = append(remaining[:i:i], remaining[i+1:]...)
+	}
+	return sorted, predrops
+}
+
+// withoutForeignKeys returns a copy of the table that does not have the
+// foreign keys that refer to the given tables (keyed by tableKey), along
+// with the foreign keys that were removed
+func withoutForeignKeys(table model.Table, names map[string]struct{}) (model.Table, []model.Index) {
+	tbl := model.NewTable(table.Name())
+	tbl.SetSchema(table.Schema())
+	tbl.SetIfNotExists(table.IsIfNotExists())
+	tbl.SetTemporary(table.IsTemporary())
+	if table.HasLikeTable() {
+		tbl.SetLikeTable(table.LikeTable())
+	}
+
+	for col := range table.Columns() {
+		tbl.AddColumn(col)
+	}
+
+	var removed []model.Index
+	for idx := range table.Indexes() {
+		if idx.IsForeignKey() && idx.Reference() != nil {
+			key := referenceKey(table, idx.Reference())
+			if _, ok := names[key]; ok && key != tableKey(table) {
+				removed = append(removed, idx)
+				continue
+			}
+		}
+		tbl.AddIndex(idx)
+	}
+
+	for check := range table.CheckConstraints() {
+		tbl.AddCheckConstraint(check)
+	}
+
+	for opt := range table.Options() {
+		tbl.AddOption(opt)
+	}
+
+	if table.HasPartitioning() {
+		tbl.SetPartitioning(table.Partitioning())
+	}
+	tbl.SetSystemVersioned(table.IsSystemVersioned())
+	return tbl, removed
+}
+
+// viewKey returns the name of the view qualified by its database, which
+// identifies the view among the views of all of the databases
+func viewKey(view model.View) string {
+	return qualifiedName(view.Schema(), view.Name())
+}
+
+// referencedViews returns the keys (see viewKey) of the views that the
+// definition of the view refers to, out of the given keys. The definition
+// is not parsed, so any identifier that matches one of the keys is
+// considered to be a reference. Identifiers that are not qualified refer
+// to the views in the same database as the view
+func referencedViews(view model.View, names map[string]struct{}) map[string]struct{} {
+	refs := make(map[string]struct{})
+	add := func(name string) {
+		if _, ok := names[name]; ok && name != viewKey(view) {
+			refs[name] = struct{}{}
+		}
+	}
+
+	toks := util.Tokenize(view.Definition())
+	for i := 0; i < len(toks); i++ {
+		if !toks[i].Ident {
+			continue
+		}
+		if i+2 < len(toks) && toks[i+1].Text == "." && toks[i+2].Ident {
+			add(qualifiedName(toks[i].Text, toks[i+2].Text))
+			i += 2
+			continue
+		}
+		add(qualifiedName(view.Schema(), toks[i].Text))
+	}
+	return refs
+}
+
+// sortCreatedViews sorts the views so that the views that are referred
+// to by other views are created first. Otherwise the given order is kept
+func sortCreatedViews(views []model.View) []model.View {
+	return sortViews(views, func(view model.View, pending map[string]struct{}) bool {
+		return len(referencedViews(view, pending)) == 0
+	})
+}
+
+// sortDroppedViews sorts the views so that the views that refer to other
+// views are dropped first. Otherwise the given order is kept
+func sortDroppedViews(views []model.View) []model.View {
+	byName := make(map[string]model.View, len(views))
+	for _, view := range views {
+		byName[viewKey(view)] = view
+	}
+	return sortViews(views, func(view model.View, pending map[string]struct{}) bool {
+		names := map[string]struct{}{viewKey(view): {}}
+		for name := range pending {
+			if name == viewKey(view) {
+				continue
+			}
+			if _, ok := referencedViews(byName[name], names)[viewKey(view)]; ok {
+				return false
+			}
+		}
+		return true
+	})
+}
+
+// sortViews repeatedly picks the first view that is ready. Views cannot
+// refer to each other in a cycle, but if they appear to (the references
+// are only guessed), the first remaining view is picked
+func sortViews(views []model.View, isReady func(model.View, map[string]struct{}) bool) []model.View {
+	pending := make(map[string]struct{}, len(views))
+	for _, view := range views {
+		pending[viewKey(view)] = struct{}{}
+	}
+
+	var sorted []model.View
+	remaining := views
+	for len(remaining) > 0 {
+		i := 0
+		for ; i < len(remaining); i++ {
+			if isReady(remaining[i], pending) {
+				break
+			}
+		}
+		if i == len(remaining) {
+			i = 0
+		}
+
+		sorted = append(sorted, remaining[i])
+		delete(pending, viewKey(remaining[i]))
+		remaining = append(remaining[:i:i], remaining[i+1:]...)
+	}
+	return sorted
+}
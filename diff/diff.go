@@ -4,90 +4,293 @@ package diff
 
 import (
 	"bytes"
+	"fmt"
 	"io"
+	"math"
 	"reflect"
 	"sort"
+	"strings"
 
 	"github.com/deckarep/golang-set"
 	"github.com/schemalex/schemalex"
-	"github.com/schemalex/schemalex/format"
 	"github.com/schemalex/schemalex/internal/errors"
+	"github.com/schemalex/schemalex/internal/util"
 	"github.com/schemalex/schemalex/model"
 )
 
 type diffCtx struct {
-	fromSet mapset.Set
-	toSet   mapset.Set
-	from    model.Stmts
-	to      model.Stmts
+	fromSet   mapset.Set
+	toSet     mapset.Set
+	fromOrder map[string]int
+	toOrder   map[string]int
+	from      model.Stmts
+	to        model.Stmts
+
+	ignoredTableOptions map[string]struct{}
+	renames             *renames
+
+	// foreign keys of the tables that are kept, which are dropped along
+	// with the tables that they refer to, keyed by tableKey and index ID
+	predropped map[string]map[string]struct{}
+
+	// the server version given by schemalex.WithServerVersion, if any
+	version *schemalex.ServerVersion
+}
+
+// defaultIgnoredTableOptions lists the table options whose values
+// change as the table is used, and therefore should not be diffed
+var defaultIgnoredTableOptions = []string{"AUTO_INCREMENT"}
+
+func makeIgnoredTableOptions(names []string) map[string]struct{} {
+	m := make(map[string]struct{}, len(names))
+	for _, name := range names {
+		m[strings.ToUpper(name)] = struct{}{}
+	}
+	return m
 }
 
 func newDiffCtx(from, to model.Stmts) *diffCtx {
 	fromSet := mapset.NewSet()
-	for _, stmt := range from {
+	fromOrder := make(map[string]int)
+	for i, stmt := range from {
 		if cs, ok := stmt.(model.Table); ok {
 			fromSet.Add(cs.ID())
+			fromOrder[cs.ID()] = i
 		}
 	}
 	toSet := mapset.NewSet()
-	for _, stmt := range to {
+	toOrder := make(map[string]int)
+	for i, stmt := range to {
 		if cs, ok := stmt.(model.Table); ok {
 			toSet.Add(cs.ID())
+			toOrder[cs.ID()] = i
 		}
 	}
 
 	return &diffCtx{
-		fromSet: fromSet,
-		toSet:   toSet,
-		from:    from,
-		to:      to,
+		fromSet:   fromSet,
+		toSet:     toSet,
+		fromOrder: fromOrder,
+		toOrder:   toOrder,
+		from:      from,
+		to:        to,
+	}
+}
+
+// replay applies the statements that alter or drop tables and other
+// objects to the ones created before them, so that a schema that
+// contains `ALTER TABLE`, `CREATE INDEX`, `RENAME TABLE` or `DROP`
+// statements is compared by its end result
+func replay(stmts model.Stmts) (model.Stmts, error) {
+	result := make(model.Stmts, 0, len(stmts))
+	for _, stmt := range stmts {
+		var err error
+		switch stmt := stmt.(type) {
+		case model.AlterTable:
+			result, err = model.Apply(result, stmt)
+		case model.DropTable:
+			result, err = model.Drop(result, stmt)
+		case model.DropObject:
+			result, err = model.Remove(result, stmt)
+		default:
+			result = append(result, stmt)
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+	return result, nil
+}
+
+// sortedIDs returns the IDs in the set, sorted by the given key.
+// IDs with the same key are sorted by the IDs themselves, so that
+// the result is always the same regardless of the iteration order
+// of the set
+func sortedIDs(set mapset.Set, key func(string) string) []string {
+	ids := make([]string, 0, set.Cardinality())
+	keys := make(map[string]string, set.Cardinality())
+	for v := range set.Iter() {
+		id := v.(string)
+		ids = append(ids, id)
+		keys[id] = key(id)
+	}
+	sort.Slice(ids, func(i, j int) bool {
+		if keys[ids[i]] != keys[ids[j]] {
+			return keys[ids[i]] < keys[ids[j]]
+		}
+		return ids[i] < ids[j]
+	})
+	return ids
+}
+
+// orderKey returns a key that sorts in the same order as the numbers
+func orderKey(n int, ok bool) string {
+	if !ok {
+		n = math.MaxInt32
+	}
+	return fmt.Sprintf("%010d", n)
+}
+
+func tableOrderKey(order map[string]int) func(string) string {
+	return func(id string) string {
+		n, ok := order[id]
+		return orderKey(n, ok)
+	}
+}
+
+func columnOrderKey(table model.Table) func(string) string {
+	return func(id string) string {
+		return orderKey(table.LookupColumnOrder(id))
+	}
+}
+
+// indexes are sorted by their names. the primary key, which does not
+// have a name, comes first
+func indexOrderKey(table model.Table) func(string) string {
+	return func(id string) string {
+		idx, ok := table.LookupIndex(id)
+		switch {
+		case !ok:
+			return ""
+		case idx.IsPrimaryKey():
+			return ""
+		case idx.HasName():
+			return idx.Name()
+		default:
+			return idx.Symbol()
+		}
+	}
+}
+
+func checkOrderKey(table model.Table) func(string) string {
+	return func(id string) string {
+		if check, ok := table.LookupCheckConstraint(id); ok {
+			return check.Name()
+		}
+		return ""
 	}
 }
 
 // Statements compares two model.Stmts and generates a series
 // of statements to migrate from the old one to the new one,
 // writing the result to `dst`
+//
+// If WithReverse is specified, the statements to migrate from the
+// new schema back to the old one are generated as well
 func Statements(dst io.Writer, from, to model.Stmts, options ...Option) error {
-	var txn bool
+	var reverse io.Writer
 	for _, o := range options {
 		switch o.Name() {
-		case optkeyTransaction:
-			txn = o.Value().(bool)
+		case optkeyReverse:
+			reverse = o.Value().(io.Writer)
 		}
 	}
 
-	ctx := newDiffCtx(from, to)
+	changes, err := Compute(from, to, options...)
+	if err != nil {
+		return err
+	}
+	if err := Render(dst, changes, options...); err != nil {
+		return err
+	}
 
-	var procs = []func(*diffCtx, io.Writer) (int64, error){
-		dropTables,
-		createTables,
-		alterTables,
+	if reverse == nil {
+		return nil
 	}
 
-	var buf bytes.Buffer
-	if txn {
-		buf.WriteString("\nBEGIN;\n\nSET FOREIGN_KEY_CHECKS = 0;")
+	changes, err = computeReverse(from, to, options...)
+	if err != nil {
+		return errors.Wrap(err, `failed to compute reverse diff`)
 	}
 
-	for _, p := range procs {
-		var pbuf bytes.Buffer
-		n, err := p(ctx, &pbuf)
-		if err != nil {
-			return errors.Wrap(err, `failed to produce diff`)
+	reverseOptions := make([]Option, 0, len(options))
+	for _, o := range options {
+		if o.Name() != optkeySafetyPolicy {
+			reverseOptions = append(reverseOptions, o)
 		}
-		if txn && n > 0 || !txn && buf.Len() > 0 && n > 0 {
-			buf.WriteString("\n\n")
+	}
+	if err := Render(reverse, changes, reverseOptions...); err != nil {
+		return errors.Wrap(err, `failed to render reverse diff`)
+	}
+	return nil
+}
+
+// Compute compares two model.Stmts and returns the changes required
+// to migrate from the old one to the new one. Use Render to generate
+// the SQL statements for the Changeset
+func Compute(from, to model.Stmts, options ...Option) (*Changeset, error) {
+	var heuristics bool
+	var hints RenameHints
+	ignoredTableOptions := defaultIgnoredTableOptions
+	for _, o := range options {
+		switch o.Name() {
+		case optkeyIgnoredTableOptions:
+			ignoredTableOptions = o.Value().([]string)
+		case optkeyRenameHints:
+			hints = o.Value().(RenameHints)
+		case optkeyRenameHeuristics:
+			heuristics = o.Value().(bool)
 		}
-		pbuf.WriteTo(&buf)
 	}
-	if txn {
-		buf.WriteString("\n\nSET FOREIGN_KEY_CHECKS = 1;\n\nCOMMIT;")
+
+	version, hasVersion, err := schemalex.ServerVersionFromOptions(options...)
+	if err != nil {
+		return nil, errors.Wrap(err, `invalid server version`)
 	}
 
-	if _, err := buf.WriteTo(dst); err != nil {
-		return errors.Wrap(err, `failed to write diff`)
+	from, err = replay(from)
+	if err != nil {
+		return nil, errors.Wrap(err, `failed to replay "from"`)
 	}
-	return nil
+	to, err = replay(to)
+	if err != nil {
+		return nil, errors.Wrap(err, `failed to replay "to"`)
+	}
+
+	renames, err := resolveRenames(from, to, hints, heuristics)
+	if err != nil {
+		return nil, errors.Wrap(err, `failed to resolve renames`)
+	}
+
+	ctx := newDiffCtx(renames.apply(from, to), to)
+	ctx.ignoredTableOptions = makeIgnoredTableOptions(ignoredTableOptions)
+	ctx.renames = renames
+	if hasVersion {
+		ctx.version = &version
+	}
+
+	// views, triggers, routines and events are dropped before, and
+	// created after, the tables that they may refer to are changed.
+	// routines are created first, as the others may call them.
+	// databases are created before, and dropped after, everything else
+	var procs = []func(*diffCtx) ([]Change, error){
+		createDatabases,
+		alterDatabases,
+		dropEvents,
+		dropTriggers,
+		dropViews,
+		dropRoutines,
+		renameTables,
+		dropTables,
+		createTables,
+		alterTables,
+		createRoutines,
+		createViews,
+		createTriggers,
+		createEvents,
+		alterEvents,
+		dropDatabases,
+	}
+
+	var changes Changeset
+	for _, p := range procs {
+		l, err := p(ctx)
+		if err != nil {
+			return nil, errors.Wrap(err, `failed to produce diff`)
+		}
+		changes.add(l...)
+	}
+	return &changes, nil
 }
 
 // Strings compares two strings and generates a series
@@ -102,7 +305,8 @@ func Strings(dst io.Writer, from, to string, options ...Option) error {
 		}
 	}
 	if p == nil {
-		p = schemalex.New()
+		// the server version option, if any, is meant for the parser too
+		p = schemalex.New(options...)
 	}
 
 	stmts1, err := p.ParseString(from)
@@ -142,52 +346,595 @@ func Sources(dst io.Writer, from, to schemalex.SchemaSource, options ...Option)
 	return Strings(dst, fromStr, buf.String(), options...)
 }
 
-func dropTables(ctx *diffCtx, dst io.Writer) (int64, error) {
-	var buf bytes.Buffer
-	ids := ctx.fromSet.Difference(ctx.toSet)
-	for i, id := range ids.ToSlice() {
-		if i > 0 {
-			buf.WriteByte('\n')
+// tables are dropped in the order that they appear in the old schema,
+// except that tables that refer to other tables by foreign keys are
+// dropped first
+func dropTables(ctx *diffCtx) ([]Change, error) {
+	var tables []model.Table
+	ids := sortedIDs(ctx.fromSet.Difference(ctx.toSet), tableOrderKey(ctx.fromOrder))
+	for _, id := range ids {
+		stmt, ok := ctx.from.Lookup(id)
+		if !ok {
+			return nil, errors.Errorf(`failed to lookup table %s`, id)
 		}
 
-		stmt, ok := ctx.from.Lookup(id.(string))
+		table, ok := stmt.(model.Table)
 		if !ok {
-			return 0, errors.Errorf(`failed to lookup table %s`, id)
+			return nil, errors.Errorf(`lookup failed: %s is not a model.Table`, id)
+		}
+		tables = append(tables, table)
+	}
+
+	tables, predrops := sortDroppedTables(tables)
+	var changes []Change
+	for _, change := range keptForeignKeys(ctx, tables) {
+		changes = append(changes, change)
+	}
+	for _, change := range predrops {
+		changes = append(changes, change)
+	}
+	for _, table := range tables {
+		changes = append(changes, &TableDropped{Table: table})
+	}
+	return changes, nil
+}
+
+// keptForeignKeys returns the changes that drop the foreign keys of the
+// tables that are kept, which refer to the tables that are dropped, and
+// are not in the new schema either. They are dropped before the tables,
+// instead of along with the other changes to the tables, as the tables
+// that they refer to cannot be dropped otherwise
+func keptForeignKeys(ctx *diffCtx, dropped []model.Table) []*IndexDropped {
+	keys := make(map[string]struct{}, len(dropped))
+	for _, table := range dropped {
+		keys[tableKey(table)] = struct{}{}
+	}
+
+	var changes []*IndexDropped
+	for _, id := range sortedIDs(ctx.fromSet.Intersect(ctx.toSet), tableOrderKey(ctx.fromOrder)) {
+		before, ok := ctx.from.Lookup(id)
+		if !ok {
+			continue
+		}
+		after, ok := ctx.to.Lookup(id)
+		if !ok {
+			continue
+		}
+		from, to := before.(model.Table), after.(model.Table)
+
+		var ids []string
+		for idx := range from.Indexes() {
+			if !idx.IsForeignKey() || idx.Reference() == nil {
+				continue
+			}
+			if _, ok := keys[referenceKey(from, idx.Reference())]; !ok {
+				continue
+			}
+			if _, ok := to.LookupIndex(idx.ID()); ok {
+				continue
+			}
+			ids = append(ids, idx.ID())
+		}
+		sort.Strings(ids)
+
+		for _, idxID := range ids {
+			idx, _ := from.LookupIndex(idxID)
+			if ctx.predropped == nil {
+				ctx.predropped = make(map[string]map[string]struct{})
+			}
+			if ctx.predropped[tableKey(to)] == nil {
+				ctx.predropped[tableKey(to)] = make(map[string]struct{})
+			}
+			ctx.predropped[tableKey(to)][idxID] = struct{}{}
+			changes = append(changes, &IndexDropped{Table: to.Name(), Schema: to.Schema(), Index: idx})
+		}
+	}
+	return changes
+}
+
+// tables are created in the order that they appear in the new schema,
+// except that tables that are referred to by foreign keys are created
+// first
+func createTables(ctx *diffCtx) ([]Change, error) {
+	var tables []model.Table
+	ids := sortedIDs(ctx.toSet.Difference(ctx.fromSet), tableOrderKey(ctx.toOrder))
+	for _, id := range ids {
+		stmt, ok := ctx.to.Lookup(id)
+		if !ok {
+			return nil, errors.Errorf(`failed to lookup table %s`, id)
 		}
 
 		table, ok := stmt.(model.Table)
 		if !ok {
-			return 0, errors.Errorf(`lookup failed: %s is not a model.Table`, id)
+			return nil, errors.Errorf(`lookup failed: %s is not a model.Table`, id)
 		}
-		buf.WriteString("DROP TABLE `")
-		buf.WriteString(table.Name())
-		buf.WriteString("`;")
+		tables = append(tables, table)
 	}
 
-	return buf.WriteTo(dst)
+	tables, deferred := sortCreatedTables(tables)
+	var changes []Change
+	for _, table := range tables {
+		changes = append(changes, &TableAdded{Table: table})
+	}
+	for _, change := range deferred {
+		changes = append(changes, change)
+	}
+	return changes, nil
 }
 
-func createTables(ctx *diffCtx, dst io.Writer) (int64, error) {
-	var buf bytes.Buffer
+// viewsByName returns the views in the statements, along with their
+// keys (see viewKey) in the order that they appear in
+func viewsByName(stmts model.Stmts) (map[string]model.View, []string) {
+	m := make(map[string]model.View)
+	var names []string
+	for _, stmt := range stmts {
+		if view, ok := stmt.(model.View); ok {
+			m[viewKey(view)] = view
+			names = append(names, viewKey(view))
+		}
+	}
+	return m, names
+}
+
+// views are dropped in the order that they appear in the old schema,
+// except that views that refer to other views are dropped first
+func dropViews(ctx *diffCtx) ([]Change, error) {
+	toViews, _ := viewsByName(ctx.to)
+	fromViews, names := viewsByName(ctx.from)
+
+	var views []model.View
+	for _, name := range names {
+		if _, ok := toViews[name]; !ok {
+			views = append(views, fromViews[name])
+		}
+	}
+
+	var changes []Change
+	for _, view := range sortDroppedViews(views) {
+		changes = append(changes, &ViewDropped{View: view})
+	}
+	return changes, nil
+}
+
+// views are created or replaced in the order that they appear in the new
+// schema, except that views that are referred to by other views are
+// created first
+func createViews(ctx *diffCtx) ([]Change, error) {
+	fromViews, _ := viewsByName(ctx.from)
+	toViews, names := viewsByName(ctx.to)
+
+	var views []model.View
+	for _, name := range names {
+		if before, ok := fromViews[name]; !ok || !sameView(before, toViews[name]) {
+			views = append(views, toViews[name])
+		}
+	}
+
+	var changes []Change
+	for _, view := range sortCreatedViews(views) {
+		if before, ok := fromViews[viewKey(view)]; ok {
+			changes = append(changes, &ViewModified{Before: before, After: view})
+			continue
+		}
+		changes = append(changes, &ViewAdded{View: view})
+	}
+	return changes, nil
+}
+
+// sameView returns true if the views are defined the same way. Options
+// that are set to their default values are the same as options that are
+// not given, and the definers are only compared if both are specified.
+// The definitions are compared after normalizeDefinition
+func sameView(before, after model.View) bool {
+	option := func(ok bool, v, def string) string {
+		if !ok {
+			return def
+		}
+		return strings.ToUpper(v)
+	}
+
+	if option(before.HasAlgorithm(), before.Algorithm(), "UNDEFINED") != option(after.HasAlgorithm(), after.Algorithm(), "UNDEFINED") {
+		return false
+	}
+	if option(before.HasSQLSecurity(), before.SQLSecurity(), "DEFINER") != option(after.HasSQLSecurity(), after.SQLSecurity(), "DEFINER") {
+		return false
+	}
+	if before.HasDefiner() && after.HasDefiner() && before.Definer() != after.Definer() {
+		return false
+	}
+	if option(before.HasCheckOption(), before.CheckOption(), "") != option(after.HasCheckOption(), after.CheckOption(), "") {
+		return false
+	}
+	return normalizeDefinition(before.Definition()) == normalizeDefinition(after.Definition()) && equalValues(before.Columns(), after.Columns())
+}
+
+// normalizeDefinition returns the definition of a view in a form that can
+// be compared with the one that SHOW CREATE VIEW returns for it. The
+// server writes keywords in lower case, quotes the identifiers, qualifies
+// the columns by their tables, and gives each selected column an alias
+// even if it is the same as the name of the column. So the keywords and
+// identifiers are compared in lower case and without quotes, qualifiers
+// are removed if the view selects from a single table, and aliases that
+// are the same as the column are removed.
+//
+// Other changes made by the server, such as replacing `*` with the list
+// of the columns, or adding parenthesis to the conditions, are not undone.
+// Views that are written in such ways are replaced even if they have not
+// changed
+func normalizeDefinition(def string) string {
+	toks := util.Tokenize(def)
+
+	// the tables that the view selects from
+	tables := make(map[string]struct{})
+	for i, tok := range toks {
+		if i+1 < len(toks) && toks[i+1].Ident && tok.Ident && !tok.Quoted && (strings.EqualFold(tok.Text, "FROM") || strings.EqualFold(tok.Text, "JOIN")) {
+			tables[strings.ToLower(toks[i+1].Text)] = struct{}{}
+		}
+	}
+
+	var words []string
+	var ident bool // whether the last word is an identifier
+	for i := 0; i < len(toks); i++ {
+		tok := toks[i]
+		text := tok.Text
+		if tok.Ident {
+			text = strings.ToLower(text)
+		}
+
+		if len(tables) == 1 && tok.Ident && i+2 < len(toks) && toks[i+1].Text == "." && toks[i+2].Ident {
+			if _, ok := tables[text]; ok {
+				i++
+				continue
+			}
+		}
+		if ident && tok.Ident && !tok.Quoted && text == "as" && i+1 < len(toks) && toks[i+1].Ident && strings.ToLower(toks[i+1].Text) == words[len(words)-1] {
+			i++
+			continue
+		}
+
+		words = append(words, text)
+		ident = tok.Ident
+	}
+	return strings.Join(words, " ")
+}
+
+// triggersByName returns the triggers in the statements, along with their
+// names qualified by their databases in the order that they appear in
+func triggersByName(stmts model.Stmts) (map[string]model.Trigger, []string) {
+	m := make(map[string]model.Trigger)
+	var names []string
+	for _, stmt := range stmts {
+		if trigger, ok := stmt.(model.Trigger); ok {
+			name := qualifiedName(trigger.Schema(), trigger.Name())
+			m[name] = trigger
+			names = append(names, name)
+		}
+	}
+	return m, names
+}
+
+// triggers cannot be altered. triggers that have been removed or modified
+// are dropped in the order that they appear in the old schema
+func dropTriggers(ctx *diffCtx) ([]Change, error) {
+	toTriggers, _ := triggersByName(ctx.to)
+	fromTriggers, names := triggersByName(ctx.from)
+
+	var changes []Change
+	for _, name := range names {
+		if after, ok := toTriggers[name]; !ok || !sameTrigger(fromTriggers[name], after) {
+			changes = append(changes, &TriggerDropped{Trigger: fromTriggers[name]})
+		}
+	}
+	return changes, nil
+}
+
+// triggers that have been added or modified are created in the order
+// that they appear in the new schema
+func createTriggers(ctx *diffCtx) ([]Change, error) {
+	fromTriggers, _ := triggersByName(ctx.from)
+	toTriggers, names := triggersByName(ctx.to)
+
+	var changes []Change
+	for _, name := range names {
+		if before, ok := fromTriggers[name]; !ok || !sameTrigger(before, toTriggers[name]) {
+			changes = append(changes, &TriggerAdded{Trigger: toTriggers[name]})
+		}
+	}
+	return changes, nil
+}
+
+// sameTrigger returns true if the triggers are defined the same way.
+// The definers are only compared if both are specified, and the order
+// of the triggers is not compared, as it is not shown by the server
+func sameTrigger(before, after model.Trigger) bool {
+	if before.HasDefiner() && after.HasDefiner() && before.Definer() != after.Definer() {
+		return false
+	}
+	return before.Timing() == after.Timing() &&
+		before.Event() == after.Event() &&
+		before.TableName() == after.TableName() &&
+		before.Body() == after.Body()
+}
+
+// routinesByID returns the routines in the statements, along with their
+// IDs in the order that they appear in. Procedures and functions may have
+// the same names, and the IDs include the databases of the routines
+func routinesByID(stmts model.Stmts) (map[string]model.Routine, []string) {
+	m := make(map[string]model.Routine)
+	var ids []string
+	for _, stmt := range stmts {
+		if routine, ok := stmt.(model.Routine); ok {
+			m[routine.ID()] = routine
+			ids = append(ids, routine.ID())
+		}
+	}
+	return m, ids
+}
+
+// routines are dropped and created again when they are modified, as
+// ALTER PROCEDURE and ALTER FUNCTION can only change the characteristics.
+// routines that have been removed or modified are dropped in the order
+// that they appear in the old schema
+func dropRoutines(ctx *diffCtx) ([]Change, error) {
+	toRoutines, _ := routinesByID(ctx.to)
+	fromRoutines, ids := routinesByID(ctx.from)
+
+	var changes []Change
+	for _, id := range ids {
+		if after, ok := toRoutines[id]; !ok || !sameRoutine(fromRoutines[id], after) {
+			changes = append(changes, &RoutineDropped{Routine: fromRoutines[id]})
+		}
+	}
+	return changes, nil
+}
+
+// routines that have been added or modified are created in the order
+// that they appear in the new schema
+func createRoutines(ctx *diffCtx) ([]Change, error) {
+	fromRoutines, _ := routinesByID(ctx.from)
+	toRoutines, ids := routinesByID(ctx.to)
+
+	var changes []Change
+	for _, id := range ids {
+		if before, ok := fromRoutines[id]; !ok || !sameRoutine(before, toRoutines[id]) {
+			changes = append(changes, &RoutineAdded{Routine: toRoutines[id]})
+		}
+	}
+	return changes, nil
+}
+
+// sameRoutine returns true if the routines are defined the same way.
+// Characteristics that are set to their default values are the same as
+// characteristics that are not given, and the definers are only compared
+// if both are specified
+func sameRoutine(before, after model.Routine) bool {
+	option := func(ok bool, v, def string) string {
+		if !ok {
+			return def
+		}
+		return v
+	}
+
+	if before.HasDefiner() && after.HasDefiner() && before.Definer() != after.Definer() {
+		return false
+	}
+	if option(before.HasDataAccess(), before.DataAccess(), "CONTAINS SQL") != option(after.HasDataAccess(), after.DataAccess(), "CONTAINS SQL") {
+		return false
+	}
+	if option(before.HasSQLSecurity(), before.SQLSecurity(), "DEFINER") != option(after.HasSQLSecurity(), after.SQLSecurity(), "DEFINER") {
+		return false
+	}
+	if option(before.HasComment(), before.Comment(), "") != option(after.HasComment(), after.Comment(), "") {
+		return false
+	}
+
+	var beforeParams, afterParams []string
+	for param := range before.Parameters() {
+		beforeParams = append(beforeParams, param.Direction()+" "+param.Name()+" "+param.Type())
+	}
+	for param := range after.Parameters() {
+		afterParams = append(afterParams, param.Direction()+" "+param.Name()+" "+param.Type())
+	}
+	return reflect.DeepEqual(beforeParams, afterParams) &&
+		before.Returns() == after.Returns() &&
+		before.IsDeterministic() == after.IsDeterministic() &&
+		before.Body() == after.Body()
+}
 
-	ids := ctx.toSet.Difference(ctx.fromSet)
-	for _, id := range ids.ToSlice() {
-		// Lookup the corresponding statement, and add its SQL
-		stmt, ok := ctx.to.Lookup(id.(string))
+// eventsByName returns the events in the statements, along with their
+// names qualified by their databases in the order that they appear in
+func eventsByName(stmts model.Stmts) (map[string]model.Event, []string) {
+	m := make(map[string]model.Event)
+	var names []string
+	for _, stmt := range stmts {
+		if event, ok := stmt.(model.Event); ok {
+			name := qualifiedName(event.Schema(), event.Name())
+			m[name] = event
+			names = append(names, name)
+		}
+	}
+	return m, names
+}
+
+// events are dropped in the order that they appear in the old schema
+func dropEvents(ctx *diffCtx) ([]Change, error) {
+	toEvents, _ := eventsByName(ctx.to)
+	fromEvents, names := eventsByName(ctx.from)
+
+	var changes []Change
+	for _, name := range names {
+		if _, ok := toEvents[name]; !ok {
+			changes = append(changes, &EventDropped{Event: fromEvents[name]})
+		}
+	}
+	return changes, nil
+}
+
+// events are created in the order that they appear in the new schema
+func createEvents(ctx *diffCtx) ([]Change, error) {
+	fromEvents, _ := eventsByName(ctx.from)
+	toEvents, names := eventsByName(ctx.to)
+
+	var changes []Change
+	for _, name := range names {
+		if _, ok := fromEvents[name]; !ok {
+			changes = append(changes, &EventAdded{Event: toEvents[name]})
+		}
+	}
+	return changes, nil
+}
+
+// events can be modified in place by ALTER EVENT
+func alterEvents(ctx *diffCtx) ([]Change, error) {
+	fromEvents, _ := eventsByName(ctx.from)
+	toEvents, names := eventsByName(ctx.to)
+
+	var changes []Change
+	for _, name := range names {
+		before, ok := fromEvents[name]
 		if !ok {
-			return 0, errors.Errorf(`failed to lookup table %s`, id)
+			continue
+		}
+		if after := toEvents[name]; !sameEvent(before, after) {
+			changes = append(changes, &EventModified{Before: before, After: after})
+		}
+	}
+	return changes, nil
+}
+
+// sameEventSchedule returns true if the events are scheduled the same
+// way. The server fills in STARTS for recurring events when it is not
+// specified, so the start times are only compared if both are specified
+func sameEventSchedule(before, after model.Event) bool {
+	if before.HasAt() != after.HasAt() {
+		return false
+	}
+	if before.HasAt() {
+		return before.At() == after.At()
+	}
+	if before.HasStarts() && after.HasStarts() && before.Starts() != after.Starts() {
+		return false
+	}
+	return before.Every() == after.Every() &&
+		before.HasEnds() == after.HasEnds() &&
+		before.Ends() == after.Ends()
+}
+
+// eventStatus returns the status of the event, which is enabled unless
+// specified otherwise
+func eventStatus(event model.Event) string {
+	if event.HasStatus() {
+		return event.Status()
+	}
+	return "ENABLE"
+}
+
+// sameEvent returns true if the events are defined the same way. The
+// definers are only compared if both are specified
+func sameEvent(before, after model.Event) bool {
+	if before.HasDefiner() && after.HasDefiner() && before.Definer() != after.Definer() {
+		return false
+	}
+	return sameEventSchedule(before, after) &&
+		before.IsPreserve() == after.IsPreserve() &&
+		eventStatus(before) == eventStatus(after) &&
+		before.Comment() == after.Comment() &&
+		before.Body() == after.Body()
+}
+
+// databasesByName returns the databases in the statements, along with
+// their names in the order that they appear in
+func databasesByName(stmts model.Stmts) (map[string]model.Database, []string) {
+	m := make(map[string]model.Database)
+	var names []string
+	for _, stmt := range stmts {
+		if database, ok := stmt.(model.Database); ok {
+			m[database.Name()] = database
+			names = append(names, database.Name())
 		}
+	}
+	return m, names
+}
+
+// diffsDatabases returns true if the databases are created and dropped.
+// This is only the case if both schemas declare their databases. A schema
+// that does not, such as the schema of the database that a DSN is bound
+// to, says nothing about which databases exist, so a schema file that
+// declares the database that it belongs to does not drop or create it
+func diffsDatabases(ctx *diffCtx) bool {
+	_, from := databasesByName(ctx.from)
+	_, to := databasesByName(ctx.to)
+	return len(from) > 0 && len(to) > 0
+}
 
-		if buf.Len() > 0 {
-			buf.WriteByte('\n')
+// databases are dropped in the order that they appear in the old schema
+func dropDatabases(ctx *diffCtx) ([]Change, error) {
+	if !diffsDatabases(ctx) {
+		return nil, nil
+	}
+	toDatabases, _ := databasesByName(ctx.to)
+	fromDatabases, names := databasesByName(ctx.from)
+
+	var changes []Change
+	for _, name := range names {
+		if _, ok := toDatabases[name]; !ok {
+			changes = append(changes, &DatabaseDropped{Database: fromDatabases[name]})
 		}
+	}
+	return changes, nil
+}
+
+// databases are created in the order that they appear in the new schema
+func createDatabases(ctx *diffCtx) ([]Change, error) {
+	if !diffsDatabases(ctx) {
+		return nil, nil
+	}
+	fromDatabases, _ := databasesByName(ctx.from)
+	toDatabases, names := databasesByName(ctx.to)
 
-		if err := format.SQL(&buf, stmt); err != nil {
-			return 0, err
+	var changes []Change
+	for _, name := range names {
+		if _, ok := fromDatabases[name]; !ok {
+			changes = append(changes, &DatabaseAdded{Database: toDatabases[name]})
 		}
-		buf.WriteByte(';')
 	}
-	return buf.WriteTo(dst)
+	return changes, nil
+}
+
+// databases can be modified in place by ALTER DATABASE
+func alterDatabases(ctx *diffCtx) ([]Change, error) {
+	fromDatabases, _ := databasesByName(ctx.from)
+	toDatabases, names := databasesByName(ctx.to)
+
+	var changes []Change
+	for _, name := range names {
+		before, ok := fromDatabases[name]
+		if !ok {
+			continue
+		}
+		if after := toDatabases[name]; len(alterDatabaseOptions(before, after)) > 0 {
+			changes = append(changes, &DatabaseModified{Before: before, After: after})
+		}
+	}
+	return changes, nil
+}
+
+// alterDatabaseOptions returns the options of the ALTER DATABASE
+// statement that changes the database from before to after. The options
+// that are not specified in after are left alone, as the server fills
+// them in with its own defaults, and there is no way to reset them
+func alterDatabaseOptions(before, after model.Database) []string {
+	var options []string
+	if after.HasCharacterSet() && !strings.EqualFold(before.CharacterSet(), after.CharacterSet()) {
+		options = append(options, "DEFAULT CHARACTER SET = "+after.CharacterSet())
+	}
+	if after.HasCollation() && !strings.EqualFold(before.Collation(), after.Collation()) {
+		options = append(options, "DEFAULT COLLATE = "+after.Collation())
+	}
+	if after.HasEncryption() && before.Encryption() != after.Encryption() {
+		options = append(options, "ENCRYPTION = '"+after.Encryption()+"'")
+	}
+	return options
 }
 
 type alterCtx struct {
@@ -195,8 +942,28 @@ type alterCtx struct {
 	toColumns   mapset.Set
 	fromIndexes mapset.Set
 	toIndexes   mapset.Set
+	fromChecks  mapset.Set
+	toChecks    mapset.Set
 	from        model.Table
 	to          model.Table
+
+	// names of the table options that should not be compared
+	ignoredTableOptions map[string]struct{}
+
+	// columns and indexes that have been renamed, keyed by their new
+	// names. the values are the columns and indexes in the old schema
+	renamedColumns map[string]model.TableColumn
+	renamedIndexes map[string]model.Index
+
+	// IDs of the foreign keys that have already been dropped, along
+	// with the tables that they refer to
+	predropped map[string]struct{}
+
+	// columns that exist in both tables, but cannot be modified in place.
+	// these are dropped and added again, along with the indexes that
+	// refer to them
+	recreateColumns mapset.Set
+	recreateIndexes mapset.Set
 }
 
 func newAlterCtx(from, to model.Table) *alterCtx {
@@ -220,86 +987,199 @@ func newAlterCtx(from, to model.Table) *alterCtx {
 		toIndexes.Add(idx.ID())
 	}
 
+	recreateColumns := mapset.NewSet()
+	recreateColumnNames := make(map[string]struct{})
+	for _, v := range fromColumns.Intersect(toColumns).ToSlice() {
+		beforeCol, _ := from.LookupColumn(v.(string))
+		afterCol, _ := to.LookupColumn(v.(string))
+		if mustRecreateColumn(beforeCol, afterCol) {
+			recreateColumns.Add(v)
+			recreateColumnNames[afterCol.Name()] = struct{}{}
+		}
+	}
+
+	recreateIndexes := mapset.NewSet()
+	if len(recreateColumnNames) > 0 {
+		for _, v := range fromIndexes.Intersect(toIndexes).ToSlice() {
+			idx, _ := to.LookupIndex(v.(string))
+			for col := range idx.Columns() {
+				if _, ok := recreateColumnNames[col.Name()]; ok {
+					recreateIndexes.Add(v)
+					break
+				}
+			}
+		}
+	}
+
+	fromChecks := mapset.NewSet()
+	for check := range from.CheckConstraints() {
+		fromChecks.Add(check.ID())
+	}
+
+	toChecks := mapset.NewSet()
+	for check := range to.CheckConstraints() {
+		toChecks.Add(check.ID())
+	}
+
 	return &alterCtx{
 		fromColumns: fromColumns,
 		toColumns:   toColumns,
 		fromIndexes: fromIndexes,
 		toIndexes:   toIndexes,
+		fromChecks:  fromChecks,
+		toChecks:    toChecks,
 		from:        from,
 		to:          to,
+
+		recreateColumns: recreateColumns,
+		recreateIndexes: recreateIndexes,
 	}
 }
 
-func alterTables(ctx *diffCtx, dst io.Writer) (int64, error) {
-	procs := []func(*alterCtx, io.Writer) (int64, error){
+// sameColumn returns true if the columns are defined the same way. The
+// generation expressions are compared after util.NormalizeExpression, as
+// the server shows them quoted and parenthesized
+func sameColumn(before, after model.TableColumn) bool {
+	if before.IsGenerated() && after.IsGenerated() {
+		before = before.Clone().SetGenerationExpression(util.NormalizeExpression(before.GenerationExpression()))
+		after = after.Clone().SetGenerationExpression(util.NormalizeExpression(after.GenerationExpression()))
+	}
+	return reflect.DeepEqual(before, after)
+}
+
+// mustRecreateColumn returns true if the column cannot be changed from
+// `before` to `after` in place. MySQL does not allow changing the storage
+// of a generated column, nor turning a regular column into a VIRTUAL
+// column (or vice versa) using CHANGE COLUMN.
+func mustRecreateColumn(before, after model.TableColumn) bool {
+	switch {
+	case before.IsGenerated() && after.IsGenerated():
+		return before.GenerationStorage() != after.GenerationStorage()
+	case after.IsGenerated():
+		return after.GenerationStorage() != model.GenerationStorageStored
+	case before.IsGenerated():
+		return before.GenerationStorage() != model.GenerationStorageStored
+	}
+	return false
+}
+
+// withDefaultCollation returns a copy of the table that has both the
+// DEFAULT CHARACTER SET and the DEFAULT COLLATE options, if it only has
+// one of them. The missing one is filled in the same way as the server
+// does, so that a table that only names its character set compares equal
+// to the same table dumped by the server, which names both
+func withDefaultCollation(table model.Table, version schemalex.ServerVersion) model.Table {
+	var charset, collation model.TableOption
+	for opt := range table.Options() {
+		switch opt.Key() {
+		case "DEFAULT CHARACTER SET":
+			charset = opt
+		case "DEFAULT COLLATE":
+			collation = opt
+		}
+	}
+
+	var missing model.TableOption
+	switch {
+	case charset != nil && collation == nil:
+		name := version.DefaultCollation(charset.Value())
+		if name == "" {
+			return table
+		}
+		missing = model.NewTableOption("DEFAULT COLLATE", name, false)
+	case charset == nil && collation != nil:
+		i := strings.IndexByte(collation.Value(), '_')
+		if i < 0 {
+			return table
+		}
+		missing = model.NewTableOption("DEFAULT CHARACTER SET", collation.Value()[:i], false)
+	default:
+		return table
+	}
+
+	// withoutForeignKeys with no table names makes a plain copy
+	tbl, _ := withoutForeignKeys(table, nil)
+	tbl.AddOption(missing)
+	return tbl
+}
+
+func alterTables(ctx *diffCtx) ([]Change, error) {
+	procs := []func(*alterCtx) ([]Change, error){
+		alterTableOptions,
+		dropSystemVersioning,
+		dropTableCheckConstraints,
 		dropTableIndexes,
+		renameTableIndexes,
 		dropTableColumns,
+		renameTableColumns,
 		addTableColumns,
 		alterTableColumns,
 		addTableIndexes,
+		addTableCheckConstraints,
+		addSystemVersioning,
+		alterTablePartitions,
 	}
 
-	ids := ctx.toSet.Intersect(ctx.fromSet)
-	var buf bytes.Buffer
-	for _, id := range ids.ToSlice() {
+	var changes []Change
+	ids := sortedIDs(ctx.toSet.Intersect(ctx.fromSet), tableOrderKey(ctx.toOrder))
+	for _, id := range ids {
 		var stmt model.Stmt
 		var ok bool
 
-		stmt, ok = ctx.from.Lookup(id.(string))
+		stmt, ok = ctx.from.Lookup(id)
 		if !ok {
-			return 0, errors.Errorf(`table '%s' not found in old schema (alter table)`, id)
+			return nil, errors.Errorf(`table '%s' not found in old schema (alter table)`, id)
 		}
 		beforeStmt := stmt.(model.Table)
 
-		stmt, ok = ctx.to.Lookup(id.(string))
+		stmt, ok = ctx.to.Lookup(id)
 		if !ok {
-			return 0, errors.Errorf(`table '%s' not found in new schema (alter table)`, id)
+			return nil, errors.Errorf(`table '%s' not found in new schema (alter table)`, id)
 		}
 		afterStmt := stmt.(model.Table)
 
-		var pbuf bytes.Buffer
+		if ctx.version != nil {
+			beforeStmt = withDefaultCollation(beforeStmt, *ctx.version)
+			afterStmt = withDefaultCollation(afterStmt, *ctx.version)
+		}
+
 		alterCtx := newAlterCtx(beforeStmt, afterStmt)
+		alterCtx.ignoredTableOptions = ctx.ignoredTableOptions
+		alterCtx.renamedColumns = ctx.renames.renamedColumns[tableKey(afterStmt)]
+		alterCtx.renamedIndexes = ctx.renames.renamedIndexes[tableKey(afterStmt)]
+		alterCtx.predropped = ctx.predropped[tableKey(afterStmt)]
 		for _, p := range procs {
-			n, err := p(alterCtx, &pbuf)
+			l, err := p(alterCtx)
 			if err != nil {
-				return 0, errors.Wrap(err, `failed to generate alter table`)
+				return nil, errors.Wrap(err, `failed to generate alter table`)
 			}
-
-			if buf.Len() > 0 && n > 0 {
-				buf.WriteByte('\n')
-			}
-			pbuf.WriteTo(&buf)
+			changes = append(changes, l...)
 		}
 	}
 
-	return buf.WriteTo(dst)
+	return changes, nil
 }
 
-func dropTableColumns(ctx *alterCtx, dst io.Writer) (int64, error) {
-	columnNames := ctx.fromColumns.Difference(ctx.toColumns)
-
-	var buf bytes.Buffer
-	for _, columnName := range columnNames.ToSlice() {
-		if buf.Len() > 0 {
-			buf.WriteByte('\n')
-		}
-		buf.WriteString("ALTER TABLE `")
-		buf.WriteString(ctx.from.Name())
-		buf.WriteString("` DROP COLUMN `")
-		col, ok := ctx.from.LookupColumn(columnName.(string))
+func dropTableColumns(ctx *alterCtx) ([]Change, error) {
+	var changes []Change
+	columnNames := ctx.fromColumns.Difference(ctx.toColumns).Union(ctx.recreateColumns)
+	for _, columnName := range sortedIDs(columnNames, columnOrderKey(ctx.from)) {
+		col, ok := ctx.from.LookupColumn(columnName)
 		if !ok {
-			return 0, errors.Errorf(`failed to lookup column %s`, columnName)
+			return nil, errors.Errorf(`failed to lookup column %s`, columnName)
 		}
-
-		buf.WriteString(col.Name())
-		buf.WriteString("`;")
+		changes = append(changes, &ColumnDropped{
+			Table:  ctx.to.Name(),
+			Schema: ctx.to.Schema(),
+			Column: col,
+		})
 	}
 
-	return buf.WriteTo(dst)
+	return changes, nil
 }
 
-func addTableColumns(ctx *alterCtx, dst io.Writer) (int64, error) {
-	var buf bytes.Buffer
+func addTableColumns(ctx *alterCtx) ([]Change, error) {
+	var changes []Change
 
 	beforeToNext := make(map[string]string) // lookup next column
 	nextToBefore := make(map[string]string) // lookup before column
@@ -308,12 +1188,12 @@ func addTableColumns(ctx *alterCtx, dst io.Writer) (int64, error) {
 	// we always start adding with a column that has a either no before
 	// columns, or one that already exists in the database
 	var firstColumn model.TableColumn
-	for _, v := range ctx.toColumns.Difference(ctx.fromColumns).ToSlice() {
+	for _, v := range ctx.toColumns.Difference(ctx.fromColumns).Union(ctx.recreateColumns).ToSlice() {
 		columnName := v.(string)
 		// find the before-column for each.
 		col, ok := ctx.to.LookupColumn(columnName)
 		if !ok {
-			return 0, errors.Errorf(`failed to lookup column %s`, columnName)
+			return nil, errors.Errorf(`failed to lookup column %s`, columnName)
 		}
 
 		beforeCol, hasBeforeCol := ctx.to.LookupColumnBefore(col.ID())
@@ -331,14 +1211,17 @@ func addTableColumns(ctx *alterCtx, dst io.Writer) (int64, error) {
 
 	// First column is always safe to add
 	if firstColumn != nil {
-		writeAddColumn(ctx, &buf, firstColumn.ID())
+		l, err := addColumnChanges(ctx, firstColumn.ID())
+		if err != nil {
+			return nil, err
+		}
+		changes = append(changes, l...)
 	}
 
 	var columnNames []string
 	// Find columns that have before columns which existed in both
 	// from and to tables
-	for _, v := range ctx.toColumns.Intersect(ctx.fromColumns).ToSlice() {
-		columnName := v.(string)
+	for _, columnName := range sortedIDs(ctx.toColumns.Intersect(ctx.fromColumns).Difference(ctx.recreateColumns), columnOrderKey(ctx.to)) {
 		if nextColumnName, ok := beforeToNext[columnName]; ok {
 			delete(beforeToNext, columnName)
 			delete(nextToBefore, nextColumnName)
@@ -347,8 +1230,11 @@ func addTableColumns(ctx *alterCtx, dst io.Writer) (int64, error) {
 	}
 
 	if len(columnNames) > 0 {
-		sort.Strings(columnNames)
-		writeAddColumn(ctx, &buf, columnNames...)
+		l, err := addColumnChanges(ctx, columnNames...)
+		if err != nil {
+			return nil, err
+		}
+		changes = append(changes, l...)
 	}
 
 	// Finally, we process the remaining columns.
@@ -366,178 +1252,306 @@ func addTableColumns(ctx *alterCtx, dst io.Writer) (int64, error) {
 			jcol, _ := ctx.to.LookupColumnOrder(columnNames[j])
 			return icol < jcol
 		})
-		writeAddColumn(ctx, &buf, columnNames...)
+		l, err := addColumnChanges(ctx, columnNames...)
+		if err != nil {
+			return nil, err
+		}
+		changes = append(changes, l...)
 	}
-	return buf.WriteTo(dst)
+
+	return changes, nil
 }
 
-func writeAddColumn(ctx *alterCtx, buf *bytes.Buffer, columnNames ...string) error {
+func addColumnChanges(ctx *alterCtx, columnNames ...string) ([]Change, error) {
+	var changes []Change
 	for _, columnName := range columnNames {
 		stmt, ok := ctx.to.LookupColumn(columnName)
 		if !ok {
-			return errors.Errorf(`failed to lookup column %s`, columnName)
+			return nil, errors.Errorf(`failed to lookup column %s`, columnName)
 		}
 
-		beforeCol, hasBeforeCol := ctx.to.LookupColumnBefore(stmt.ID())
-		if buf.Len() > 0 {
-			buf.WriteByte('\n')
+		change := &ColumnAdded{
+			Table:  ctx.to.Name(),
+			Schema: ctx.to.Schema(),
+			Column: stmt,
 		}
-		buf.WriteString("ALTER TABLE `")
-		buf.WriteString(ctx.from.Name())
-		buf.WriteString("` ADD COLUMN ")
-		if err := format.SQL(buf, stmt); err != nil {
-			return err
+		if beforeCol, ok := ctx.to.LookupColumnBefore(stmt.ID()); ok {
+			change.AfterColumn = beforeCol.Name()
 		}
-		if hasBeforeCol {
-			buf.WriteString(" AFTER `")
-			buf.WriteString(beforeCol.Name())
-			buf.WriteString("`")
-		} else {
-			buf.WriteString(" FIRST")
-		}
-
-		buf.WriteByte(';')
+		changes = append(changes, change)
 	}
-	return nil
+	return changes, nil
 }
 
-func alterTableColumns(ctx *alterCtx, dst io.Writer) (int64, error) {
-	var buf bytes.Buffer
-	columnNames := ctx.toColumns.Intersect(ctx.fromColumns)
-	for _, columnName := range columnNames.ToSlice() {
-		beforeColumnStmt, ok := ctx.from.LookupColumn(columnName.(string))
+func alterTableColumns(ctx *alterCtx) ([]Change, error) {
+	var changes []Change
+	columnNames := ctx.toColumns.Intersect(ctx.fromColumns).Difference(ctx.recreateColumns)
+	for _, columnName := range sortedIDs(columnNames, columnOrderKey(ctx.to)) {
+		beforeColumnStmt, ok := ctx.from.LookupColumn(columnName)
 		if !ok {
-			return 0, errors.Errorf(`column %s not found in old schema`, columnName)
+			return nil, errors.Errorf(`column %s not found in old schema`, columnName)
 		}
 
-		afterColumnStmt, ok := ctx.to.LookupColumn(columnName.(string))
+		afterColumnStmt, ok := ctx.to.LookupColumn(columnName)
 		if !ok {
-			return 0, errors.Errorf(`column %s not found in new schema`, columnName)
+			return nil, errors.Errorf(`column %s not found in new schema`, columnName)
 		}
 
-		if reflect.DeepEqual(beforeColumnStmt, afterColumnStmt) {
+		if sameColumn(beforeColumnStmt, afterColumnStmt) {
 			continue
 		}
-
-		if buf.Len() > 0 {
-			buf.WriteByte('\n')
-		}
-		buf.WriteString("ALTER TABLE `")
-		buf.WriteString(ctx.from.Name())
-		buf.WriteString("` CHANGE COLUMN `")
-		buf.WriteString(afterColumnStmt.Name())
-		buf.WriteString("` ")
-		if err := format.SQL(&buf, afterColumnStmt); err != nil {
-			return 0, err
+		if _, ok := ctx.renamedColumns[afterColumnStmt.Name()]; ok {
+			// already taken care of by renameTableColumns
+			continue
 		}
-		buf.WriteByte(';')
+
+		changes = append(changes, &ColumnModified{
+			Table:  ctx.to.Name(),
+			Schema: ctx.to.Schema(),
+			Before: beforeColumnStmt,
+			After:  afterColumnStmt,
+		})
 	}
 
-	return buf.WriteTo(dst)
+	return changes, nil
 }
 
-func dropTableIndexes(ctx *alterCtx, dst io.Writer) (int64, error) {
-	var buf bytes.Buffer
-	indexes := ctx.fromIndexes.Difference(ctx.toIndexes)
+func dropTableIndexes(ctx *alterCtx) ([]Change, error) {
+	indexes := ctx.fromIndexes.Difference(ctx.toIndexes).Union(ctx.recreateIndexes)
+
 	// drop index after drop constraint.
 	// because cannot drop index if needed in a foreign key constraint
-	lazy := make([]model.Index, 0, indexes.Cardinality())
-	for _, index := range indexes.ToSlice() {
-		indexStmt, ok := ctx.from.LookupIndex(index.(string))
+	var changes []Change
+	lazy := make([]Change, 0, indexes.Cardinality())
+	for _, index := range sortedIDs(indexes, indexOrderKey(ctx.from)) {
+		if _, ok := ctx.predropped[index]; ok {
+			continue
+		}
+		indexStmt, ok := ctx.from.LookupIndex(index)
 		if !ok {
-			return 0, errors.Errorf(`index '%s' not found in old schema (drop index)`, index)
+			return nil, errors.Errorf(`index '%s' not found in old schema (drop index)`, index)
 		}
 
+		change := &IndexDropped{
+			Table:  ctx.to.Name(),
+			Schema: ctx.to.Schema(),
+			Index:  indexStmt,
+		}
 		if indexStmt.IsPrimaryKey() {
-			if buf.Len() > 0 {
-				buf.WriteByte('\n')
-			}
-			buf.WriteString("ALTER TABLE `")
-			buf.WriteString(ctx.from.Name())
-			buf.WriteString("` DROP PRIMARY KEY;")
+			changes = append(changes, change)
 			continue
 		}
 
 		if !indexStmt.HasName() && !indexStmt.HasSymbol() {
-			return 0, errors.Errorf("can not drop index without name: %s", indexStmt.ID())
+			return nil, errors.Errorf("can not drop index without name: %s", indexStmt.ID())
 		}
+
 		if !indexStmt.IsForeignKey() {
-			lazy = append(lazy, indexStmt)
+			lazy = append(lazy, change)
 			continue
 		}
-
-		if buf.Len() > 0 {
-			buf.WriteByte('\n')
-		}
-		buf.WriteString("ALTER TABLE `")
-		buf.WriteString(ctx.from.Name())
-		buf.WriteString("` DROP FOREIGN KEY `")
-		if indexStmt.HasSymbol() {
-			buf.WriteString(indexStmt.Symbol())
-		} else {
-			buf.WriteString(indexStmt.Name())
-		}
-		buf.WriteString("`;")
-	}
-	// drop index after drop CONSTRAINT
-	for _, indexStmt := range lazy {
-		if buf.Len() > 0 {
-			buf.WriteByte('\n')
-		}
-		buf.WriteString("ALTER TABLE `")
-		buf.WriteString(ctx.from.Name())
-		buf.WriteString("` DROP INDEX `")
-		if !indexStmt.HasName() {
-			buf.WriteString(indexStmt.Symbol())
-		} else {
-			buf.WriteString(indexStmt.Name())
-		}
-
-		buf.WriteString("`;")
+		changes = append(changes, change)
 	}
 
-	return buf.WriteTo(dst)
+	// drop index after drop CONSTRAINT
+	return append(changes, lazy...), nil
 }
 
-func addTableIndexes(ctx *alterCtx, dst io.Writer) (int64, error) {
-	var buf bytes.Buffer
-	indexes := ctx.toIndexes.Difference(ctx.fromIndexes)
+func addTableIndexes(ctx *alterCtx) ([]Change, error) {
+	indexes := ctx.toIndexes.Difference(ctx.fromIndexes).Union(ctx.recreateIndexes)
+
 	// add index before add foreign key.
 	// because cannot add index if create implicitly index by foreign key.
-	lazy := make([]model.Index, 0, indexes.Cardinality())
-	for _, index := range indexes.ToSlice() {
-		indexStmt, ok := ctx.to.LookupIndex(index.(string))
+	var changes []Change
+	lazy := make([]Change, 0, indexes.Cardinality())
+	for _, index := range sortedIDs(indexes, indexOrderKey(ctx.to)) {
+		indexStmt, ok := ctx.to.LookupIndex(index)
 		if !ok {
-			return 0, errors.Errorf(`index '%s' not found in old schema (add index)`, index)
+			return nil, errors.Errorf(`index '%s' not found in old schema (add index)`, index)
+		}
+
+		change := &IndexAdded{
+			Table:  ctx.to.Name(),
+			Schema: ctx.to.Schema(),
+			Index:  indexStmt,
 		}
 		if indexStmt.IsForeignKey() {
-			lazy = append(lazy, indexStmt)
+			lazy = append(lazy, change)
+			continue
+		}
+		changes = append(changes, change)
+	}
+
+	return append(changes, lazy...), nil
+}
+
+// resetTableOptions lists the values that restore a table option to its
+// default, for the options that can be reset once they have been set
+var resetTableOptions = map[string]model.TableOption{
+	"COMMENT":            model.NewTableOption("COMMENT", "", true),
+	"CONNECTION":         model.NewTableOption("CONNECTION", "", true),
+	"KEY_BLOCK_SIZE":     model.NewTableOption("KEY_BLOCK_SIZE", "0", false),
+	"PACK_KEYS":          model.NewTableOption("PACK_KEYS", "DEFAULT", false),
+	"ROW_FORMAT":         model.NewTableOption("ROW_FORMAT", "DEFAULT", false),
+	"STATS_AUTO_RECALC":  model.NewTableOption("STATS_AUTO_RECALC", "DEFAULT", false),
+	"STATS_PERSISTENT":   model.NewTableOption("STATS_PERSISTENT", "DEFAULT", false),
+	"STATS_SAMPLE_PAGES": model.NewTableOption("STATS_SAMPLE_PAGES", "DEFAULT", false),
+}
+
+func sameTableOption(before, after model.TableOption) bool {
+	if before.NeedQuotes() || after.NeedQuotes() {
+		return before.Value() == after.Value()
+	}
+	// unquoted values are names (engines, charsets, row formats...)
+	// or numbers, and neither of them are case sensitive
+	return strings.EqualFold(before.Value(), after.Value())
+}
+
+// table options are altered before anything else, and all of the changes
+// are combined into a single statement, as each of them may require the
+// table to be rebuilt
+func alterTableOptions(ctx *alterCtx) ([]Change, error) {
+	var changed []model.TableOption
+
+	fromOptions := make(map[string]model.TableOption)
+	for opt := range ctx.from.Options() {
+		fromOptions[opt.Key()] = opt
+	}
+
+	toOptions := make(map[string]struct{})
+	for opt := range ctx.to.Options() {
+		toOptions[opt.Key()] = struct{}{}
+		if _, ok := ctx.ignoredTableOptions[opt.Key()]; ok {
+			continue
+		}
+		if before, ok := fromOptions[opt.Key()]; ok && sameTableOption(before, opt) {
+			continue
+		}
+		changed = append(changed, opt)
+	}
+
+	for opt := range ctx.from.Options() {
+		if _, ok := ctx.ignoredTableOptions[opt.Key()]; ok {
 			continue
 		}
-		if buf.Len() > 0 {
-			buf.WriteByte('\n')
+		if _, ok := toOptions[opt.Key()]; ok {
+			continue
 		}
-		buf.WriteString("ALTER TABLE `")
-		buf.WriteString(ctx.from.Name())
-		buf.WriteString("` ADD ")
-		if err := format.SQL(&buf, indexStmt); err != nil {
-			return 0, err
+		// options that were removed are reset to their defaults where
+		// possible. the rest (ENGINE, DEFAULT CHARACTER SET, ...) depend
+		// on the server configuration, so we leave them alone
+		if reset, ok := resetTableOptions[opt.Key()]; ok && !sameTableOption(opt, reset) {
+			changed = append(changed, reset)
 		}
-		buf.WriteByte(';')
 	}
 
-	for _, indexStmt := range lazy {
-		if buf.Len() > 0 {
-			buf.WriteByte('\n')
+	if len(changed) == 0 {
+		return nil, nil
+	}
+
+	return []Change{&TableOptionsModified{
+		Table:   ctx.to.Name(),
+		Schema:  ctx.to.Schema(),
+		Options: changed,
+	}}, nil
+}
+
+// check constraints are dropped before indexes and columns, because they may
+// refer to columns that are about to be dropped or modified
+func dropTableCheckConstraints(ctx *alterCtx) ([]Change, error) {
+	var changes []Change
+	checks := ctx.fromChecks.Difference(ctx.toChecks)
+	for _, check := range sortedIDs(checks, checkOrderKey(ctx.from)) {
+		checkStmt, ok := ctx.from.LookupCheckConstraint(check)
+		if !ok {
+			return nil, errors.Errorf(`check constraint '%s' not found in old schema (drop check)`, check)
 		}
-		buf.WriteString("ALTER TABLE `")
-		buf.WriteString(ctx.from.Name())
-		buf.WriteString("` ADD ")
-		if err := format.SQL(&buf, indexStmt); err != nil {
-			return 0, err
+		if !checkStmt.HasName() {
+			return nil, errors.Errorf("can not drop check constraint without name: %s", checkStmt.ID())
 		}
-		buf.WriteByte(';')
+
+		changes = append(changes, &CheckConstraintDropped{
+			Table:           ctx.to.Name(),
+			Schema:          ctx.to.Schema(),
+			CheckConstraint: checkStmt,
+		})
 	}
 
-	return buf.WriteTo(dst)
+	return changes, nil
+}
+
+// check constraints are added after everything else, because they may
+// refer to columns that have just been added or modified
+func addTableCheckConstraints(ctx *alterCtx) ([]Change, error) {
+	var changes []Change
+	checks := ctx.toChecks.Difference(ctx.fromChecks)
+	for _, check := range sortedIDs(checks, checkOrderKey(ctx.to)) {
+		checkStmt, ok := ctx.to.LookupCheckConstraint(check)
+		if !ok {
+			return nil, errors.Errorf(`check constraint '%s' not found in new schema (add check)`, check)
+		}
+
+		changes = append(changes, &CheckConstraintAdded{
+			Table:           ctx.to.Name(),
+			Schema:          ctx.to.Schema(),
+			CheckConstraint: checkStmt,
+		})
+	}
+
+	return changes, nil
+}
+
+func samePartitioning(before, after model.Partitioning) bool {
+	if before.ID() != after.ID() || before.PartitionCount() != after.PartitionCount() {
+		return false
+	}
+
+	var beforeDefs, afterDefs []string
+	for def := range before.Definitions() {
+		beforeDefs = append(beforeDefs, def.ID())
+	}
+	for def := range after.Definitions() {
+		afterDefs = append(afterDefs, def.ID())
+	}
+	return reflect.DeepEqual(beforeDefs, afterDefs)
+}
+
+// system versioning is disabled before anything else, so that the table
+// can be altered without having to keep the history of the rows
+func dropSystemVersioning(ctx *alterCtx) ([]Change, error) {
+	if !ctx.from.IsSystemVersioned() || ctx.to.IsSystemVersioned() {
+		return nil, nil
+	}
+	return []Change{&SystemVersioningModified{Table: ctx.to.Name(), Schema: ctx.to.Schema()}}, nil
+}
+
+// system versioning is enabled after the table has been altered, but
+// before the table is partitioned, as the table may be partitioned by
+// SYSTEM_TIME
+func addSystemVersioning(ctx *alterCtx) ([]Change, error) {
+	if ctx.from.IsSystemVersioned() || !ctx.to.IsSystemVersioned() {
+		return nil, nil
+	}
+	return []Change{&SystemVersioningModified{Table: ctx.to.Name(), Schema: ctx.to.Schema(), Enabled: true}}, nil
+}
+
+// partitioning is altered last, because the partitioning expression
+// may refer to columns that have just been added or modified
+func alterTablePartitions(ctx *alterCtx) ([]Change, error) {
+	if !ctx.from.HasPartitioning() && !ctx.to.HasPartitioning() {
+		return nil, nil
+	}
+
+	change := &PartitioningModified{Table: ctx.to.Name(), Schema: ctx.to.Schema()}
+	if ctx.from.HasPartitioning() {
+		change.Before = ctx.from.Partitioning()
+	}
+	if ctx.to.HasPartitioning() {
+		change.After = ctx.to.Partitioning()
+	}
+
+	if change.Before != nil && change.After != nil && samePartitioning(change.Before, change.After) {
+		return nil, nil
+	}
+	return []Change{change}, nil
 }
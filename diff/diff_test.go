@@ -4,7 +4,9 @@ import (
 	"bytes"
 	"testing"
 
+	"github.com/schemalex/schemalex"
 	"github.com/schemalex/schemalex/diff"
+	"github.com/schemalex/schemalex/model"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -22,12 +24,143 @@ func TestDiff(t *testing.T) {
 			After:  "CREATE TABLE `fuga` ( `id` INTEGER NOT NULL );",
 			Expect: "DROP TABLE `hoge`;",
 		},
+		// tables are compared after the ALTER TABLE statements in the
+		// schema are applied to them
+		{
+			Before: "CREATE TABLE t (a INT NOT NULL); ALTER TABLE t ADD COLUMN b INT NOT NULL",
+			After:  "CREATE TABLE t (a INT NOT NULL)",
+			Expect: "ALTER TABLE `t` DROP COLUMN `b`;",
+		},
+		// CREATE INDEX and RENAME TABLE statements in the schema are
+		// applied to the tables as well
+		{
+			Before: "CREATE TABLE t (a INT NOT NULL, b INT NOT NULL); CREATE INDEX a ON t (a)",
+			After:  "CREATE TABLE s (a INT NOT NULL, b INT NOT NULL); RENAME TABLE s TO t; CREATE INDEX b ON t (b)",
+			Expect: "ALTER TABLE `t` DROP INDEX `a`;\nALTER TABLE `t` ADD INDEX `b` (`b`);",
+		},
 		// create table
 		{
 			Before: "CREATE TABLE `fuga` ( `id` INTEGER NOT NULL );",
 			After:  "CREATE TABLE `hoge` ( `id` INTEGER NOT NULL ) ENGINE=InnoDB DEFAULT CHARACTER SET utf8mb4 COMMENT 'table comment'; CREATE TABLE `fuga` ( `id` INTEGER NOT NULL );",
 			Expect: "CREATE TABLE `hoge` (\n`id` INT (11) NOT NULL\n) ENGINE = InnoDB, DEFAULT CHARACTER SET = utf8mb4, COMMENT = 'table comment';",
 		},
+		// create tables that are referred to by foreign keys first
+		{
+			Before: "",
+			After:  "CREATE TABLE `child` ( `id` INTEGER NOT NULL, `parent_id` INTEGER NOT NULL, CONSTRAINT `fk_parent` FOREIGN KEY (`parent_id`) REFERENCES `parent` (`id`) ); CREATE TABLE `parent` ( `id` INTEGER NOT NULL, PRIMARY KEY (`id`) );",
+			Expect: "CREATE TABLE `parent` (\n`id` INT (11) NOT NULL,\nPRIMARY KEY (`id`)\n);\nCREATE TABLE `child` (\n`id` INT (11) NOT NULL,\n`parent_id` INT (11) NOT NULL,\nINDEX `fk_parent` (`parent_id`),\nCONSTRAINT `fk_parent` FOREIGN KEY (`parent_id`) REFERENCES `parent` (`id`)\n);",
+		},
+		// drop tables that refer to other tables by foreign keys first
+		{
+			Before: "CREATE TABLE `parent` ( `id` INTEGER NOT NULL, PRIMARY KEY (`id`) ); CREATE TABLE `child` ( `id` INTEGER NOT NULL, `parent_id` INTEGER NOT NULL, CONSTRAINT `fk_parent` FOREIGN KEY (`parent_id`) REFERENCES `parent` (`id`) );",
+			After:  "",
+			Expect: "DROP TABLE `child`;\nDROP TABLE `parent`;",
+		},
+		// foreign keys of the tables that are kept are dropped before the tables that they refer to
+		{
+			Before: "CREATE TABLE `p` ( `id` INTEGER NOT NULL, PRIMARY KEY (`id`) ); CREATE TABLE `c` ( `id` INTEGER NOT NULL, `p_id` INTEGER NOT NULL, CONSTRAINT `fk` FOREIGN KEY (`p_id`) REFERENCES `p` (`id`) );",
+			After:  "CREATE TABLE `c` ( `id` INTEGER NOT NULL, `p_id` INTEGER NOT NULL );",
+			Expect: "ALTER TABLE `c` DROP FOREIGN KEY `fk`;\n\nDROP TABLE `p`;\n\nALTER TABLE `c` DROP INDEX `fk`;",
+		},
+		// foreign keys that form a cycle are added after the tables are created
+		{
+			Before: "",
+			After:  "CREATE TABLE `a` ( `id` INTEGER NOT NULL, `b_id` INTEGER NOT NULL, PRIMARY KEY (`id`), CONSTRAINT `fk_b` FOREIGN KEY (`b_id`) REFERENCES `b` (`id`) ); CREATE TABLE `b` ( `id` INTEGER NOT NULL, `a_id` INTEGER NOT NULL, PRIMARY KEY (`id`), CONSTRAINT `fk_a` FOREIGN KEY (`a_id`) REFERENCES `a` (`id`) );",
+			Expect: "CREATE TABLE `a` (\n`id` INT (11) NOT NULL,\n`b_id` INT (11) NOT NULL,\nPRIMARY KEY (`id`),\nINDEX `fk_b` (`b_id`)\n);\nCREATE TABLE `b` (\n`id` INT (11) NOT NULL,\n`a_id` INT (11) NOT NULL,\nPRIMARY KEY (`id`),\nINDEX `fk_a` (`a_id`),\nCONSTRAINT `fk_a` FOREIGN KEY (`a_id`) REFERENCES `a` (`id`)\n);\n\nALTER TABLE `a` ADD CONSTRAINT `fk_b` FOREIGN KEY (`b_id`) REFERENCES `b` (`id`);",
+		},
+		// foreign keys that form a cycle are dropped before the tables
+		{
+			Before: "CREATE TABLE `a` ( `id` INTEGER NOT NULL, `b_id` INTEGER NOT NULL, PRIMARY KEY (`id`), CONSTRAINT `fk_b` FOREIGN KEY (`b_id`) REFERENCES `b` (`id`) ); CREATE TABLE `b` ( `id` INTEGER NOT NULL, `a_id` INTEGER NOT NULL, PRIMARY KEY (`id`), CONSTRAINT `fk_a` FOREIGN KEY (`a_id`) REFERENCES `a` (`id`) );",
+			After:  "",
+			Expect: "ALTER TABLE `b` DROP FOREIGN KEY `fk_a`;\n\nDROP TABLE `a`;\nDROP TABLE `b`;",
+		},
+		// views are created after the tables, in dependency order
+		{
+			Before: "",
+			After:  "CREATE VIEW `v2` AS SELECT * FROM `v1`; CREATE VIEW v1 AS SELECT id FROM t; CREATE TABLE `t` ( `id` INTEGER NOT NULL );",
+			Expect: "CREATE TABLE `t` (\n`id` INT (11) NOT NULL\n);\n\nCREATE OR REPLACE VIEW `v1` AS SELECT id FROM t;\nCREATE OR REPLACE VIEW `v2` AS SELECT * FROM `v1`;",
+		},
+		// views are dropped before the tables, in reverse dependency order
+		{
+			Before: "CREATE TABLE `t` ( `id` INTEGER NOT NULL ); CREATE VIEW v1 AS SELECT id FROM t; CREATE VIEW v2 AS SELECT id FROM v1 WHERE 'v3' = 'v3'; CREATE VIEW v3 AS SELECT 1",
+			After:  "",
+			Expect: "DROP VIEW `v2`;\nDROP VIEW `v1`;\nDROP VIEW `v3`;\n\nDROP TABLE `t`;",
+		},
+		// modified views are replaced, and options with default values
+		// are the same as options that are not specified
+		{
+			Before: "CREATE ALGORITHM=UNDEFINED DEFINER=`root`@`%` SQL SECURITY DEFINER VIEW v1 AS SELECT 1; CREATE VIEW v2 AS SELECT 1",
+			After:  "CREATE VIEW v1 AS SELECT  1; CREATE VIEW v2 AS SELECT 2",
+			Expect: "CREATE OR REPLACE VIEW `v2` AS SELECT 2;",
+		},
+		// views are compared with the definitions returned by the server
+		{
+			Before: "CREATE ALGORITHM=UNDEFINED DEFINER=`root`@`%` SQL SECURITY DEFINER VIEW `v1` AS select `t`.`id` AS `id`,`t`.`name` AS `n` from `t` where `t`.`id` > 'A'; CREATE VIEW `v2` AS select `t`.`id` AS `id` from `t`",
+			After:  "CREATE VIEW v1 AS SELECT id, name AS n FROM t WHERE id > 'A'; CREATE VIEW v2 AS SELECT id FROM t WHERE id > 1",
+			Expect: "CREATE OR REPLACE VIEW `v2` AS SELECT id FROM t WHERE id > 1;",
+		},
+		// triggers are dropped and created again when they are modified
+		{
+			Before: "CREATE TABLE `t` ( `id` INTEGER NOT NULL ); CREATE TRIGGER a BEFORE INSERT ON t FOR EACH ROW SET NEW.id = 1; CREATE TRIGGER b BEFORE INSERT ON t FOR EACH ROW SET NEW.id = 2; CREATE TRIGGER c AFTER DELETE ON t FOR EACH ROW SET @n = 1",
+			After:  "CREATE TABLE `t` ( `id` INTEGER NOT NULL ); CREATE TRIGGER b BEFORE UPDATE ON t FOR EACH ROW SET NEW.id = 2; CREATE TRIGGER c AFTER DELETE ON t FOR EACH ROW SET  @n = 1; DELIMITER //\nCREATE TRIGGER d AFTER INSERT ON t FOR EACH ROW BEGIN SET @n = 1; SET @m = 2; END//",
+			Expect: "DROP TRIGGER `a`;\nDROP TRIGGER `b`;\n\nCREATE TRIGGER `b` BEFORE UPDATE ON `t` FOR EACH ROW SET NEW.id = 2;\nDELIMITER ;;\nCREATE TRIGGER `d` AFTER INSERT ON `t` FOR EACH ROW BEGIN SET @n = 1; SET @m = 2; END;;\nDELIMITER ;",
+		},
+		// routines are dropped and created again when they are modified,
+		// and are created before the views and triggers
+		{
+			Before: "CREATE PROCEDURE p () SELECT 1; CREATE FUNCTION p () RETURNS INT RETURN 1; CREATE FUNCTION f () RETURNS INT RETURN 1",
+			After:  "CREATE PROCEDURE p () SQL SECURITY DEFINER SELECT 1; CREATE FUNCTION f () RETURNS BIGINT RETURN 1; CREATE VIEW v AS SELECT f(); CREATE FUNCTION g (a INT) RETURNS INT DETERMINISTIC RETURN a",
+			Expect: "DROP FUNCTION `p`;\nDROP FUNCTION `f`;\n\nCREATE FUNCTION `f` () RETURNS BIGINT RETURN 1;\nCREATE FUNCTION `g` (`a` INT) RETURNS INT DETERMINISTIC RETURN a;\n\nCREATE OR REPLACE VIEW `v` AS SELECT f();",
+		},
+		// events are altered, and the start times are only compared if
+		// both are specified
+		{
+			Before: "CREATE EVENT a ON SCHEDULE EVERY 1 DAY STARTS '2024-01-01 00:00:00' DO SELECT 1; CREATE EVENT b ON SCHEDULE EVERY 1 DAY DO SELECT 1; CREATE EVENT c ON SCHEDULE EVERY 1 DAY DO SELECT 1",
+			After:  "CREATE EVENT d ON SCHEDULE EVERY 1 HOUR DO SELECT 1; CREATE EVENT a ON SCHEDULE EVERY 1 DAY ENABLE DO SELECT 1; CREATE EVENT b ON SCHEDULE EVERY 2 DAY DISABLE COMMENT 'x' DO SELECT 2",
+			Expect: "DROP EVENT `c`;\n\nCREATE EVENT `d` ON SCHEDULE EVERY 1 HOUR DO SELECT 1;\nALTER EVENT `b` ON SCHEDULE EVERY 2 DAY DISABLE COMMENT 'x' DO SELECT 2;",
+		},
+		// quotes in the comments of events and routines are escaped
+		{
+			Before: "CREATE EVENT a ON SCHEDULE EVERY 1 DAY DO SELECT 1",
+			After:  "CREATE EVENT a ON SCHEDULE EVERY 1 DAY COMMENT 'it''s' DO SELECT 1; CREATE EVENT b ON SCHEDULE EVERY 1 DAY COMMENT 'b\\'s' DO SELECT 1; CREATE PROCEDURE p () COMMENT 'p''s' SELECT 1",
+			Expect: "CREATE PROCEDURE `p` () COMMENT 'p''s' SELECT 1;\n\nCREATE EVENT `b` ON SCHEDULE EVERY 1 DAY COMMENT 'b''s' DO SELECT 1;\nALTER EVENT `a` COMMENT 'it''s';",
+		},
+		// views, triggers, routines and events are identified by their
+		// databases, like tables
+		{
+			Before: "USE a; CREATE VIEW v AS SELECT 1; CREATE TRIGGER tr BEFORE INSERT ON t FOR EACH ROW SET NEW.id = 1; CREATE PROCEDURE p () SELECT 1; CREATE EVENT e ON SCHEDULE EVERY 1 DAY DO SELECT 1; USE b; CREATE VIEW v AS SELECT 1; CREATE TRIGGER tr BEFORE INSERT ON t FOR EACH ROW SET NEW.id = 1; CREATE PROCEDURE p () SELECT 1; CREATE EVENT e ON SCHEDULE EVERY 1 DAY DO SELECT 1",
+			After:  "USE a; CREATE VIEW v AS SELECT 1; CREATE TRIGGER tr BEFORE INSERT ON t FOR EACH ROW SET NEW.id = 1; CREATE PROCEDURE p () SELECT 1; CREATE EVENT e ON SCHEDULE EVERY 1 DAY DO SELECT 1; USE b; CREATE VIEW v AS SELECT 2 FROM v2; CREATE VIEW v2 AS SELECT 1; CREATE TRIGGER tr BEFORE INSERT ON b.t FOR EACH ROW SET NEW.id = 2; CREATE EVENT e ON SCHEDULE EVERY 2 DAY DO SELECT 1",
+			Expect: "DROP TRIGGER `b`.`tr`;\n\nDROP PROCEDURE `b`.`p`;\n\nCREATE OR REPLACE VIEW `b`.`v2` AS SELECT 1;\nCREATE OR REPLACE VIEW `b`.`v` AS SELECT 2 FROM v2;\n\nCREATE TRIGGER `b`.`tr` BEFORE INSERT ON `b`.`t` FOR EACH ROW SET NEW.id = 2;\n\nALTER EVENT `b`.`e` ON SCHEDULE EVERY 2 DAY;",
+		},
+		// databases are created first and dropped last, and only the
+		// options that are specified in the new schema are altered
+		{
+			Before: "CREATE DATABASE a DEFAULT CHARACTER SET latin1 COLLATE latin1_bin; CREATE DATABASE b CHARACTER SET utf8mb4; CREATE TABLE `t` ( `id` INTEGER NOT NULL );",
+			After:  "CREATE DATABASE c DEFAULT ENCRYPTION 'Y'; CREATE DATABASE a CHARACTER SET utf8mb4; CREATE TABLE `t` ( `id` INTEGER NOT NULL ); CREATE TABLE `u` ( `id` INTEGER NOT NULL );",
+			Expect: "CREATE DATABASE `c` ENCRYPTION = 'Y';\n\nALTER DATABASE `a` DEFAULT CHARACTER SET = utf8mb4;\n\nCREATE TABLE `u` (\n`id` INT (11) NOT NULL\n);\n\nDROP DATABASE `b`;",
+		},
+		{
+			Before: "CREATE DATABASE a CHARACTER SET utf8mb4 COLLATE utf8mb4_bin",
+			After:  "CREATE DATABASE a CHARACTER SET UTF8MB4",
+		},
+		// databases are not created or dropped if only one of the
+		// schemas declares them, such as a schema file compared with the
+		// database that a DSN is bound to
+		{
+			Before: "CREATE DATABASE app; CREATE TABLE `t` ( `id` INTEGER NOT NULL );",
+			After:  "CREATE TABLE `t` ( `id` INTEGER NOT NULL );",
+		},
+		{
+			Before: "CREATE TABLE `t` ( `id` INTEGER NOT NULL );",
+			After:  "CREATE DATABASE app; CREATE TABLE `t` ( `id` INTEGER NOT NULL );",
+		},
+		// tables are identified by their databases, and tables that
+		// refer to each other across the databases are ordered
+		{
+			Before: "USE a; CREATE TABLE `t` ( `id` INTEGER NOT NULL ); USE b; CREATE TABLE `t` ( `id` INTEGER NOT NULL ); CREATE TABLE `u` ( `id` INTEGER NOT NULL );",
+			After:  "CREATE TABLE b.v ( `id` INTEGER NOT NULL, CONSTRAINT `v_fk` FOREIGN KEY (`id`) REFERENCES `a`.`w` (`id`) ); CREATE TABLE a.w ( `id` INTEGER NOT NULL ); USE a; CREATE TABLE `t` ( `id` INTEGER NOT NULL, `name` VARCHAR (20) NOT NULL ); USE b; CREATE TABLE `t` ( `id` INTEGER NOT NULL );",
+			Expect: "DROP TABLE `b`.`u`;\n\nCREATE TABLE `a`.`w` (\n`id` INT (11) NOT NULL\n);\nCREATE TABLE `b`.`v` (\n`id` INT (11) NOT NULL,\nINDEX `v_fk` (`id`),\nCONSTRAINT `v_fk` FOREIGN KEY (`id`) REFERENCES `a`.`w` (`id`)\n);\n\nALTER TABLE `a`.`t` ADD COLUMN `name` VARCHAR (20) NOT NULL AFTER `id`;",
+		},
 		// drop column
 		{
 			Before: "CREATE TABLE `fuga` ( `id` INTEGER NOT NULL, `c` VARCHAR (20) NOT NULL DEFAULT 'xxx' );",
@@ -123,6 +256,163 @@ func TestDiff(t *testing.T) {
 			After:  "CREATE TABLE `hoge` ( `txt` TEXT );",
 			Expect: "ALTER TABLE `hoge` DROP INDEX `ft_idx`;",
 		},
+		// add check constraint
+		{
+			Before: "CREATE TABLE `hoge` ( `a` INTEGER NOT NULL );",
+			After:  "CREATE TABLE `hoge` ( `a` INTEGER NOT NULL, CONSTRAINT `a_positive` CHECK (`a` > 0) );",
+			Expect: "ALTER TABLE `hoge` ADD CONSTRAINT `a_positive` CHECK (`a` > 0);",
+		},
+		// drop check constraint
+		{
+			Before: "CREATE TABLE `hoge` ( `a` INTEGER NOT NULL, CHECK (`a` > 0) );",
+			After:  "CREATE TABLE `hoge` ( `a` INTEGER NOT NULL );",
+			Expect: "ALTER TABLE `hoge` DROP CHECK `hoge_chk_1`;",
+		},
+		// change check constraint
+		{
+			Before: "CREATE TABLE `hoge` ( `a` INTEGER NOT NULL, `b` INTEGER NOT NULL, CONSTRAINT `a_positive` CHECK (`a` > 0) );",
+			After:  "CREATE TABLE `hoge` ( `a` INTEGER NOT NULL, CONSTRAINT `a_positive` CHECK (`a` > 0) NOT ENFORCED );",
+			Expect: "ALTER TABLE `hoge` DROP CHECK `a_positive`;\nALTER TABLE `hoge` DROP COLUMN `b`;\nALTER TABLE `hoge` ADD CONSTRAINT `a_positive` CHECK (`a` > 0) NOT ENFORCED;",
+		},
+		// check constraints are compared with the expressions that the
+		// server shows, which are quoted and parenthesized
+		{
+			Before: "CREATE TABLE `hoge` ( `a` INTEGER NOT NULL, CONSTRAINT `hoge_chk_1` CHECK ((`a` > 0)), CONSTRAINT `b_positive` CHECK ((`a` <> 10)) );",
+			After:  "CREATE TABLE `hoge` ( `a` INTEGER NOT NULL, CHECK (a > 0), CONSTRAINT `b_positive` CHECK (A<>10) );",
+			Expect: "",
+		},
+		// generation expressions are compared with the ones that the
+		// server shows, which are quoted and parenthesized
+		{
+			Before: "CREATE TABLE `hoge` ( `a` INTEGER NOT NULL, `b` INTEGER GENERATED ALWAYS AS ((`a` + 1)) VIRTUAL, `c` INTEGER GENERATED ALWAYS AS ((`a` * 2)) STORED );",
+			After:  "CREATE TABLE `hoge` ( `a` INTEGER NOT NULL, `b` INTEGER AS (a + 1) VIRTUAL, `c` INTEGER AS (A*2) STORED );",
+			Expect: "",
+		},
+		// add generated column
+		{
+			Before: "CREATE TABLE `hoge` ( `a` INTEGER NOT NULL );",
+			After:  "CREATE TABLE `hoge` ( `a` INTEGER NOT NULL, `b` INTEGER AS (`a` + 1) );",
+			Expect: "ALTER TABLE `hoge` ADD COLUMN `b` INT (11) GENERATED ALWAYS AS (`a` + 1) VIRTUAL AFTER `a`;",
+		},
+		// change generated column expression
+		{
+			Before: "CREATE TABLE `hoge` ( `a` INTEGER NOT NULL, `b` INTEGER AS (`a` + 1) STORED );",
+			After:  "CREATE TABLE `hoge` ( `a` INTEGER NOT NULL, `b` INTEGER AS (`a` + 2) STORED );",
+			Expect: "ALTER TABLE `hoge` CHANGE COLUMN `b` `b` INT (11) GENERATED ALWAYS AS (`a` + 2) STORED;",
+		},
+		// change generated column from VIRTUAL to STORED
+		{
+			Before: "CREATE TABLE `hoge` ( `a` INTEGER NOT NULL, `b` INTEGER AS (`a` + 1) VIRTUAL, INDEX `b_idx` (`b`) );",
+			After:  "CREATE TABLE `hoge` ( `a` INTEGER NOT NULL, `b` INTEGER AS (`a` + 1) STORED, INDEX `b_idx` (`b`) );",
+			Expect: "ALTER TABLE `hoge` DROP INDEX `b_idx`;\nALTER TABLE `hoge` DROP COLUMN `b`;\nALTER TABLE `hoge` ADD COLUMN `b` INT (11) GENERATED ALWAYS AS (`a` + 1) STORED AFTER `a`;\nALTER TABLE `hoge` ADD INDEX `b_idx` (`b`);",
+		},
+		// change regular column to STORED can be done in place
+		{
+			Before: "CREATE TABLE `hoge` ( `a` INTEGER NOT NULL, `b` INTEGER NOT NULL );",
+			After:  "CREATE TABLE `hoge` ( `a` INTEGER NOT NULL, `b` INTEGER AS (`a` + 1) STORED NOT NULL );",
+			Expect: "ALTER TABLE `hoge` CHANGE COLUMN `b` `b` INT (11) GENERATED ALWAYS AS (`a` + 1) STORED NOT NULL;",
+		},
+		// tables and columns are in schema order, indexes in name order
+		{
+			Before: "CREATE TABLE `t1` ( `id` INTEGER NOT NULL ); CREATE TABLE `t2` ( `id` INTEGER NOT NULL ); CREATE TABLE `hoge` ( `id` INTEGER NOT NULL, `a` INTEGER NOT NULL, `b` INTEGER NOT NULL, `c` INTEGER NOT NULL, INDEX `idx_a` (`a`), INDEX `idx_b` (`b`) );",
+			After:  "CREATE TABLE `t4` ( `id` INTEGER NOT NULL ); CREATE TABLE `t3` ( `id` INTEGER NOT NULL ); CREATE TABLE `hoge` ( `id` INTEGER NOT NULL, `f` INTEGER NOT NULL, `c` BIGINT NOT NULL, `g` INTEGER NOT NULL, `a` BIGINT NOT NULL, INDEX `idx_g` (`g`), INDEX `idx_f` (`f`) );",
+			Expect: "DROP TABLE `t1`;\nDROP TABLE `t2`;\n\nCREATE TABLE `t4` (\n`id` INT (11) NOT NULL\n);\nCREATE TABLE `t3` (\n`id` INT (11) NOT NULL\n);\n\n" +
+				"ALTER TABLE `hoge` DROP INDEX `idx_a`;\nALTER TABLE `hoge` DROP INDEX `idx_b`;\nALTER TABLE `hoge` DROP COLUMN `b`;\n" +
+				"ALTER TABLE `hoge` ADD COLUMN `f` INT (11) NOT NULL AFTER `id`;\nALTER TABLE `hoge` ADD COLUMN `g` INT (11) NOT NULL AFTER `c`;\n" +
+				"ALTER TABLE `hoge` CHANGE COLUMN `c` `c` BIGINT (20) NOT NULL;\nALTER TABLE `hoge` CHANGE COLUMN `a` `a` BIGINT (20) NOT NULL;\n" +
+				"ALTER TABLE `hoge` ADD INDEX `idx_f` (`f`);\nALTER TABLE `hoge` ADD INDEX `idx_g` (`g`);",
+		},
+		// change table options
+		{
+			Before: "CREATE TABLE `hoge` ( `a` INTEGER NOT NULL ) ENGINE=MyISAM AUTO_INCREMENT=10 DEFAULT CHARSET=latin1 COMMENT='old';",
+			After:  "CREATE TABLE `hoge` ( `a` INTEGER NOT NULL ) ENGINE=InnoDB AUTO_INCREMENT=20 DEFAULT CHARSET=latin1 ROW_FORMAT=DYNAMIC COMMENT='new';",
+			Expect: "ALTER TABLE `hoge` ENGINE = InnoDB, ROW_FORMAT = DYNAMIC, COMMENT = 'new';",
+		},
+		// remove table options
+		{
+			Before: "CREATE TABLE `hoge` ( `a` INTEGER NOT NULL ) ENGINE=InnoDB KEY_BLOCK_SIZE=8 COMMENT='old';",
+			After:  "CREATE TABLE `hoge` ( `a` INTEGER NOT NULL );",
+			Expect: "ALTER TABLE `hoge` KEY_BLOCK_SIZE = 0, COMMENT = '';",
+		},
+		// table options are compared case insensitively
+		{
+			Before: "CREATE TABLE `hoge` ( `a` INTEGER NOT NULL ) ENGINE=innodb DEFAULT CHARSET=UTF8MB4;",
+			After:  "CREATE TABLE `hoge` ( `a` INTEGER NOT NULL ) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;",
+			Expect: "",
+		},
+		// partition table
+		{
+			Before: "CREATE TABLE `hoge` ( `a` INTEGER NOT NULL );",
+			After:  "CREATE TABLE `hoge` ( `a` INTEGER NOT NULL ) PARTITION BY HASH (`a`) PARTITIONS 4;",
+			Expect: "ALTER TABLE `hoge` PARTITION BY HASH (`a`) PARTITIONS 4;",
+		},
+		// remove partitioning
+		{
+			Before: "CREATE TABLE `hoge` ( `a` INTEGER NOT NULL ) PARTITION BY HASH (`a`) PARTITIONS 4;",
+			After:  "CREATE TABLE `hoge` ( `a` INTEGER NOT NULL );",
+			Expect: "ALTER TABLE `hoge` REMOVE PARTITIONING;",
+		},
+		// change partition count
+		{
+			Before: "CREATE TABLE `hoge` ( `a` INTEGER NOT NULL ) PARTITION BY KEY (`a`) PARTITIONS 4;",
+			After:  "CREATE TABLE `hoge` ( `a` INTEGER NOT NULL ) PARTITION BY KEY (`a`) PARTITIONS 2;",
+			Expect: "ALTER TABLE `hoge` COALESCE PARTITION 2;",
+		},
+		// add range partition
+		{
+			Before: "CREATE TABLE `hoge` ( `a` INTEGER NOT NULL ) PARTITION BY RANGE (`a`) (PARTITION p0 VALUES LESS THAN (10));",
+			After:  "CREATE TABLE `hoge` ( `a` INTEGER NOT NULL ) PARTITION BY RANGE (`a`) (PARTITION p0 VALUES LESS THAN (10), PARTITION p1 VALUES LESS THAN (20), PARTITION pmax VALUES LESS THAN MAXVALUE);",
+			Expect: "ALTER TABLE `hoge` ADD PARTITION (PARTITION `p1` VALUES LESS THAN (20), PARTITION `pmax` VALUES LESS THAN MAXVALUE);",
+		},
+		// split range partition
+		{
+			Before: "CREATE TABLE `hoge` ( `a` INTEGER NOT NULL ) PARTITION BY RANGE (`a`) (PARTITION p0 VALUES LESS THAN (10), PARTITION pmax VALUES LESS THAN MAXVALUE);",
+			After:  "CREATE TABLE `hoge` ( `a` INTEGER NOT NULL ) PARTITION BY RANGE (`a`) (PARTITION p0 VALUES LESS THAN (10), PARTITION p1 VALUES LESS THAN (20), PARTITION pmax VALUES LESS THAN MAXVALUE);",
+			Expect: "ALTER TABLE `hoge` REORGANIZE PARTITION `pmax` INTO (PARTITION `p1` VALUES LESS THAN (20), PARTITION `pmax` VALUES LESS THAN MAXVALUE);",
+		},
+		// drop and change list partitions
+		{
+			Before: "CREATE TABLE `hoge` ( `a` INTEGER NOT NULL ) PARTITION BY LIST (`a`) (PARTITION p0 VALUES IN (1, 2), PARTITION p1 VALUES IN (3));",
+			After:  "CREATE TABLE `hoge` ( `a` INTEGER NOT NULL ) PARTITION BY LIST (`a`) (PARTITION p0 VALUES IN (1, 2, 3));",
+			Expect: "ALTER TABLE `hoge` REORGANIZE PARTITION `p0`, `p1` INTO (PARTITION `p0` VALUES IN (1, 2, 3));",
+		},
+		// list partitions whose values are not kept are dropped, and the
+		// values of the others are moved along with their rows
+		{
+			Before: "CREATE TABLE `hoge` ( `a` INTEGER NOT NULL ) PARTITION BY LIST (`a`) (PARTITION p0 VALUES IN (1), PARTITION p1 VALUES IN (2), PARTITION p2 VALUES IN (3), PARTITION p3 VALUES IN (4));",
+			After:  "CREATE TABLE `hoge` ( `a` INTEGER NOT NULL ) PARTITION BY LIST (`a`) (PARTITION p0 VALUES IN (1), PARTITION p4 VALUES IN (2, 3), PARTITION p5 VALUES IN (5));",
+			Expect: "ALTER TABLE `hoge` DROP PARTITION `p3`;\nALTER TABLE `hoge` REORGANIZE PARTITION `p1`, `p2` INTO (PARTITION `p4` VALUES IN (2, 3));\nALTER TABLE `hoge` ADD PARTITION (PARTITION `p5` VALUES IN (5));",
+		},
+		// change the bound of a range partition along with the partition that follows it
+		{
+			Before: "CREATE TABLE `hoge` ( `a` INTEGER NOT NULL ) PARTITION BY RANGE (`a`) (PARTITION p0 VALUES LESS THAN (10), PARTITION p1 VALUES LESS THAN (20), PARTITION pmax VALUES LESS THAN MAXVALUE);",
+			After:  "CREATE TABLE `hoge` ( `a` INTEGER NOT NULL ) PARTITION BY RANGE (`a`) (PARTITION p0 VALUES LESS THAN (15), PARTITION p1 VALUES LESS THAN (20), PARTITION pmax VALUES LESS THAN MAXVALUE);",
+			Expect: "ALTER TABLE `hoge` REORGANIZE PARTITION `p0`, `p1` INTO (PARTITION `p0` VALUES LESS THAN (15), PARTITION `p1` VALUES LESS THAN (20));",
+		},
+		// extend the last range partition, and add partitions after it
+		{
+			Before: "CREATE TABLE `hoge` ( `a` INTEGER NOT NULL ) PARTITION BY RANGE (`a`) (PARTITION p0 VALUES LESS THAN (10), PARTITION p1 VALUES LESS THAN (20));",
+			After:  "CREATE TABLE `hoge` ( `a` INTEGER NOT NULL ) PARTITION BY RANGE (`a`) (PARTITION p0 VALUES LESS THAN (10), PARTITION p1 VALUES LESS THAN (30), PARTITION pmax VALUES LESS THAN MAXVALUE);",
+			Expect: "ALTER TABLE `hoge` REORGANIZE PARTITION `p1` INTO (PARTITION `p1` VALUES LESS THAN (30), PARTITION `pmax` VALUES LESS THAN MAXVALUE);",
+		},
+		// the last range partition cannot be shrunk, so the table is partitioned again
+		{
+			Before: "CREATE TABLE `hoge` ( `a` INTEGER NOT NULL ) PARTITION BY RANGE (`a`) (PARTITION p0 VALUES LESS THAN (10), PARTITION p1 VALUES LESS THAN (30), PARTITION p2 VALUES LESS THAN (40));",
+			After:  "CREATE TABLE `hoge` ( `a` INTEGER NOT NULL ) PARTITION BY RANGE (`a`) (PARTITION p0 VALUES LESS THAN (10), PARTITION p1 VALUES LESS THAN (20));",
+			Expect: "ALTER TABLE `hoge` PARTITION BY RANGE (`a`)\n(PARTITION `p0` VALUES LESS THAN (10),\nPARTITION `p1` VALUES LESS THAN (20));",
+		},
+		// add named hash partitions
+		{
+			Before: "CREATE TABLE `hoge` ( `a` INTEGER NOT NULL ) PARTITION BY HASH (`a`) (PARTITION p0, PARTITION p1);",
+			After:  "CREATE TABLE `hoge` ( `a` INTEGER NOT NULL ) PARTITION BY HASH (`a`) (PARTITION p0, PARTITION p1, PARTITION p2);",
+			Expect: "ALTER TABLE `hoge` ADD PARTITION (PARTITION `p2`);",
+		},
+		// change the definitions of named key partitions
+		{
+			Before: "CREATE TABLE `hoge` ( `a` INTEGER NOT NULL ) PARTITION BY KEY (`a`) (PARTITION p0, PARTITION p1);",
+			After:  "CREATE TABLE `hoge` ( `a` INTEGER NOT NULL ) PARTITION BY KEY (`a`) (PARTITION p0, PARTITION p1 COMMENT = 'one');",
+			Expect: "ALTER TABLE `hoge` PARTITION BY KEY (`a`)\n(PARTITION `p0`,\nPARTITION `p1` COMMENT = 'one');",
+		},
 		// multi modify
 		{
 			Before: "CREATE TABLE `fuga` ( `id` INTEGER NOT NULL AUTO_INCREMENT, `aid` INTEGER NOT NULL, `bid` INTEGER NOT NULL, INDEX `ab` (`aid`, `bid`) );",
@@ -220,3 +510,703 @@ CREATE TABLE foo (
 		}
 	}
 }
+
+func TestIgnoredTableOptions(t *testing.T) {
+	const before = "CREATE TABLE `hoge` ( `a` INTEGER NOT NULL ) AUTO_INCREMENT=10 COMMENT='old';"
+	const after = "CREATE TABLE `hoge` ( `a` INTEGER NOT NULL ) AUTO_INCREMENT=20 COMMENT='new';"
+
+	var buf bytes.Buffer
+	if !assert.NoError(t, diff.Strings(&buf, before, after, diff.WithIgnoredTableOptions("comment")), "diff.Strings should succeed") {
+		return
+	}
+	if !assert.Equal(t, "ALTER TABLE `hoge` AUTO_INCREMENT = 20;", buf.String(), "result SQL should match") {
+		return
+	}
+
+	buf.Reset()
+	if !assert.NoError(t, diff.Strings(&buf, before, after, diff.WithIgnoredTableOptions()), "diff.Strings should succeed") {
+		return
+	}
+	if !assert.Equal(t, "ALTER TABLE `hoge` AUTO_INCREMENT = 20, COMMENT = 'new';", buf.String(), "result SQL should match") {
+		return
+	}
+}
+
+func TestRenames(t *testing.T) {
+	type Spec struct {
+		Before  string
+		After   string
+		Options []diff.Option
+		Expect  string
+	}
+
+	specs := []Spec{
+		// without hints, renames are drops and adds
+		{
+			Before: "CREATE TABLE `hoge` ( `id` INTEGER NOT NULL, `a` INTEGER NOT NULL );",
+			After:  "CREATE TABLE `hoge` ( `id` INTEGER NOT NULL, `b` INTEGER NOT NULL );",
+			Expect: "ALTER TABLE `hoge` DROP COLUMN `a`;\nALTER TABLE `hoge` ADD COLUMN `b` INT (11) NOT NULL AFTER `id`;",
+		},
+		// rename table
+		{
+			Before: "CREATE TABLE `hoge` ( `id` INTEGER NOT NULL, INDEX `id_idx` (`id`) );",
+			After:  "CREATE TABLE `fuga` ( `id` INTEGER NOT NULL, INDEX `id_idx` (`id`) );",
+			Options: []diff.Option{
+				diff.WithRenameHints(diff.RenameHints{Tables: map[string]string{"hoge": "fuga"}}),
+			},
+			Expect: "RENAME TABLE `hoge` TO `fuga`;",
+		},
+		// rename table and change it
+		{
+			Before: "CREATE TABLE `hoge` ( `id` INTEGER NOT NULL );",
+			After:  "CREATE TABLE `fuga` ( `id` INTEGER NOT NULL, `a` INTEGER NOT NULL );",
+			Options: []diff.Option{
+				diff.WithRenameHints(diff.RenameHints{Tables: map[string]string{"hoge": "fuga"}}),
+			},
+			Expect: "RENAME TABLE `hoge` TO `fuga`;\n\nALTER TABLE `fuga` ADD COLUMN `a` INT (11) NOT NULL AFTER `id`;",
+		},
+		// rename column, along with the index that uses it
+		{
+			Before: "CREATE TABLE `hoge` ( `id` INTEGER NOT NULL, `a` INTEGER NOT NULL, INDEX `a_idx` (`a`) );",
+			After:  "CREATE TABLE `hoge` ( `id` INTEGER NOT NULL, `b` BIGINT NOT NULL, `c` INTEGER NOT NULL, INDEX `b_idx` (`b`) );",
+			Options: []diff.Option{
+				diff.WithRenameHints(diff.RenameHints{
+					Columns: map[string]string{"hoge.a": "b"},
+					Indexes: map[string]string{"hoge.a_idx": "b_idx"},
+				}),
+			},
+			Expect: "ALTER TABLE `hoge` RENAME INDEX `a_idx` TO `b_idx`;\nALTER TABLE `hoge` CHANGE COLUMN `a` `b` BIGINT (20) NOT NULL;\nALTER TABLE `hoge` ADD COLUMN `c` INT (11) NOT NULL AFTER `b`;",
+		},
+		// hints that do not apply are ignored
+		{
+			Before: "CREATE TABLE `hoge` ( `id` INTEGER NOT NULL, `b` INTEGER NOT NULL );",
+			After:  "CREATE TABLE `hoge` ( `id` INTEGER NOT NULL, `b` INTEGER NOT NULL );",
+			Options: []diff.Option{
+				diff.WithRenameHints(diff.RenameHints{
+					Tables:  map[string]string{"foo": "bar"},
+					Columns: map[string]string{"hoge.a": "b"},
+				}),
+			},
+			Expect: "",
+		},
+		// heuristics match identical columns
+		{
+			Before: "CREATE TABLE `hoge` ( `id` INTEGER NOT NULL, `a` VARCHAR (20) NOT NULL DEFAULT 'x', `c` INTEGER NOT NULL );",
+			After:  "CREATE TABLE `hoge` ( `id` INTEGER NOT NULL, `b` VARCHAR (20) NOT NULL DEFAULT 'x', `d` BIGINT NOT NULL );",
+			Options: []diff.Option{
+				diff.WithRenameHeuristics(true),
+			},
+			Expect: "ALTER TABLE `hoge` DROP COLUMN `c`;\nALTER TABLE `hoge` CHANGE COLUMN `a` `b` VARCHAR (20) NOT NULL DEFAULT 'x';\nALTER TABLE `hoge` ADD COLUMN `d` BIGINT (20) NOT NULL AFTER `b`;",
+		},
+		// heuristics do not match ambiguous columns
+		{
+			Before: "CREATE TABLE `hoge` ( `id` INTEGER NOT NULL, `a` INTEGER NOT NULL );",
+			After:  "CREATE TABLE `hoge` ( `id` INTEGER NOT NULL, `b` INTEGER NOT NULL, `c` INTEGER NOT NULL );",
+			Options: []diff.Option{
+				diff.WithRenameHeuristics(true),
+			},
+			Expect: "ALTER TABLE `hoge` DROP COLUMN `a`;\nALTER TABLE `hoge` ADD COLUMN `b` INT (11) NOT NULL AFTER `id`;\nALTER TABLE `hoge` ADD COLUMN `c` INT (11) NOT NULL AFTER `b`;",
+		},
+		// foreign keys follow the renamed table
+		{
+			Before: "CREATE TABLE `hoge` ( `id` INTEGER NOT NULL, PRIMARY KEY (`id`) ); CREATE TABLE `fuga` ( `hoge_id` INTEGER NOT NULL, CONSTRAINT `fk` FOREIGN KEY (`hoge_id`) REFERENCES `hoge` (`id`) );",
+			After:  "CREATE TABLE `piyo` ( `id` INTEGER NOT NULL, PRIMARY KEY (`id`) ); CREATE TABLE `fuga` ( `hoge_id` INTEGER NOT NULL, CONSTRAINT `fk` FOREIGN KEY (`hoge_id`) REFERENCES `piyo` (`id`) );",
+			Options: []diff.Option{
+				diff.WithRenameHints(diff.RenameHints{Tables: map[string]string{"hoge": "piyo"}}),
+			},
+			Expect: "RENAME TABLE `hoge` TO `piyo`;",
+		},
+		// tables that belong to a database are renamed by their qualified
+		// names, and may be moved to another database
+		{
+			Before: "USE a; CREATE TABLE `hoge` ( `id` INTEGER NOT NULL, PRIMARY KEY (`id`) ); CREATE TABLE `fuga` ( `hoge_id` INTEGER NOT NULL, `x` INTEGER NOT NULL, CONSTRAINT `fk` FOREIGN KEY (`hoge_id`) REFERENCES `hoge` (`id`) );",
+			After:  "USE a; CREATE TABLE `fuga` ( `hoge_id` INTEGER NOT NULL, `y` INTEGER NOT NULL, CONSTRAINT `fk` FOREIGN KEY (`hoge_id`) REFERENCES `b`.`piyo` (`id`) ); USE b; CREATE TABLE `piyo` ( `id` INTEGER NOT NULL, PRIMARY KEY (`id`) );",
+			Options: []diff.Option{
+				diff.WithRenameHints(diff.RenameHints{
+					Tables:  map[string]string{"a.hoge": "b.piyo"},
+					Columns: map[string]string{"a.fuga.x": "y"},
+				}),
+			},
+			Expect: "RENAME TABLE `a`.`hoge` TO `b`.`piyo`;\n\nALTER TABLE `a`.`fuga` CHANGE COLUMN `x` `y` INT (11) NOT NULL;",
+		},
+	}
+
+	var buf bytes.Buffer
+	for _, spec := range specs {
+		buf.Reset()
+		if !assert.NoError(t, diff.Strings(&buf, spec.Before, spec.After, spec.Options...), "diff.Strings should succeed") {
+			return
+		}
+
+		if !assert.Equal(t, spec.Expect, buf.String(), "result SQL should match") {
+			t.Logf("before = %s", spec.Before)
+			t.Logf("after = %s", spec.After)
+			return
+		}
+	}
+}
+
+func TestCompute(t *testing.T) {
+	p := schemalex.New()
+	before, err := p.ParseString("CREATE TABLE `hoge` ( `id` INTEGER NOT NULL, `a` INTEGER NOT NULL ); CREATE TABLE `fuga` ( `id` INTEGER NOT NULL );")
+	if !assert.NoError(t, err, "parse should succeed") {
+		return
+	}
+	after, err := p.ParseString("CREATE TABLE `hoge` ( `id` INTEGER NOT NULL, `a` BIGINT NOT NULL, INDEX `a_idx` (`a`) );")
+	if !assert.NoError(t, err, "parse should succeed") {
+		return
+	}
+
+	changes, err := diff.Compute(before, after)
+	if !assert.NoError(t, err, "diff.Compute should succeed") {
+		return
+	}
+	if !assert.Equal(t, 3, changes.Len(), "number of changes should match") {
+		return
+	}
+
+	dropped, ok := changes.Changes[0].(*diff.TableDropped)
+	if !assert.True(t, ok, "first change should be a TableDropped") {
+		return
+	}
+	if !assert.Equal(t, "fuga", dropped.Table.Name(), "dropped table should match") {
+		return
+	}
+
+	modified, ok := changes.Changes[1].(*diff.ColumnModified)
+	if !assert.True(t, ok, "second change should be a ColumnModified") {
+		return
+	}
+	if !assert.Equal(t, "hoge", modified.TableName(), "table name should match") {
+		return
+	}
+	if !assert.Equal(t, model.ColumnTypeInt, modified.Before.Type(), "type before should match") {
+		return
+	}
+	if !assert.Equal(t, model.ColumnTypeBigInt, modified.After.Type(), "type after should match") {
+		return
+	}
+
+	added, ok := changes.Changes[2].(*diff.IndexAdded)
+	if !assert.True(t, ok, "third change should be an IndexAdded") {
+		return
+	}
+	if !assert.Equal(t, "a_idx", added.Index.Name(), "index name should match") {
+		return
+	}
+
+	var buf bytes.Buffer
+	if !assert.NoError(t, diff.Render(&buf, changes), "diff.Render should succeed") {
+		return
+	}
+	if !assert.Equal(t, "DROP TABLE `fuga`;\n\nALTER TABLE `hoge` CHANGE COLUMN `a` `a` BIGINT (20) NOT NULL;\nALTER TABLE `hoge` ADD INDEX `a_idx` (`a`);", buf.String(), "result SQL should match") {
+		return
+	}
+}
+
+func TestReverse(t *testing.T) {
+	type Spec struct {
+		Before  string
+		After   string
+		Options []diff.Option
+		Expect  string
+		Reverse string
+	}
+
+	specs := []Spec{
+		// dropped columns and tables are restored with a warning
+		{
+			Before:  "CREATE TABLE `hoge` ( `id` INTEGER NOT NULL, `a` INTEGER NOT NULL, `b` INTEGER NOT NULL ); CREATE TABLE `fuga` ( `id` INTEGER NOT NULL );",
+			After:   "CREATE TABLE `hoge` ( `id` INTEGER NOT NULL, `b` INTEGER NOT NULL );",
+			Expect:  "DROP TABLE `fuga`;\n\nALTER TABLE `hoge` DROP COLUMN `a`;",
+			Reverse: "-- WARNING: table `fuga` is restored, but the data that it contained has been lost\nCREATE TABLE `fuga` (\n`id` INT (11) NOT NULL\n);\n\n-- WARNING: column `hoge`.`a` is restored, but the data that it contained has been lost\nALTER TABLE `hoge` ADD COLUMN `a` INT (11) NOT NULL AFTER `id`;",
+		},
+		// added columns are dropped, without a warning
+		{
+			Before:  "CREATE TABLE `hoge` ( `id` INTEGER NOT NULL );",
+			After:   "CREATE TABLE `hoge` ( `a` INTEGER NOT NULL, `id` INTEGER NOT NULL );",
+			Expect:  "ALTER TABLE `hoge` ADD COLUMN `a` INT (11) NOT NULL FIRST;",
+			Reverse: "ALTER TABLE `hoge` DROP COLUMN `a`;",
+		},
+		// renames are reversed
+		{
+			Before: "CREATE TABLE `hoge` ( `id` INTEGER NOT NULL, `a` INTEGER NOT NULL );",
+			After:  "CREATE TABLE `fuga` ( `id` INTEGER NOT NULL, `b` INTEGER NOT NULL );",
+			Options: []diff.Option{
+				diff.WithRenameHints(diff.RenameHints{
+					Tables:  map[string]string{"hoge": "fuga"},
+					Columns: map[string]string{"hoge.a": "b"},
+				}),
+			},
+			Expect:  "RENAME TABLE `hoge` TO `fuga`;\n\nALTER TABLE `fuga` CHANGE COLUMN `a` `b` INT (11) NOT NULL;",
+			Reverse: "RENAME TABLE `fuga` TO `hoge`;\n\nALTER TABLE `hoge` CHANGE COLUMN `b` `a` INT (11) NOT NULL;",
+		},
+	}
+
+	var buf, reverse bytes.Buffer
+	for _, spec := range specs {
+		buf.Reset()
+		reverse.Reset()
+		options := append([]diff.Option{diff.WithReverse(&reverse)}, spec.Options...)
+		if !assert.NoError(t, diff.Strings(&buf, spec.Before, spec.After, options...), "diff.Strings should succeed") {
+			return
+		}
+
+		if !assert.Equal(t, spec.Expect, buf.String(), "result SQL should match") {
+			t.Logf("before = %s", spec.Before)
+			t.Logf("after = %s", spec.After)
+			return
+		}
+		if !assert.Equal(t, spec.Reverse, reverse.String(), "reverse SQL should match") {
+			t.Logf("before = %s", spec.Before)
+			t.Logf("after = %s", spec.After)
+			return
+		}
+	}
+}
+
+func TestClassify(t *testing.T) {
+	type Spec struct {
+		Before string
+		After  string
+		Expect diff.Safety
+	}
+
+	specs := []Spec{
+		{
+			Before: "CREATE TABLE `hoge` ( `id` INTEGER NOT NULL ); CREATE TABLE `fuga` ( `id` INTEGER NOT NULL );",
+			After:  "CREATE TABLE `hoge` ( `id` INTEGER NOT NULL );",
+			Expect: diff.SafetyDestructive,
+		},
+		{
+			Before: "CREATE DATABASE `hoge`; CREATE DATABASE `fuga`;",
+			After:  "CREATE DATABASE `hoge`;",
+			Expect: diff.SafetyDestructive,
+		},
+		{
+			Before: "CREATE TABLE `hoge` ( `id` INTEGER NOT NULL, `a` INTEGER NOT NULL );",
+			After:  "CREATE TABLE `hoge` ( `id` INTEGER NOT NULL );",
+			Expect: diff.SafetyDestructive,
+		},
+		{
+			Before: "CREATE TABLE `hoge` ( `a` VARCHAR (20) NOT NULL );",
+			After:  "CREATE TABLE `hoge` ( `a` VARCHAR (10) NOT NULL );",
+			Expect: diff.SafetyLossy,
+		},
+		{
+			Before: "CREATE TABLE `hoge` ( `a` VARCHAR (10) NOT NULL );",
+			After:  "CREATE TABLE `hoge` ( `a` VARCHAR (20) NOT NULL );",
+			Expect: diff.SafetySafe,
+		},
+		{
+			Before: "CREATE TABLE `hoge` ( `a` BIGINT NOT NULL );",
+			After:  "CREATE TABLE `hoge` ( `a` INT NOT NULL );",
+			Expect: diff.SafetyLossy,
+		},
+		{
+			Before: "CREATE TABLE `hoge` ( `a` SMALLINT NOT NULL );",
+			After:  "CREATE TABLE `hoge` ( `a` INT NOT NULL );",
+			Expect: diff.SafetySafe,
+		},
+		{
+			Before: "CREATE TABLE `hoge` ( `a` INT );",
+			After:  "CREATE TABLE `hoge` ( `a` INT NOT NULL );",
+			Expect: diff.SafetyLossy,
+		},
+		{
+			Before: "CREATE TABLE `hoge` ( `a` ENUM('x', 'y') NOT NULL );",
+			After:  "CREATE TABLE `hoge` ( `a` ENUM('x', 'y', 'z') NOT NULL );",
+			Expect: diff.SafetySafe,
+		},
+		{
+			Before: "CREATE TABLE `hoge` ( `a` DECIMAL(10, 2) NOT NULL );",
+			After:  "CREATE TABLE `hoge` ( `a` DECIMAL(10, 4) NOT NULL );",
+			Expect: diff.SafetyLossy,
+		},
+		{
+			Before: "CREATE TABLE `hoge` ( `a` INT NOT NULL, UNIQUE KEY `a_uniq` (`a`) );",
+			After:  "CREATE TABLE `hoge` ( `a` INT NOT NULL );",
+			Expect: diff.SafetyLossy,
+		},
+		{
+			Before: "CREATE TABLE `hoge` ( `a` INT NOT NULL ) WITH SYSTEM VERSIONING;",
+			After:  "CREATE TABLE `hoge` ( `a` INT NOT NULL );",
+			Expect: diff.SafetyDestructive,
+		},
+		{
+			Before: "CREATE TABLE `hoge` ( `a` INT NOT NULL, KEY `a_idx` (`a`) );",
+			After:  "CREATE TABLE `hoge` ( `a` INT NOT NULL );",
+			Expect: diff.SafetySafe,
+		},
+		{
+			Before: "CREATE TABLE `hoge` ( `a` INT NOT NULL ) PARTITION BY RANGE (`a`) (PARTITION p0 VALUES LESS THAN (10), PARTITION p1 VALUES LESS THAN MAXVALUE);",
+			After:  "CREATE TABLE `hoge` ( `a` INT NOT NULL ) PARTITION BY RANGE (`a`) (PARTITION p1 VALUES LESS THAN MAXVALUE);",
+			Expect: diff.SafetyDestructive,
+		},
+	}
+
+	p := schemalex.New()
+	for _, spec := range specs {
+		before, err := p.ParseString(spec.Before)
+		if !assert.NoError(t, err, "parse should succeed") {
+			return
+		}
+		after, err := p.ParseString(spec.After)
+		if !assert.NoError(t, err, "parse should succeed") {
+			return
+		}
+
+		changes, err := diff.Compute(before, after)
+		if !assert.NoError(t, err, "diff.Compute should succeed") {
+			return
+		}
+		if !assert.Equal(t, 1, changes.Len(), "number of changes should match") {
+			t.Logf("before = %s", spec.Before)
+			t.Logf("after = %s", spec.After)
+			return
+		}
+		if !assert.Equal(t, spec.Expect, diff.Classify(changes.Changes[0]), "safety should match") {
+			t.Logf("before = %s", spec.Before)
+			t.Logf("after = %s", spec.After)
+			return
+		}
+	}
+}
+
+func TestSafetyPolicy(t *testing.T) {
+	const before = "CREATE TABLE `hoge` ( `id` INTEGER NOT NULL, `a` INTEGER NOT NULL, `b` VARCHAR (20) NOT NULL );"
+	const after = "CREATE TABLE `hoge` ( `id` INTEGER NOT NULL, `b` VARCHAR (10) NOT NULL, `c` INTEGER NOT NULL );"
+
+	var buf bytes.Buffer
+	err := diff.Strings(&buf, before, after, diff.WithSafetyPolicy(diff.SafetyPolicy{Allow: diff.SafetySafe}))
+	if !assert.Error(t, err, "diff.Strings should fail") {
+		return
+	}
+	if !assert.Contains(t, err.Error(), "destructive: ALTER TABLE `hoge` DROP COLUMN `a`;", "error should mention the destructive statement") {
+		return
+	}
+	if !assert.Contains(t, err.Error(), "lossy: ALTER TABLE `hoge` CHANGE COLUMN `b` `b` VARCHAR (10) NOT NULL;", "error should mention the lossy statement") {
+		return
+	}
+	if !assert.Equal(t, 0, buf.Len(), "nothing should be written") {
+		return
+	}
+
+	buf.Reset()
+	err = diff.Strings(&buf, before, after, diff.WithSafetyPolicy(diff.SafetyPolicy{Allow: diff.SafetyLossy, Action: diff.SafetyActionComment}))
+	if !assert.NoError(t, err, "diff.Strings should succeed") {
+		return
+	}
+	if !assert.Equal(t, "-- WARNING: the following destructive statement is not allowed by the safety policy\n-- ALTER TABLE `hoge` DROP COLUMN `a`;\nALTER TABLE `hoge` ADD COLUMN `c` INT (11) NOT NULL AFTER `b`;\nALTER TABLE `hoge` CHANGE COLUMN `b` `b` VARCHAR (10) NOT NULL;", buf.String(), "result SQL should match") {
+		return
+	}
+}
+
+func TestDeterministicOrder(t *testing.T) {
+	const before = "CREATE TABLE `t1` ( `id` INTEGER NOT NULL ); CREATE TABLE `t2` ( `id` INTEGER NOT NULL ); CREATE TABLE `t3` ( `id` INTEGER NOT NULL ); " +
+		"CREATE TABLE `hoge` ( `id` INTEGER NOT NULL, `a` INTEGER NOT NULL, `b` INTEGER NOT NULL, `c` INTEGER NOT NULL, `d` INTEGER NOT NULL, `e` INTEGER NOT NULL, " +
+		"INDEX `idx_a` (`a`), INDEX `idx_b` (`b`), INDEX `idx_c` (`c`), CONSTRAINT `chk_a` CHECK (`a` > 0), CONSTRAINT `chk_b` CHECK (`b` > 0) ); " +
+		"CREATE TABLE `fuga` ( `id` INTEGER NOT NULL, `x` INTEGER NOT NULL, `y` INTEGER NOT NULL );"
+	const after = "CREATE TABLE `t4` ( `id` INTEGER NOT NULL ); CREATE TABLE `t5` ( `id` INTEGER NOT NULL ); CREATE TABLE `t6` ( `id` INTEGER NOT NULL ); " +
+		"CREATE TABLE `fuga` ( `id` INTEGER NOT NULL, `x` BIGINT NOT NULL, `y` BIGINT NOT NULL, `z1` INTEGER NOT NULL, `z2` INTEGER NOT NULL ); " +
+		"CREATE TABLE `hoge` ( `id` INTEGER NOT NULL, `a` BIGINT NOT NULL, `f` INTEGER NOT NULL, `c` BIGINT NOT NULL, `g` INTEGER NOT NULL, `e` BIGINT NOT NULL, " +
+		"INDEX `idx_f` (`f`), INDEX `idx_g` (`g`), INDEX `idx_e` (`e`), CONSTRAINT `chk_f` CHECK (`f` > 0), CONSTRAINT `chk_g` CHECK (`g` > 0) );"
+
+	var expect string
+	for i := 0; i < 100; i++ {
+		var buf bytes.Buffer
+		if !assert.NoError(t, diff.Strings(&buf, before, after), "diff.Strings should succeed") {
+			return
+		}
+		if i == 0 {
+			expect = buf.String()
+			continue
+		}
+		if !assert.Equal(t, expect, buf.String(), "result SQL should be the same every time") {
+			return
+		}
+	}
+}
+
+func TestCombinedAlter(t *testing.T) {
+	type Spec struct {
+		Before  string
+		After   string
+		Options []diff.Option
+		Expect  string
+	}
+
+	specs := []Spec{
+		// all changes to a table are combined, foreign keys are dropped
+		// before indexes and added after them
+		{
+			Before: "CREATE TABLE `fuga` ( `id` INTEGER NOT NULL, `a` INTEGER NOT NULL, `fid` INTEGER NOT NULL, INDEX `idx_a` (`a`), CONSTRAINT `fsym` FOREIGN KEY (`fid`) REFERENCES `f` (`id`) ); " +
+				"CREATE TABLE `hoge` ( `id` INTEGER NOT NULL );",
+			After: "CREATE TABLE `fuga` ( `id` INTEGER NOT NULL, `fid` INTEGER NOT NULL, `b` VARCHAR (20) NOT NULL, CONSTRAINT `ksym` FOREIGN KEY (`fid`) REFERENCES `f` (`id`) ) ENGINE = InnoDB; " +
+				"CREATE TABLE `hoge` ( `id` BIGINT NOT NULL );",
+			Expect: "ALTER TABLE `fuga` ENGINE = InnoDB, DROP FOREIGN KEY `fsym`, DROP INDEX `fsym`, DROP INDEX `idx_a`, DROP COLUMN `a`, ADD COLUMN `b` VARCHAR (20) NOT NULL AFTER `fid`, " +
+				"ADD INDEX `ksym` (`fid`), ADD CONSTRAINT `ksym` FOREIGN KEY (`fid`) REFERENCES `f` (`id`);\n" +
+				"ALTER TABLE `hoge` CHANGE COLUMN `id` `id` BIGINT (20) NOT NULL;",
+		},
+		// partitioning is changed by a statement of its own
+		{
+			Before: "CREATE TABLE `fuga` ( `id` INTEGER NOT NULL );",
+			After:  "CREATE TABLE `fuga` ( `id` INTEGER NOT NULL, `a` INTEGER NOT NULL ) PARTITION BY HASH (`id`) PARTITIONS 4;",
+			Expect: "ALTER TABLE `fuga` ADD COLUMN `a` INT (11) NOT NULL AFTER `id`;\nALTER TABLE `fuga` PARTITION BY HASH (`id`) PARTITIONS 4;",
+		},
+		// changes that are not allowed by the safety policy are commented
+		// out separately
+		{
+			Before:  "CREATE TABLE `hoge` ( `id` INTEGER NOT NULL, `a` INTEGER NOT NULL, `b` VARCHAR (20) NOT NULL );",
+			After:   "CREATE TABLE `hoge` ( `id` INTEGER NOT NULL, `b` VARCHAR (10) NOT NULL, `c` INTEGER NOT NULL );",
+			Options: []diff.Option{diff.WithSafetyPolicy(diff.SafetyPolicy{Allow: diff.SafetySafe, Action: diff.SafetyActionComment})},
+			Expect: "ALTER TABLE `hoge` ADD COLUMN `c` INT (11) NOT NULL AFTER `b`;\n" +
+				"-- WARNING: the following destructive statement is not allowed by the safety policy\n-- ALTER TABLE `hoge` DROP COLUMN `a`;\n" +
+				"-- WARNING: the following lossy statement is not allowed by the safety policy\n-- ALTER TABLE `hoge` CHANGE COLUMN `b` `b` VARCHAR (10) NOT NULL;",
+		},
+	}
+
+	for _, spec := range specs {
+		var buf bytes.Buffer
+		options := append([]diff.Option{diff.WithCombinedAlter(true)}, spec.Options...)
+		if !assert.NoError(t, diff.Strings(&buf, spec.Before, spec.After, options...), "diff.Strings should succeed") {
+			return
+		}
+		if !assert.Equal(t, spec.Expect, buf.String(), "result SQL should match") {
+			return
+		}
+	}
+}
+
+func TestOutputFormat(t *testing.T) {
+	const before = "CREATE TABLE `fuga` ( `id` INTEGER NOT NULL, `a` INTEGER NOT NULL, INDEX `idx_a` (`a`) ); CREATE TABLE `old` ( `id` INTEGER NOT NULL );"
+	const after = "CREATE TABLE `fuga` ( `id` INTEGER NOT NULL, `b` VARCHAR (20) NOT NULL DEFAULT 'x', INDEX `idx_b` (`b`) );"
+
+	type Spec struct {
+		Format diff.OutputFormat
+		Expect string
+	}
+
+	specs := []Spec{
+		{
+			Format: diff.OutputFormatGhost,
+			Expect: "DROP TABLE `old`;\n\n" +
+				"gh-ost --database=\"app\" --table=\"fuga\" --alter=\"DROP INDEX \\`idx_a\\`, DROP COLUMN \\`a\\`, ADD COLUMN \\`b\\` VARCHAR (20) NOT NULL DEFAULT 'x' AFTER \\`id\\`, ADD INDEX \\`idx_b\\` (\\`b\\`)\" --execute",
+		},
+		{
+			Format: diff.OutputFormatPTOSC,
+			Expect: "DROP TABLE `old`;\n\n" +
+				"pt-online-schema-change --alter \"DROP INDEX \\`idx_a\\`, DROP COLUMN \\`a\\`, ADD COLUMN \\`b\\` VARCHAR (20) NOT NULL DEFAULT 'x' AFTER \\`id\\`, ADD INDEX \\`idx_b\\` (\\`b\\`)\" \"D=app,t=fuga\" --execute",
+		},
+	}
+
+	for _, spec := range specs {
+		var buf bytes.Buffer
+		err := diff.Strings(&buf, before, after, diff.WithOutputFormat(spec.Format), diff.WithDatabase("app"), diff.WithTransaction(true))
+		if !assert.NoError(t, err, "diff.Strings should succeed") {
+			return
+		}
+		if !assert.Equal(t, spec.Expect, buf.String(), "result should match") {
+			return
+		}
+	}
+
+	var buf bytes.Buffer
+	if !assert.Error(t, diff.Strings(&buf, before, after, diff.WithOutputFormat("liquibase")), "diff.Strings should fail with an unknown format") {
+		return
+	}
+}
+
+func TestAlterAlgorithm(t *testing.T) {
+	type Spec struct {
+		Before   string
+		After    string
+		Version  string
+		Combined bool
+		Expect   string
+	}
+
+	specs := []Spec{
+		// columns can be added instantly at any position as of 8.0.29
+		{
+			Before:  "CREATE TABLE `fuga` ( `id` INTEGER NOT NULL );",
+			After:   "CREATE TABLE `fuga` ( `a` INTEGER NOT NULL, `id` INTEGER NOT NULL );",
+			Version: "8.0.29",
+			Expect:  "ALTER TABLE `fuga` ADD COLUMN `a` INT (11) NOT NULL FIRST, ALGORITHM=INSTANT;",
+		},
+		{
+			Before:  "CREATE TABLE `fuga` ( `id` INTEGER NOT NULL );",
+			After:   "CREATE TABLE `fuga` ( `a` INTEGER NOT NULL, `id` INTEGER NOT NULL );",
+			Version: "5.7",
+			Expect:  "ALTER TABLE `fuga` ADD COLUMN `a` INT (11) NOT NULL FIRST, ALGORITHM=INPLACE, LOCK=NONE;",
+		},
+		// VARCHAR can be extended in place, unless the length takes more bytes
+		{
+			Before: "CREATE TABLE `fuga` ( `id` INTEGER NOT NULL, `v` VARCHAR (20) NOT NULL, `w` VARCHAR (20) NOT NULL );",
+			After:  "CREATE TABLE `fuga` ( `id` INTEGER NOT NULL, `v` VARCHAR (40) NOT NULL, `w` VARCHAR (100) NOT NULL );",
+			Expect: "ALTER TABLE `fuga` CHANGE COLUMN `v` `v` VARCHAR (40) NOT NULL, ALGORITHM=INPLACE, LOCK=NONE;\n" +
+				"-- WARNING: the following statement requires the table to be copied\n" +
+				"ALTER TABLE `fuga` CHANGE COLUMN `w` `w` VARCHAR (100) NOT NULL, ALGORITHM=COPY, LOCK=SHARED;",
+		},
+		// members can be added to the end of an ENUM instantly
+		{
+			Before: "CREATE TABLE `fuga` ( `id` INTEGER NOT NULL, `e` ENUM ('a', 'b') NOT NULL );",
+			After:  "CREATE TABLE `fuga` ( `id` INTEGER NOT NULL, `e` ENUM ('a', 'b', 'c') NOT NULL );",
+			Expect: "ALTER TABLE `fuga` CHANGE COLUMN `e` `e` ENUM ('a','b','c') NOT NULL, ALGORITHM=INSTANT;",
+		},
+		// the primary key can be replaced in place, but not dropped
+		{
+			Before:   "CREATE TABLE `fuga` ( `id` INTEGER NOT NULL, `a` INTEGER NOT NULL, PRIMARY KEY (`id`) );",
+			After:    "CREATE TABLE `fuga` ( `id` INTEGER NOT NULL, `a` INTEGER NOT NULL, PRIMARY KEY (`id`, `a`) );",
+			Combined: true,
+			Expect:   "ALTER TABLE `fuga` DROP PRIMARY KEY, ADD PRIMARY KEY (`id`, `a`), ALGORITHM=INPLACE, LOCK=NONE;",
+		},
+		{
+			Before: "CREATE TABLE `fuga` ( `id` INTEGER NOT NULL, `a` INTEGER NOT NULL, PRIMARY KEY (`id`) );",
+			After:  "CREATE TABLE `fuga` ( `id` INTEGER NOT NULL, `a` INTEGER NOT NULL, PRIMARY KEY (`id`, `a`) );",
+			Expect: "-- WARNING: the following statement requires the table to be copied\n" +
+				"ALTER TABLE `fuga` DROP PRIMARY KEY, ALGORITHM=COPY, LOCK=SHARED;\n" +
+				"ALTER TABLE `fuga` ADD PRIMARY KEY (`id`, `a`), ALGORITHM=INPLACE, LOCK=NONE;",
+		},
+		// the weakest algorithm and the strongest lock are used for
+		// combined statements
+		{
+			Before:   "CREATE TABLE `fuga` ( `id` INTEGER NOT NULL, `a` INTEGER NOT NULL, `b` TEXT NOT NULL );",
+			After:    "CREATE TABLE `fuga` ( `id` INTEGER NOT NULL, `a` INTEGER NOT NULL DEFAULT 0, `b` TEXT NOT NULL, FULLTEXT INDEX `ft_b` (`b`) );",
+			Combined: true,
+			Expect:   "ALTER TABLE `fuga` CHANGE COLUMN `a` `a` INT (11) NOT NULL DEFAULT 0, ADD FULLTEXT INDEX `ft_b` (`b`), ALGORITHM=INPLACE, LOCK=SHARED;",
+		},
+	}
+
+	for _, spec := range specs {
+		var buf bytes.Buffer
+		err := diff.Strings(&buf, spec.Before, spec.After,
+			diff.WithAlterAlgorithm(diff.AlterAlgorithmPolicy{Version: spec.Version}),
+			diff.WithCombinedAlter(spec.Combined),
+		)
+		if !assert.NoError(t, err, "diff.Strings should succeed") {
+			return
+		}
+		if !assert.Equal(t, spec.Expect, buf.String(), "result SQL should match") {
+			return
+		}
+	}
+
+	var buf bytes.Buffer
+	err := diff.Strings(&buf, "CREATE TABLE `fuga` ( `id` INTEGER NOT NULL );", "CREATE TABLE `fuga` ( `id` BIGINT NOT NULL );",
+		diff.WithAlterAlgorithm(diff.AlterAlgorithmPolicy{Copy: diff.CopyActionFail}),
+	)
+	if !assert.Error(t, err, "diff.Strings should fail") {
+		return
+	}
+	if !assert.Contains(t, err.Error(), "ALTER TABLE `fuga` CHANGE COLUMN `id` `id` BIGINT (20) NOT NULL, ALGORITHM=COPY, LOCK=SHARED;", "error should mention the statement") {
+		return
+	}
+
+	if !assert.Error(t, diff.Strings(&buf, "", "", diff.WithAlterAlgorithm(diff.AlterAlgorithmPolicy{Version: "5.6"})), "diff.Strings should fail with an unsupported version") {
+		return
+	}
+}
+
+func TestServerVersion(t *testing.T) {
+	type Spec struct {
+		Before  string
+		After   string
+		Version string
+		Expect  string
+	}
+
+	renameHints := diff.WithRenameHints(diff.RenameHints{Columns: map[string]string{"fuga.a": "b"}})
+	specs := []Spec{
+		// columns that are only renamed use RENAME COLUMN as of 8.0
+		{
+			Before:  "CREATE TABLE `fuga` ( `id` INTEGER NOT NULL, `a` INTEGER NOT NULL );",
+			After:   "CREATE TABLE `fuga` ( `id` INTEGER NOT NULL, `b` INTEGER NOT NULL );",
+			Version: "8.0",
+			Expect:  "ALTER TABLE `fuga` RENAME COLUMN `a` TO `b`;",
+		},
+		{
+			Before:  "CREATE TABLE `fuga` ( `id` INTEGER NOT NULL, `a` INTEGER NOT NULL );",
+			After:   "CREATE TABLE `fuga` ( `id` INTEGER NOT NULL, `b` BIGINT NOT NULL );",
+			Version: "8.0",
+			Expect:  "ALTER TABLE `fuga` CHANGE COLUMN `a` `b` BIGINT (20) NOT NULL;",
+		},
+		{
+			Before:  "CREATE TABLE `fuga` ( `id` INTEGER NOT NULL, `a` INTEGER NOT NULL );",
+			After:   "CREATE TABLE `fuga` ( `id` INTEGER NOT NULL, `b` INTEGER NOT NULL );",
+			Version: "5.7",
+			Expect:  "ALTER TABLE `fuga` CHANGE COLUMN `a` `b` INT (11) NOT NULL;",
+		},
+		// the default collation of the character set is filled in
+		{
+			Before:  "CREATE TABLE `fuga` ( `id` INTEGER NOT NULL ) DEFAULT CHARACTER SET = utf8mb4;",
+			After:   "CREATE TABLE `fuga` ( `id` INTEGER NOT NULL ) DEFAULT CHARACTER SET = utf8mb4 COLLATE = utf8mb4_0900_ai_ci;",
+			Version: "8.0",
+			Expect:  "",
+		},
+		{
+			Before:  "CREATE TABLE `fuga` ( `id` INTEGER NOT NULL ) DEFAULT CHARACTER SET = utf8mb4;",
+			After:   "CREATE TABLE `fuga` ( `id` INTEGER NOT NULL ) COLLATE = utf8mb4_general_ci;",
+			Version: "5.7",
+			Expect:  "",
+		},
+		{
+			Before:  "CREATE TABLE `fuga` ( `id` INTEGER NOT NULL ) DEFAULT CHARACTER SET = utf8mb4;",
+			After:   "CREATE TABLE `fuga` ( `id` INTEGER NOT NULL ) DEFAULT CHARACTER SET = utf8mb4 COLLATE = utf8mb4_general_ci;",
+			Version: "8.0",
+			Expect:  "ALTER TABLE `fuga` DEFAULT COLLATE = utf8mb4_general_ci;",
+		},
+		{
+			Before:  "CREATE TABLE `fuga` ( `id` INTEGER NOT NULL ) DEFAULT CHARACTER SET = utf8mb4;",
+			After:   "CREATE TABLE `fuga` ( `id` INTEGER NOT NULL ) DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_general_ci;",
+			Version: "10.6.12-MariaDB",
+			Expect:  "",
+		},
+		// MariaDB supports RENAME COLUMN as of 10.5.2
+		{
+			Before:  "CREATE TABLE `fuga` ( `id` INTEGER NOT NULL, `a` INTEGER NOT NULL );",
+			After:   "CREATE TABLE `fuga` ( `id` INTEGER NOT NULL, `b` INTEGER NOT NULL );",
+			Version: "10.4-MariaDB",
+			Expect:  "ALTER TABLE `fuga` CHANGE COLUMN `a` `b` INT (11) NOT NULL;",
+		},
+		// system versioning is disabled first, and enabled last
+		{
+			Before:  "CREATE TABLE `fuga` ( `id` INTEGER NOT NULL, `a` INTEGER NOT NULL ) WITH SYSTEM VERSIONING;",
+			After:   "CREATE TABLE `fuga` ( `id` INTEGER NOT NULL );",
+			Version: "10.6-MariaDB",
+			Expect:  "ALTER TABLE `fuga` DROP SYSTEM VERSIONING;\nALTER TABLE `fuga` DROP COLUMN `a`;",
+		},
+		{
+			Before:  "CREATE TABLE `fuga` ( `id` INTEGER NOT NULL );",
+			After:   "CREATE TABLE `fuga` ( `id` INTEGER NOT NULL, `a` INTEGER NOT NULL ) WITH SYSTEM VERSIONING;",
+			Version: "10.6-MariaDB",
+			Expect:  "ALTER TABLE `fuga` ADD COLUMN `a` INT (11) NOT NULL AFTER `id`;\nALTER TABLE `fuga` ADD SYSTEM VERSIONING;",
+		},
+	}
+
+	for _, spec := range specs {
+		var buf bytes.Buffer
+		err := diff.Strings(&buf, spec.Before, spec.After, renameHints, schemalex.WithServerVersion(spec.Version))
+		if !assert.NoError(t, err, "diff.Strings should succeed") {
+			return
+		}
+		if !assert.Equal(t, spec.Expect, buf.String(), "result SQL should match") {
+			return
+		}
+	}
+
+	var buf bytes.Buffer
+	err := diff.Strings(&buf, "", "CREATE TABLE `fuga` ( `id` INTEGER NOT NULL, INDEX `idx_id` (`id`) INVISIBLE );", schemalex.WithServerVersion("5.7"))
+	if !assert.Error(t, err, "diff.Strings should fail for a feature that the version does not support") {
+		return
+	}
+}
+
+func TestReplayError(t *testing.T) {
+	var buf bytes.Buffer
+	err := diff.Strings(&buf, "CREATE TABLE t (a INT NOT NULL)", "CREATE TABLE t (a INT NOT NULL); ALTER TABLE u ADD COLUMN b INT")
+	if !assert.Error(t, err, "diff.Strings should fail") {
+		return
+	}
+	assert.Contains(t, err.Error(), "table 'u' does not exist", "error should name the table")
+}
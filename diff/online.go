@@ -0,0 +1,69 @@
+package diff
+
+import (
+	"strings"
+)
+
+// OutputFormat specifies how the changes to existing tables are written
+// by Render and Statements
+type OutputFormat string
+
+// List of possible OutputFormat values. OutputFormatSQL writes ALTER TABLE
+// statements. OutputFormatGhost and OutputFormatPTOSC write a command line
+// of gh-ost or pt-online-schema-change for each table that is altered.
+// Tables that are created, dropped or renamed are always written as SQL
+const (
+	OutputFormatSQL   OutputFormat = "sql"
+	OutputFormatGhost OutputFormat = "gh-ost"
+	OutputFormatPTOSC OutputFormat = "pt-osc"
+)
+
+// ghostCommand returns the gh-ost command line that performs the alter
+// specification on the table
+func ghostCommand(database, table, alter string) string {
+	var buf strings.Builder
+	buf.WriteString("gh-ost")
+	if database != "" {
+		buf.WriteString(" --database=")
+		buf.WriteString(shellQuote(database))
+	}
+	buf.WriteString(" --table=")
+	buf.WriteString(shellQuote(table))
+	buf.WriteString(" --alter=")
+	buf.WriteString(shellQuote(alter))
+	buf.WriteString(" --execute")
+	return buf.String()
+}
+
+// ptOSCCommand returns the pt-online-schema-change command line that
+// performs the alter specification on the table
+func ptOSCCommand(database, table, alter string) string {
+	var buf strings.Builder
+	buf.WriteString("pt-online-schema-change --alter ")
+	buf.WriteString(shellQuote(alter))
+	buf.WriteByte(' ')
+	var dsn string
+	if database != "" {
+		dsn = "D=" + database + ","
+	}
+	buf.WriteString(shellQuote(dsn + "t=" + table))
+	buf.WriteString(" --execute")
+	return buf.String()
+}
+
+// shellQuote quotes the argument for a POSIX shell using double quotes.
+// The characters that are special within double quotes (including the
+// backquotes around the identifiers) are escaped
+func shellQuote(s string) string {
+	var buf strings.Builder
+	buf.WriteByte('"')
+	for _, r := range s {
+		switch r {
+		case '"', '\\', '$', '`':
+			buf.WriteByte('\\')
+		}
+		buf.WriteRune(r)
+	}
+	buf.WriteByte('"')
+	return buf.String()
+}
@@ -1,6 +1,8 @@
 package diff
 
 import (
+	"io"
+
 	"github.com/schemalex/schemalex"
 	"github.com/schemalex/schemalex/internal/option"
 )
@@ -8,8 +10,17 @@ import (
 type Option = schemalex.Option
 
 const (
-	optkeyParser      = "parser"
-	optkeyTransaction = "transaction"
+	optkeyAlterAlgorithm      = "alter-algorithm"
+	optkeyCombinedAlter       = "combined-alter"
+	optkeyDatabase            = "database"
+	optkeyIgnoredTableOptions = "ignored-table-options"
+	optkeyOutputFormat        = "output-format"
+	optkeyParser              = "parser"
+	optkeyRenameHeuristics    = "rename-heuristics"
+	optkeyRenameHints         = "rename-hints"
+	optkeyReverse             = "reverse"
+	optkeySafetyPolicy        = "safety-policy"
+	optkeyTransaction         = "transaction"
 )
 
 // WithParser specifies the parser instance to use when parsing
@@ -24,3 +35,88 @@ func WithParser(p *schemalex.Parser) Option {
 func WithTransaction(b bool) Option {
 	return option.New(optkeyTransaction, b)
 }
+
+// WithIgnoredTableOptions specifies the names of the table options
+// (e.g. "AUTO_INCREMENT", "COMMENT") that should not be compared when
+// computing the diff. Names are matched case insensitively.
+//
+// If unspecified, only AUTO_INCREMENT is ignored, as its value changes
+// with the contents of the table. Calling WithIgnoredTableOptions without
+// any arguments makes the diff compare every table option
+func WithIgnoredTableOptions(names ...string) Option {
+	return option.New(optkeyIgnoredTableOptions, names)
+}
+
+// WithRenameHints specifies the tables, columns and indexes that have
+// been renamed between the two schemas. Renamed objects are migrated
+// using RENAME TABLE, CHANGE COLUMN and RENAME INDEX, instead of being
+// dropped and added again
+func WithRenameHints(hints RenameHints) Option {
+	return option.New(optkeyRenameHints, hints)
+}
+
+// WithRenameHeuristics specifies if columns that only exist in one of
+// the two versions of a table should be treated as renamed, if their
+// definitions are identical except for their names.
+func WithRenameHeuristics(b bool) Option {
+	return option.New(optkeyRenameHeuristics, b)
+}
+
+// WithReverse specifies a destination for the statements that migrate
+// the new schema back to the old one (i.e. a "down" migration), which
+// are generated along with the regular statements. Statements that
+// restore tables or columns whose data has been lost are preceded by
+// a comment with a warning.
+func WithReverse(dst io.Writer) Option {
+	return option.New(optkeyReverse, dst)
+}
+
+// WithSafetyPolicy specifies the classes of changes (see Classify) that
+// are allowed to be generated. Statements for the changes that are not
+// allowed either make the diff fail, or are commented out, depending
+// on the action specified in the policy.
+//
+// The policy is not applied to the statements generated by WithReverse
+func WithSafetyPolicy(p SafetyPolicy) Option {
+	return option.New(optkeySafetyPolicy, p)
+}
+
+// WithCombinedAlter specifies if the changes to each table should be
+// combined into a single ALTER TABLE statement, instead of generating
+// a statement for each change. This avoids rebuilding large tables
+// more than once. Changes to the partitioning of a table are always
+// performed by a separate statement
+func WithCombinedAlter(b bool) Option {
+	return option.New(optkeyCombinedAlter, b)
+}
+
+// WithOutputFormat specifies how the changes to existing tables are
+// written. If unspecified, OutputFormatSQL is used.
+//
+// With OutputFormatGhost and OutputFormatPTOSC, the changes to each table
+// are combined into the --alter argument of a single command line (see
+// WithCombinedAlter), and WithTransaction is ignored
+func WithOutputFormat(f OutputFormat) Option {
+	return option.New(optkeyOutputFormat, f)
+}
+
+// WithDatabase specifies the name of the database that the changes are
+// applied to. It is passed to the online schema change tools by the
+// command lines written with WithOutputFormat
+func WithDatabase(name string) Option {
+	return option.New(optkeyDatabase, name)
+}
+
+// WithAlterAlgorithm specifies that the ALGORITHM and LOCK clauses should
+// be added to the generated ALTER TABLE statements. The strongest algorithm
+// (INSTANT, INPLACE or COPY) and the weakest lock that the changes support
+// on the given version of MySQL are used, so that MySQL refuses to execute
+// the statement instead of silently blocking concurrent access to the
+// table. The action for the statements that require COPY is taken from
+// the policy.
+//
+// The clauses are only added to the SQL output format, and not to changes
+// to the partitioning of a table
+func WithAlterAlgorithm(p AlterAlgorithmPolicy) Option {
+	return option.New(optkeyAlterAlgorithm, p)
+}
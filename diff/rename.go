@@ -0,0 +1,574 @@
+package diff
+
+import (
+	"bytes"
+	"sort"
+	"strings"
+
+	"github.com/schemalex/schemalex/format"
+	"github.com/schemalex/schemalex/internal/errors"
+	"github.com/schemalex/schemalex/model"
+)
+
+// RenameHints describes the tables, columns and indexes that have been
+// renamed between the two schemas. Without them, a renamed object is
+// indistinguishable from an object that was dropped and another one that
+// was added, which means that its data would be lost.
+//
+// Tables maps the old name of a table to its new name. Columns and
+// Indexes map "table.old_name" to the new name of the column or index,
+// where table is the name of the table in the old schema. The names of
+// the tables that belong to a specific database are qualified by the
+// name of the database ("db.table").
+//
+// Hints that do not apply to the schemas being compared (e.g. because the
+// object does not exist, or has already been renamed) are ignored.
+type RenameHints struct {
+	Tables  map[string]string
+	Columns map[string]string
+	Indexes map[string]string
+}
+
+// renames holds the renames that apply to the schemas being compared.
+// Everything is keyed by the names used in the old schema. The names of
+// the tables are qualified by their databases (see tableKey)
+type renames struct {
+	tables  map[string]string            // table -> new table name
+	columns map[string]map[string]string // table -> column -> new column name
+	indexes map[string]map[string]string // table -> index -> new index name
+
+	// the tables in the old schema, before any renames were applied,
+	// and the tables in the new schema
+	fromTables map[string]model.Table
+	toTables   map[string]model.Table
+
+	// these are filled by apply(), and are keyed by the names used in
+	// the new schema, as that is what alterCtx works with. the values
+	// are the columns and indexes from the old schema
+	renamedColumns map[string]map[string]model.TableColumn // table -> new column name -> column
+	renamedIndexes map[string]map[string]model.Index       // table -> new index name -> index
+}
+
+func splitQualifiedHints(hints map[string]string) map[string]map[string]string {
+	m := make(map[string]map[string]string)
+	for k, v := range hints {
+		i := strings.LastIndexByte(k, '.')
+		if i <= 0 || i == len(k)-1 {
+			continue
+		}
+		table, name := k[:i], k[i+1:]
+		if m[table] == nil {
+			m[table] = make(map[string]string)
+		}
+		m[table][name] = v
+	}
+	return m
+}
+
+// qualifiedName returns the name qualified by the name of the database,
+// if it is not empty
+func qualifiedName(schema, name string) string {
+	if schema == "" {
+		return name
+	}
+	return schema + "." + name
+}
+
+// tableKey returns the name of the table qualified by its database, which
+// identifies the table among the tables of all of the databases
+func tableKey(table model.Table) string {
+	return qualifiedName(table.Schema(), table.Name())
+}
+
+// referenceKey returns the key (see tableKey) of the table that the
+// reference refers to. References that are not qualified refer to the
+// tables in the same database as the table that holds them
+func referenceKey(table model.Table, ref model.Reference) string {
+	schema := ref.Schema()
+	if schema == "" {
+		schema = table.Schema()
+	}
+	return qualifiedName(schema, ref.TableName())
+}
+
+func tablesByName(stmts model.Stmts) map[string]model.Table {
+	m := make(map[string]model.Table)
+	for _, stmt := range stmts {
+		if table, ok := stmt.(model.Table); ok {
+			m[tableKey(table)] = table
+		}
+	}
+	return m
+}
+
+func columnsByName(table model.Table) map[string]model.TableColumn {
+	m := make(map[string]model.TableColumn)
+	for col := range table.Columns() {
+		m[col.Name()] = col
+	}
+	return m
+}
+
+func indexesByName(table model.Table) map[string]model.Index {
+	m := make(map[string]model.Index)
+	for idx := range table.Indexes() {
+		if idx.HasName() {
+			m[idx.Name()] = idx
+		}
+	}
+	return m
+}
+
+// resolveRenames validates the given hints against the two schemas, and
+// if heuristics is true, looks for columns that only differ by their names
+func resolveRenames(from, to model.Stmts, hints RenameHints, heuristics bool) (*renames, error) {
+	r := &renames{
+		tables:         make(map[string]string),
+		columns:        make(map[string]map[string]string),
+		indexes:        make(map[string]map[string]string),
+		renamedColumns: make(map[string]map[string]model.TableColumn),
+		renamedIndexes: make(map[string]map[string]model.Index),
+	}
+
+	fromTables := tablesByName(from)
+	r.fromTables = fromTables
+	toTables := tablesByName(to)
+	r.toTables = toTables
+	for oldName, newName := range hints.Tables {
+		_, fromHasOld := fromTables[oldName]
+		_, fromHasNew := fromTables[newName]
+		_, toHasOld := toTables[oldName]
+		_, toHasNew := toTables[newName]
+		if fromHasOld && toHasNew && !fromHasNew && !toHasOld {
+			r.tables[oldName] = newName
+		}
+	}
+
+	columnHints := splitQualifiedHints(hints.Columns)
+	indexHints := splitQualifiedHints(hints.Indexes)
+	for name, fromTable := range fromTables {
+		newName := name
+		if v, ok := r.tables[name]; ok {
+			newName = v
+		}
+		toTable, ok := toTables[newName]
+		if !ok {
+			continue
+		}
+
+		fromColumns := columnsByName(fromTable)
+		toColumns := columnsByName(toTable)
+		columns := make(map[string]string)
+		targets := make(map[string]struct{})
+		for oldCol, newCol := range columnHints[name] {
+			_, fromHasOld := fromColumns[oldCol]
+			_, fromHasNew := fromColumns[newCol]
+			_, toHasOld := toColumns[oldCol]
+			_, toHasNew := toColumns[newCol]
+			if fromHasOld && toHasNew && !fromHasNew && !toHasOld {
+				columns[oldCol] = newCol
+				targets[newCol] = struct{}{}
+			}
+		}
+
+		if heuristics {
+			if err := matchRenamedColumns(fromTable, toTable, columns, targets); err != nil {
+				return nil, err
+			}
+		}
+		if len(columns) > 0 {
+			r.columns[name] = columns
+		}
+
+		fromIndexes := indexesByName(fromTable)
+		toIndexes := indexesByName(toTable)
+		indexes := make(map[string]string)
+		for oldIdx, newIdx := range indexHints[name] {
+			idx, fromHasOld := fromIndexes[oldIdx]
+			_, fromHasNew := fromIndexes[newIdx]
+			_, toHasOld := toIndexes[oldIdx]
+			_, toHasNew := toIndexes[newIdx]
+			if !fromHasOld || !toHasNew || fromHasNew || toHasOld {
+				continue
+			}
+			// primary keys do not have names, and foreign keys can only
+			// be dropped and added again
+			if idx.IsPrimaryKey() || idx.IsForeignKey() {
+				continue
+			}
+			indexes[oldIdx] = newIdx
+		}
+		if len(indexes) > 0 {
+			r.indexes[name] = indexes
+		}
+	}
+
+	return r, nil
+}
+
+// matchRenamedColumns pairs columns that only exist in the old table with
+// columns that only exist in the new table, if their definitions are
+// identical except for their names. Only unambiguous pairs are matched
+func matchRenamedColumns(fromTable, toTable model.Table, columns map[string]string, targets map[string]struct{}) error {
+	var dropped, added []model.TableColumn
+	for col := range fromTable.Columns() {
+		if _, ok := columns[col.Name()]; ok {
+			continue
+		}
+		if _, ok := toTable.LookupColumn(col.ID()); !ok {
+			dropped = append(dropped, col)
+		}
+	}
+	for col := range toTable.Columns() {
+		if _, ok := targets[col.Name()]; ok {
+			continue
+		}
+		if _, ok := fromTable.LookupColumn(col.ID()); !ok {
+			added = append(added, col)
+		}
+	}
+	if len(dropped) == 0 || len(added) == 0 {
+		return nil
+	}
+
+	definitions := make(map[string][]model.TableColumn)
+	for _, col := range added {
+		var buf bytes.Buffer
+		if err := format.SQL(&buf, col.Clone().SetName("")); err != nil {
+			return err
+		}
+		definitions[buf.String()] = append(definitions[buf.String()], col)
+	}
+
+	var candidates = make(map[string][]model.TableColumn)
+	for _, col := range dropped {
+		var buf bytes.Buffer
+		if err := format.SQL(&buf, col.Clone().SetName("")); err != nil {
+			return err
+		}
+		candidates[buf.String()] = append(candidates[buf.String()], col)
+	}
+
+	for def, cols := range candidates {
+		if len(cols) != 1 || len(definitions[def]) != 1 {
+			continue
+		}
+		newCol := definitions[def][0].Name()
+		columns[cols[0].Name()] = newCol
+		targets[newCol] = struct{}{}
+	}
+	return nil
+}
+
+func (r *renames) renamedTable(name string) string {
+	if v, ok := r.tables[name]; ok {
+		return v
+	}
+	return name
+}
+
+func (r *renames) renamedColumn(table, name string) string {
+	if v, ok := r.columns[table][name]; ok {
+		return v
+	}
+	return name
+}
+
+// apply returns a copy of the old schema with all of the renames
+// applied, so that the renamed objects compare equal to their
+// counterparts in the new schema
+func (r *renames) apply(from, to model.Stmts) model.Stmts {
+	if len(r.tables) == 0 && len(r.columns) == 0 && len(r.indexes) == 0 {
+		return from
+	}
+
+	stmts := make(model.Stmts, 0, len(from))
+	for _, stmt := range from {
+		table, ok := stmt.(model.Table)
+		if !ok {
+			stmts = append(stmts, stmt)
+			continue
+		}
+		if !r.affects(table) {
+			stmts = append(stmts, table)
+			continue
+		}
+		stmts = append(stmts, r.applyTable(table, r.toTables[r.renamedTable(tableKey(table))]))
+	}
+	return stmts
+}
+
+// affects returns true if the table, or any of the tables that it refers
+// to, has been renamed or has renamed columns or indexes
+func (r *renames) affects(table model.Table) bool {
+	affected := func(name string) bool {
+		_, renamed := r.tables[name]
+		return renamed || len(r.columns[name]) > 0 || len(r.indexes[name]) > 0
+	}
+
+	if affected(tableKey(table)) {
+		return true
+	}
+	for idx := range table.Indexes() {
+		if ref := idx.Reference(); ref != nil && affected(referenceKey(table, ref)) {
+			return true
+		}
+	}
+	return false
+}
+
+func (r *renames) applyTable(table, toTable model.Table) model.Table {
+	oldName := tableKey(table)
+	newName := r.renamedTable(oldName)
+
+	// toTable is only missing if the table is not renamed
+	tbl := model.NewTable(table.Name())
+	tbl.SetSchema(table.Schema())
+	if toTable != nil {
+		tbl = model.NewTable(toTable.Name())
+		tbl.SetSchema(toTable.Schema())
+	}
+	tbl.SetIfNotExists(table.IsIfNotExists())
+	tbl.SetTemporary(table.IsTemporary())
+	if table.HasLikeTable() {
+		like := table.LikeTable()
+		if v, ok := r.toTables[r.renamedTable(qualifiedName(table.Schema(), like))]; ok {
+			like = v.Name()
+		}
+		tbl.SetLikeTable(like)
+	}
+
+	for col := range table.Columns() {
+		if v, ok := r.columns[oldName][col.Name()]; ok {
+			if r.renamedColumns[newName] == nil {
+				r.renamedColumns[newName] = make(map[string]model.TableColumn)
+			}
+			r.renamedColumns[newName][v] = col
+			col = col.Clone().SetName(v)
+		}
+		tbl.AddColumn(col)
+	}
+
+	for idx := range table.Indexes() {
+		name := idx.Name()
+		if v, ok := r.indexes[oldName][name]; ok {
+			// only rename the index if it is otherwise identical to the
+			// one in the new schema. if it is not, it has to be dropped
+			// and added anyway
+			renamed := r.applyIndex(idx, table, tbl, v)
+			if toTable != nil {
+				if _, ok := toTable.LookupIndex(renamed.ID()); ok {
+					if r.renamedIndexes[newName] == nil {
+						r.renamedIndexes[newName] = make(map[string]model.Index)
+					}
+					r.renamedIndexes[newName][v] = idx
+					tbl.AddIndex(renamed)
+					continue
+				}
+			}
+		}
+		tbl.AddIndex(r.applyIndex(idx, table, tbl, name))
+	}
+
+	for check := range table.CheckConstraints() {
+		c := model.NewCheckConstraint(tbl.ID())
+		c.SetExpression(check.Expression())
+		c.SetEnforced(check.IsEnforced())
+		if check.HasName() {
+			// MySQL renames the generated names of check constraints
+			// along with the table
+			name := check.Name()
+			if prefix := table.Name() + "_chk_"; strings.HasPrefix(name, prefix) {
+				name = tbl.Name() + "_chk_" + strings.TrimPrefix(name, prefix)
+			}
+			c.SetName(name)
+		}
+		tbl.AddCheckConstraint(c)
+	}
+
+	for opt := range table.Options() {
+		tbl.AddOption(opt)
+	}
+
+	if table.HasPartitioning() {
+		tbl.SetPartitioning(table.Partitioning())
+	}
+	tbl.SetSystemVersioned(table.IsSystemVersioned())
+	return tbl
+}
+
+func (r *renames) applyIndexColumns(dst model.ColumnContainer, src model.ColumnContainer, table string) {
+	for col := range src.Columns() {
+		c := model.NewIndexColumn(r.renamedColumn(table, col.Name()))
+		if col.HasLength() {
+			c.SetLength(col.Length())
+		}
+		switch {
+		case col.IsAscending():
+			c.SetSortDirection(model.SortDirectionAscending)
+		case col.IsDescending():
+			c.SetSortDirection(model.SortDirectionDescending)
+		}
+		dst.AddColumns(c)
+	}
+}
+
+// applyIndex returns a copy of the index of the table in the old schema,
+// to be added to tbl, the same table with the renames applied
+func (r *renames) applyIndex(idx model.Index, table, tbl model.Table, name string) model.Index {
+	var kind model.IndexKind
+	switch {
+	case idx.IsPrimaryKey():
+		kind = model.IndexKindPrimaryKey
+	case idx.IsUnique():
+		kind = model.IndexKindUnique
+	case idx.IsFullText():
+		kind = model.IndexKindFullText
+	case idx.IsSpatial():
+		kind = model.IndexKindSpatial
+	case idx.IsForeignKey():
+		kind = model.IndexKindForeignKey
+	default:
+		kind = model.IndexKindNormal
+	}
+
+	newidx := model.NewIndex(kind, tbl.ID())
+	if idx.HasName() {
+		newidx.SetName(name)
+	}
+	if idx.HasSymbol() {
+		newidx.SetSymbol(idx.Symbol())
+	}
+	switch {
+	case idx.IsBtree():
+		newidx.SetType(model.IndexTypeBtree)
+	case idx.IsHash():
+		newidx.SetType(model.IndexTypeHash)
+	}
+	r.applyIndexColumns(newidx, idx, tableKey(table))
+
+	if ref := idx.Reference(); ref != nil {
+		refKey := referenceKey(table, ref)
+		schema, refName := ref.Schema(), ref.TableName()
+		if schema == "" {
+			schema = table.Schema()
+		}
+		if v, ok := r.toTables[r.renamedTable(refKey)]; ok && r.renamedTable(refKey) != refKey {
+			schema, refName = v.Schema(), v.Name()
+		}
+		if schema == tbl.Schema() {
+			schema = ""
+		}
+
+		newref := model.NewReference()
+		newref.SetSchema(schema)
+		newref.SetTableName(refName)
+		switch {
+		case ref.MatchFull():
+			newref.SetMatch(model.ReferenceMatchFull)
+		case ref.MatchPartial():
+			newref.SetMatch(model.ReferenceMatchPartial)
+		case ref.MatchSimple():
+			newref.SetMatch(model.ReferenceMatchSimple)
+		}
+		newref.SetOnDelete(ref.OnDelete())
+		newref.SetOnUpdate(ref.OnUpdate())
+		r.applyIndexColumns(newref, ref, refKey)
+		newidx.SetReference(newref)
+	}
+
+	for opt := range idx.Options() {
+		newidx.AddOption(opt)
+	}
+	return newidx
+}
+
+func renameTables(ctx *diffCtx) ([]Change, error) {
+	var names []string
+	for name := range ctx.renames.tables {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var changes []Change
+	for _, name := range names {
+		table, ok := ctx.renames.toTables[ctx.renames.tables[name]]
+		if !ok {
+			return nil, errors.Errorf(`table '%s' not found in new schema (rename table)`, ctx.renames.tables[name])
+		}
+		changes = append(changes, &TableRenamed{
+			Before: ctx.renames.fromTables[name],
+			After:  table,
+		})
+	}
+	return changes, nil
+}
+
+// columns are renamed before new columns are added, because the new
+// columns may be positioned after them
+func renameTableColumns(ctx *alterCtx) ([]Change, error) {
+	var columns []model.TableColumn
+	for name := range ctx.renamedColumns {
+		col, ok := ctx.to.LookupColumn(model.NewTableColumn(name).ID())
+		if !ok {
+			return nil, errors.Errorf(`column %s not found in new schema`, name)
+		}
+		columns = append(columns, col)
+	}
+	sort.Slice(columns, func(i, j int) bool {
+		iorder, _ := ctx.to.LookupColumnOrder(columns[i].ID())
+		jorder, _ := ctx.to.LookupColumnOrder(columns[j].ID())
+		return iorder < jorder
+	})
+
+	var changes []Change
+	for _, col := range columns {
+		changes = append(changes, &ColumnModified{
+			Table:  ctx.to.Name(),
+			Schema: ctx.to.Schema(),
+			Before: ctx.renamedColumns[col.Name()],
+			After:  col,
+		})
+	}
+	return changes, nil
+}
+
+// isColumnRenameOnly returns true if the columns differ only by their names
+func isColumnRenameOnly(before, after model.TableColumn) bool {
+	if before.Name() == after.Name() {
+		return false
+	}
+	renamed := before.Clone()
+	renamed.SetName(after.Name())
+	renamed.SetTableID(after.TableID())
+	return sameColumn(renamed, after)
+}
+
+func renameTableIndexes(ctx *alterCtx) ([]Change, error) {
+	var names []string
+	for name := range ctx.renamedIndexes {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var changes []Change
+	for _, name := range names {
+		var after model.Index
+		for idx := range ctx.to.Indexes() {
+			if idx.HasName() && idx.Name() == name {
+				after = idx
+				break
+			}
+		}
+		if after == nil {
+			return nil, errors.Errorf(`index '%s' not found in new schema (rename index)`, name)
+		}
+		changes = append(changes, &IndexRenamed{
+			Table:  ctx.to.Name(),
+			Schema: ctx.to.Schema(),
+			Before: ctx.renamedIndexes[name],
+			After:  after,
+		})
+	}
+	return changes, nil
+}
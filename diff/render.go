@@ -0,0 +1,955 @@
+package diff
+
+import (
+	"bytes"
+	"io"
+	"strconv"
+	"strings"
+
+	"github.com/schemalex/schemalex"
+	"github.com/schemalex/schemalex/format"
+	"github.com/schemalex/schemalex/internal/errors"
+	"github.com/schemalex/schemalex/internal/util"
+	"github.com/schemalex/schemalex/model"
+)
+
+// Render generates the SQL statements for the changes in the Changeset,
+// and writes them to `dst`. The output is the same as that of Statements
+func Render(dst io.Writer, changes *Changeset, options ...Option) error {
+	var txn bool
+	var combine bool
+	r := renderer{
+		format:   OutputFormatSQL,
+		warnings: make(map[Change][]string),
+	}
+
+	version, hasVersion, err := schemalex.ServerVersionFromOptions(options...)
+	if err != nil {
+		return errors.Wrap(err, `invalid server version`)
+	}
+	if hasVersion {
+		r.version = &version
+		r.formatOptions = []format.Option{schemalex.WithServerVersion(version.String())}
+	}
+	for _, o := range options {
+		switch o.Name() {
+		case optkeyTransaction:
+			txn = o.Value().(bool)
+		case optkeyCombinedAlter:
+			combine = o.Value().(bool)
+		case optkeyAlterAlgorithm:
+			p := o.Value().(AlterAlgorithmPolicy)
+			r.algorithm = &p
+		case optkeyDatabase:
+			r.database = o.Value().(string)
+		case optkeyOutputFormat:
+			r.format = o.Value().(OutputFormat)
+		case optkeySafetyPolicy:
+			p := o.Value().(SafetyPolicy)
+			r.policy = &p
+		}
+	}
+
+	switch r.format {
+	case OutputFormatSQL:
+	case OutputFormatGhost, OutputFormatPTOSC:
+		// the online schema change tools rebuild the table once for
+		// all of the changes, and do not run in a transaction
+		combine = true
+		txn = false
+	default:
+		return errors.Errorf(`unknown output format %q`, r.format)
+	}
+
+	if r.algorithm != nil && r.format == OutputFormatSQL {
+		v := schemalex.ServerVersion{Major: 8, Minor: 0, Patch: -1}
+		if r.version != nil {
+			v = *r.version
+		}
+		if r.algorithm.Version != "" {
+			if v, err = schemalex.ParseServerVersion(r.algorithm.Version); err != nil {
+				return errors.Wrap(err, `invalid alter algorithm policy`)
+			}
+		}
+		if v.IsMariaDB() {
+			return errors.Errorf(`unsupported server version %q: online DDL rules are only known for MySQL`, v)
+		}
+		if !v.AtLeast(5, 7, 0) {
+			return errors.Errorf(`unsupported server version %q: online DDL rules are only known for 5.7 and later`, v)
+		}
+		// foreign_key_checks is disabled within the transaction
+		r.online = &onlineDDL{version: v, foreignKeyChecks: !txn}
+	}
+
+	var buf bytes.Buffer
+	if txn {
+		buf.WriteString("\nBEGIN;\n\nSET FOREIGN_KEY_CHECKS = 0;")
+	}
+
+	for _, w := range changes.Warnings {
+		r.warnings[w.Change] = append(r.warnings[w.Change], w.Message)
+	}
+
+	// statements are grouped by the kind of the change. groups are
+	// separated by an empty line
+	prevGroup := -1
+	for i := 0; i < len(changes.Changes); {
+		change := changes.Changes[i]
+		i++
+
+		var stmts []string
+		if combine && isCombinable(change) {
+			// consecutive changes to the same table are combined. the
+			// changes are already in the order that the clauses have
+			// to be listed in
+			run := []Change{change}
+			for ; i < len(changes.Changes); i++ {
+				next := changes.Changes[i]
+				if !isCombinable(next) || next.TableName() != change.TableName() || next.SchemaName() != change.SchemaName() {
+					break
+				}
+				run = append(run, next)
+			}
+			stmts, err = r.renderCombined(run)
+		} else {
+			stmts, err = r.render(change)
+		}
+		if err != nil {
+			return errors.Wrap(err, `failed to render diff`)
+		}
+		if len(stmts) == 0 {
+			continue
+		}
+
+		group := changeGroup(change)
+		switch {
+		case group != prevGroup && (txn || buf.Len() > 0):
+			buf.WriteString("\n\n")
+		case group == prevGroup:
+			buf.WriteByte('\n')
+		}
+		prevGroup = group
+
+		buf.WriteString(strings.Join(stmts, "\n"))
+	}
+
+	if len(r.disallowed) > 0 {
+		return errors.Errorf("changes not allowed by the safety policy:\n%s", strings.Join(r.disallowed, "\n"))
+	}
+	if len(r.copyRequired) > 0 {
+		return errors.Errorf("changes that require ALGORITHM=COPY:\n%s", strings.Join(r.copyRequired, "\n"))
+	}
+
+	if txn {
+		buf.WriteString("\n\nSET FOREIGN_KEY_CHECKS = 1;\n\nCOMMIT;")
+	}
+
+	if _, err := buf.WriteTo(dst); err != nil {
+		return errors.Wrap(err, `failed to write diff`)
+	}
+	return nil
+}
+
+type renderer struct {
+	algorithm *AlterAlgorithmPolicy
+	database  string
+	format    OutputFormat
+	online    *onlineDDL
+	policy    *SafetyPolicy
+	version   *schemalex.ServerVersion
+	warnings  map[Change][]string
+
+	// options passed to format.SQL
+	formatOptions []format.Option
+
+	// statements that were rejected by the safety policy, or by the
+	// alter algorithm policy
+	copyRequired []string
+	disallowed   []string
+}
+
+// render returns the statements for a single change, preceded by the
+// warnings about it
+func (r *renderer) render(change Change) ([]string, error) {
+	stmts, err := r.renderChange(change, r.allows(change))
+	if err != nil {
+		return nil, err
+	}
+	if len(stmts) == 0 {
+		return nil, nil
+	}
+
+	if stmts, _ = r.applyPolicy(change, stmts); stmts == nil {
+		return nil, nil
+	}
+	return append(r.warningLines(change), stmts...), nil
+}
+
+// renderCombined returns a single ALTER TABLE statement for the changes,
+// which must all apply to the same table. Changes that are not allowed by
+// the safety policy are left out of the statement, and are commented out
+// separately if the policy says so
+func (r *renderer) renderCombined(changes []Change) ([]string, error) {
+	var warnings, clauses, rejected []string
+	var allowed []Change
+	for _, change := range changes {
+		l, err := r.alterClauses(change)
+		if err != nil {
+			return nil, err
+		}
+		if len(l) == 0 {
+			continue
+		}
+
+		stmts, ok := r.applyPolicy(change, []string{r.alterStatement(change.SchemaName(), change.TableName(), l)})
+		if !ok {
+			if stmts != nil {
+				rejected = append(rejected, r.warningLines(change)...)
+				rejected = append(rejected, stmts...)
+			}
+			continue
+		}
+		warnings = append(warnings, r.warningLines(change)...)
+		clauses = append(clauses, l...)
+		allowed = append(allowed, change)
+	}
+
+	stmts := warnings
+	if len(clauses) > 0 {
+		stmts = append(stmts, r.annotatedAlterStatement(changes[0].SchemaName(), changes[0].TableName(), allowed, clauses)...)
+	}
+	return append(stmts, rejected...), nil
+}
+
+// annotatedAlterStatement returns the ALTER TABLE statement that performs
+// the clauses, with the ALGORITHM and LOCK clauses that apply to the
+// changes, if WithAlterAlgorithm was specified. Statements that require
+// ALGORITHM=COPY are preceded by a warning, or are recorded so that
+// Render fails
+func (r *renderer) annotatedAlterStatement(schema, table string, changes []Change, clauses []string) []string {
+	if r.online == nil {
+		return []string{r.alterStatement(schema, table, clauses)}
+	}
+
+	a, l := r.online.statement(changes)
+	clauses = append(clauses, "ALGORITHM="+a.String())
+	if a != algorithmInstant {
+		clauses = append(clauses, "LOCK="+l.String())
+	}
+	stmt := r.alterStatement(schema, table, clauses)
+	if a != algorithmCopy {
+		return []string{stmt}
+	}
+
+	if r.algorithm.Copy == CopyActionFail {
+		r.copyRequired = append(r.copyRequired, stmt)
+	}
+	return []string{"-- WARNING: the following statement requires the table to be copied", stmt}
+}
+
+// allows returns true if the change is allowed by the safety policy
+func (r *renderer) allows(change Change) bool {
+	return r.policy == nil || Classify(change) <= r.policy.Allow
+}
+
+// applyPolicy checks the statements for the change against the safety
+// policy. If the change is not allowed, the statements are either
+// recorded as disallowed and nil is returned, or they are returned
+// commented out
+func (r *renderer) applyPolicy(change Change, stmts []string) ([]string, bool) {
+	if r.policy == nil {
+		return stmts, true
+	}
+
+	if r.allows(change) {
+		return stmts, true
+	}
+	safety := Classify(change)
+	if r.policy.Action == SafetyActionFail {
+		r.disallowed = append(r.disallowed, safety.String()+": "+strings.Join(stmts, " "))
+		return nil, false
+	}
+	return commentOut(stmts, safety), false
+}
+
+func (r *renderer) warningLines(change Change) []string {
+	var l []string
+	for _, msg := range r.warnings[change] {
+		l = append(l, "-- WARNING: "+msg)
+	}
+	return l
+}
+
+// commentOut turns the statements into SQL comments, so that they are
+// not executed
+func commentOut(stmts []string, safety Safety) []string {
+	l := []string{"-- WARNING: the following " + safety.String() + " statement is not allowed by the safety policy"}
+	for _, stmt := range stmts {
+		for _, line := range strings.Split(stmt, "\n") {
+			l = append(l, "-- "+line)
+		}
+	}
+	return l
+}
+
+// isCombinable returns true if the change can be performed as a part of
+// an ALTER TABLE statement along with other changes. Changes to the
+// partitioning of a table must be performed by a statement of their own
+func isCombinable(change Change) bool {
+	switch change.(type) {
+	case *TableRenamed, *TableDropped, *TableAdded, *PartitioningModified,
+		*ViewDropped, *ViewAdded, *ViewModified, *TriggerDropped, *TriggerAdded,
+		*RoutineDropped, *RoutineAdded, *EventDropped, *EventAdded, *EventModified,
+		*DatabaseDropped, *DatabaseAdded, *DatabaseModified:
+		return false
+	}
+	return true
+}
+
+// alterStatement returns an ALTER TABLE statement that performs the
+// given clauses, or the command line of the online schema change tool
+// that performs them. The tools are given the database of the table if
+// it is qualified, or the one given by WithDatabase otherwise
+func (r *renderer) alterStatement(schema, table string, clauses []string) string {
+	alter := strings.Join(clauses, ", ")
+	database := r.database
+	if schema != "" {
+		database = schema
+	}
+	switch r.format {
+	case OutputFormatGhost:
+		return ghostCommand(database, table, alter)
+	case OutputFormatPTOSC:
+		return ptOSCCommand(database, table, alter)
+	default:
+		return "ALTER TABLE " + util.BackquoteQualified(schema, table) + " " + alter + ";"
+	}
+}
+
+func changeGroup(change Change) int {
+	switch change.(type) {
+	case *TableRenamed:
+		return 0
+	case *TableDropped:
+		return 1
+	case *TableAdded:
+		return 2
+	case *ViewDropped:
+		return 4
+	case *ViewAdded, *ViewModified:
+		return 5
+	case *TriggerDropped:
+		return 6
+	case *TriggerAdded:
+		return 7
+	case *RoutineDropped:
+		return 8
+	case *RoutineAdded:
+		return 9
+	case *EventDropped:
+		return 10
+	case *EventAdded, *EventModified:
+		return 11
+	case *DatabaseAdded:
+		return 12
+	case *DatabaseModified:
+		return 13
+	case *DatabaseDropped:
+		return 14
+	default:
+		return 3
+	}
+}
+
+// renderChange returns the statements (terminated by a semicolon) that
+// perform the change. If annotate is false, the ALGORITHM and LOCK
+// clauses are not added
+func (r *renderer) renderChange(change Change, annotate bool) ([]string, error) {
+	switch c := change.(type) {
+	case *TableRenamed:
+		return []string{"RENAME TABLE " + util.BackquoteQualified(c.Before.Schema(), c.Before.Name()) + " TO " + util.BackquoteQualified(c.After.Schema(), c.After.Name()) + ";"}, nil
+	case *TableDropped:
+		return []string{"DROP TABLE " + util.BackquoteQualified(c.Table.Schema(), c.Table.Name()) + ";"}, nil
+	case *TableAdded:
+		var buf bytes.Buffer
+		if err := format.SQL(&buf, c.Table, r.formatOptions...); err != nil {
+			return nil, err
+		}
+		buf.WriteByte(';')
+		return []string{buf.String()}, nil
+	case *ViewDropped:
+		return []string{"DROP VIEW " + util.BackquoteQualified(c.View.Schema(), c.View.Name()) + ";"}, nil
+	case *ViewAdded:
+		return r.createView(c.View)
+	case *ViewModified:
+		return r.createView(c.After)
+	case *TriggerDropped:
+		return []string{"DROP TRIGGER " + util.BackquoteQualified(c.Trigger.Schema(), c.Trigger.Name()) + ";"}, nil
+	case *TriggerAdded:
+		var buf bytes.Buffer
+		if err := format.SQL(&buf, c.Trigger, r.formatOptions...); err != nil {
+			return nil, err
+		}
+		return delimited(buf.String()), nil
+	case *RoutineDropped:
+		if c.Routine.IsFunction() {
+			return []string{"DROP FUNCTION " + util.BackquoteQualified(c.Routine.Schema(), c.Routine.Name()) + ";"}, nil
+		}
+		return []string{"DROP PROCEDURE " + util.BackquoteQualified(c.Routine.Schema(), c.Routine.Name()) + ";"}, nil
+	case *RoutineAdded:
+		var buf bytes.Buffer
+		if err := format.SQL(&buf, c.Routine, r.formatOptions...); err != nil {
+			return nil, err
+		}
+		return delimited(buf.String()), nil
+	case *EventDropped:
+		return []string{"DROP EVENT " + util.BackquoteQualified(c.Event.Schema(), c.Event.Name()) + ";"}, nil
+	case *EventAdded:
+		var buf bytes.Buffer
+		if err := format.SQL(&buf, c.Event, r.formatOptions...); err != nil {
+			return nil, err
+		}
+		return delimited(buf.String()), nil
+	case *EventModified:
+		return delimited(alterEventStatement(c.Before, c.After)), nil
+	case *DatabaseDropped:
+		return []string{"DROP DATABASE " + util.Backquote(c.Database.Name()) + ";"}, nil
+	case *DatabaseAdded:
+		var buf bytes.Buffer
+		if err := format.SQL(&buf, c.Database, r.formatOptions...); err != nil {
+			return nil, err
+		}
+		return []string{buf.String()}, nil
+	case *DatabaseModified:
+		return []string{"ALTER DATABASE " + util.Backquote(c.After.Name()) + " " + strings.Join(alterDatabaseOptions(c.Before, c.After), " ") + ";"}, nil
+	}
+
+	clauses, err := r.alterClauses(change)
+	if err != nil {
+		return nil, err
+	}
+
+	// changes to the partitioning are not annotated, as they support
+	// a different set of algorithms and locks
+	_, partitioning := change.(*PartitioningModified)
+
+	var stmts []string
+	for _, clause := range clauses {
+		if annotate && !partitioning {
+			stmts = append(stmts, r.annotatedAlterStatement(change.SchemaName(), change.TableName(), []Change{change}, []string{clause})...)
+			continue
+		}
+		stmts = append(stmts, r.alterStatement(change.SchemaName(), change.TableName(), []string{clause}))
+	}
+	return stmts, nil
+}
+
+// createView returns the CREATE OR REPLACE VIEW statement for the view,
+// which works regardless of whether the view exists
+func (r *renderer) createView(view model.View) ([]string, error) {
+	var buf bytes.Buffer
+	if err := format.SQL(&buf, view.Clone().SetOrReplace(true), r.formatOptions...); err != nil {
+		return nil, err
+	}
+	buf.WriteByte(';')
+	return []string{buf.String()}, nil
+}
+
+// alterEventStatement returns the ALTER EVENT statement that changes the
+// clauses of the event that differ
+func alterEventStatement(before, after model.Event) string {
+	var buf bytes.Buffer
+	buf.WriteString("ALTER")
+	if before.HasDefiner() && after.HasDefiner() && before.Definer() != after.Definer() {
+		buf.WriteString(" DEFINER = ")
+		buf.WriteString(after.Definer())
+	}
+	buf.WriteString(" EVENT ")
+	buf.WriteString(util.BackquoteQualified(after.Schema(), after.Name()))
+
+	if !sameEventSchedule(before, after) {
+		buf.WriteString(" ON SCHEDULE ")
+		if after.HasAt() {
+			buf.WriteString("AT ")
+			buf.WriteString(after.At())
+		} else {
+			buf.WriteString("EVERY ")
+			buf.WriteString(after.Every())
+			if after.HasStarts() {
+				buf.WriteString(" STARTS ")
+				buf.WriteString(after.Starts())
+			}
+			if after.HasEnds() {
+				buf.WriteString(" ENDS ")
+				buf.WriteString(after.Ends())
+			}
+		}
+	}
+	if before.IsPreserve() != after.IsPreserve() {
+		if after.IsPreserve() {
+			buf.WriteString(" ON COMPLETION PRESERVE")
+		} else {
+			buf.WriteString(" ON COMPLETION NOT PRESERVE")
+		}
+	}
+	if eventStatus(before) != eventStatus(after) {
+		buf.WriteByte(' ')
+		buf.WriteString(eventStatus(after))
+	}
+	if before.Comment() != after.Comment() {
+		buf.WriteString(" COMMENT ")
+		buf.WriteString(util.SingleQuote(after.Comment()))
+	}
+	if before.Body() != after.Body() {
+		buf.WriteString(" DO ")
+		buf.WriteString(after.Body())
+	}
+	return buf.String()
+}
+
+// delimited returns the statement terminated by a semicolon. If the
+// statement itself contains semicolons, such as a trigger with a compound
+// statement as its body, the statement delimiter is changed around it
+func delimited(stmt string) []string {
+	if !strings.Contains(stmt, ";") {
+		return []string{stmt + ";"}
+	}
+	return []string{"DELIMITER ;;", stmt + ";;", "DELIMITER ;"}
+}
+
+// alterClauses returns the ALTER TABLE clauses that perform the change.
+// Each clause is meant to be executed as a separate statement
+func (r *renderer) alterClauses(change Change) ([]string, error) {
+	var buf bytes.Buffer
+	switch c := change.(type) {
+	case *TableOptionsModified:
+		for i, opt := range c.Options {
+			if i > 0 {
+				buf.WriteString(", ")
+			}
+			if err := format.SQL(&buf, opt, r.formatOptions...); err != nil {
+				return nil, err
+			}
+		}
+	case *ColumnDropped:
+		buf.WriteString("DROP COLUMN ")
+		buf.WriteString(util.Backquote(c.Column.Name()))
+	case *ColumnAdded:
+		buf.WriteString("ADD COLUMN ")
+		if err := format.SQL(&buf, c.Column, r.formatOptions...); err != nil {
+			return nil, err
+		}
+		if c.AfterColumn != "" {
+			buf.WriteString(" AFTER ")
+			buf.WriteString(util.Backquote(c.AfterColumn))
+		} else {
+			buf.WriteString(" FIRST")
+		}
+	case *ColumnModified:
+		// a column that is only renamed can be renamed without repeating
+		// its definition, if the server supports it
+		if r.version != nil && r.version.SupportsRenameColumn() && isColumnRenameOnly(c.Before, c.After) {
+			buf.WriteString("RENAME COLUMN ")
+			buf.WriteString(util.Backquote(c.Before.Name()))
+			buf.WriteString(" TO ")
+			buf.WriteString(util.Backquote(c.After.Name()))
+			break
+		}
+		buf.WriteString("CHANGE COLUMN ")
+		buf.WriteString(util.Backquote(c.Before.Name()))
+		buf.WriteByte(' ')
+		if err := format.SQL(&buf, c.After, r.formatOptions...); err != nil {
+			return nil, err
+		}
+	case *IndexDropped:
+		switch {
+		case c.Index.IsPrimaryKey():
+			buf.WriteString("DROP PRIMARY KEY")
+		case c.Index.IsForeignKey():
+			buf.WriteString("DROP FOREIGN KEY ")
+			if c.Index.HasSymbol() {
+				buf.WriteString(util.Backquote(c.Index.Symbol()))
+			} else {
+				buf.WriteString(util.Backquote(c.Index.Name()))
+			}
+		default:
+			buf.WriteString("DROP INDEX ")
+			if !c.Index.HasName() {
+				buf.WriteString(util.Backquote(c.Index.Symbol()))
+			} else {
+				buf.WriteString(util.Backquote(c.Index.Name()))
+			}
+		}
+	case *IndexAdded:
+		buf.WriteString("ADD ")
+		if err := format.SQL(&buf, c.Index, r.formatOptions...); err != nil {
+			return nil, err
+		}
+	case *IndexRenamed:
+		buf.WriteString("RENAME INDEX ")
+		buf.WriteString(util.Backquote(c.Before.Name()))
+		buf.WriteString(" TO ")
+		buf.WriteString(util.Backquote(c.After.Name()))
+	case *CheckConstraintDropped:
+		buf.WriteString("DROP CHECK ")
+		buf.WriteString(util.Backquote(c.CheckConstraint.Name()))
+	case *CheckConstraintAdded:
+		buf.WriteString("ADD ")
+		if err := format.SQL(&buf, c.CheckConstraint, r.formatOptions...); err != nil {
+			return nil, err
+		}
+	case *SystemVersioningModified:
+		if c.Enabled {
+			buf.WriteString("ADD SYSTEM VERSIONING")
+		} else {
+			buf.WriteString("DROP SYSTEM VERSIONING")
+		}
+	case *PartitioningModified:
+		return partitioningClauses(c.Before, c.After)
+	default:
+		return nil, errors.Errorf(`unknown change type %T`, change)
+	}
+	return []string{buf.String()}, nil
+}
+
+func partitioningClauses(from, to model.Partitioning) ([]string, error) {
+	switch {
+	case from == nil && to == nil:
+		return nil, nil
+	case to == nil:
+		return []string{"REMOVE PARTITIONING"}, nil
+	case from == nil || from.ID() != to.ID():
+		var buf bytes.Buffer
+		if err := format.SQL(&buf, to); err != nil {
+			return nil, err
+		}
+		return []string{buf.String()}, nil
+	}
+
+	switch to.Type() {
+	case model.PartitionTypeHash, model.PartitionTypeKey:
+		return hashPartitionClauses(from, to)
+	default:
+		return rangePartitionClauses(from, to)
+	}
+}
+
+// partitionCount returns the number of partitions of a HASH or KEY
+// partitioned table. MySQL creates a single partition when neither the
+// PARTITIONS clause nor explicit definitions are given
+func partitionCount(p model.Partitioning) (int, error) {
+	if l := len(p.Definitions()); l > 0 {
+		return l, nil
+	}
+	if !p.HasPartitionCount() {
+		return 1, nil
+	}
+	n, err := strconv.Atoi(p.PartitionCount())
+	if err != nil {
+		return 0, errors.Wrapf(err, `invalid partition count '%s'`, p.PartitionCount())
+	}
+	return n, nil
+}
+
+func hashPartitionClauses(from, to model.Partitioning) ([]string, error) {
+	fromCount, err := partitionCount(from)
+	if err != nil {
+		return nil, err
+	}
+	toCount, err := partitionCount(to)
+	if err != nil {
+		return nil, err
+	}
+
+	// partitions that are named can only be added or removed at the end.
+	// any other change to their definitions requires the table to be
+	// partitioned again
+	fromDefs, toDefs := definitionIDs(from), definitionIDs(to)
+	if len(fromDefs) > 0 || len(toDefs) > 0 {
+		switch {
+		case len(fromDefs) > 0 && len(toDefs) > len(fromDefs) && isPrefix(fromDefs, toDefs):
+			var added []model.PartitionDefinition
+			for def := range to.Definitions() {
+				if _, ok := from.LookupDefinition(def.Name()); !ok {
+					added = append(added, def)
+				}
+			}
+			s, err := partitionDefinitionList(added)
+			if err != nil {
+				return nil, err
+			}
+			return []string{"ADD PARTITION " + s}, nil
+		case len(toDefs) > 0 && len(toDefs) < len(fromDefs) && isPrefix(toDefs, fromDefs):
+			return []string{"COALESCE PARTITION " + strconv.Itoa(fromCount-toCount)}, nil
+		case isPrefix(fromDefs, toDefs) && len(fromDefs) == len(toDefs):
+			return nil, nil
+		}
+		var buf bytes.Buffer
+		if err := format.SQL(&buf, to); err != nil {
+			return nil, err
+		}
+		return []string{buf.String()}, nil
+	}
+
+	switch {
+	case toCount > fromCount:
+		return []string{"ADD PARTITION PARTITIONS " + strconv.Itoa(toCount-fromCount)}, nil
+	case toCount < fromCount:
+		return []string{"COALESCE PARTITION " + strconv.Itoa(fromCount-toCount)}, nil
+	}
+	return nil, nil
+}
+
+// definitionIDs returns the IDs of the partitions that are defined
+// explicitly, in order
+func definitionIDs(p model.Partitioning) []string {
+	var ids []string
+	for def := range p.Definitions() {
+		ids = append(ids, def.ID())
+	}
+	return ids
+}
+
+// isPrefix reports whether a is a prefix of b
+func isPrefix(a, b []string) bool {
+	if len(a) > len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+func partitionDefinitionList(defs []model.PartitionDefinition) (string, error) {
+	var buf bytes.Buffer
+	buf.WriteByte('(')
+	for i, def := range defs {
+		if i > 0 {
+			buf.WriteString(", ")
+		}
+		if err := format.SQL(&buf, def); err != nil {
+			return "", err
+		}
+	}
+	buf.WriteByte(')')
+	return buf.String(), nil
+}
+
+func rangePartitionClauses(from, to model.Partitioning) ([]string, error) {
+	var clauses []string
+
+	// the LIST partitions that are removed, but whose values are moved
+	// to other partitions, are reorganized along with those partitions
+	// so that their rows are kept. DROP PARTITION deletes the rows
+	var merged map[string]struct{}
+	if to.Type() == model.PartitionTypeList {
+		merged = mergedListPartitions(from, to)
+	}
+
+	var dropped, mergedNames []string
+	for def := range from.Definitions() {
+		if _, ok := merged[def.Name()]; ok {
+			mergedNames = append(mergedNames, util.Backquote(def.Name()))
+			continue
+		}
+		if _, ok := to.LookupDefinition(def.Name()); !ok {
+			dropped = append(dropped, util.Backquote(def.Name()))
+		}
+	}
+	if len(dropped) > 0 {
+		clauses = append(clauses, "DROP PARTITION "+strings.Join(dropped, ", "))
+	}
+	if len(merged) > 0 {
+		var mergedInto []model.PartitionDefinition
+		for def := range to.Definitions() {
+			if _, ok := merged[def.Name()]; ok {
+				mergedInto = append(mergedInto, def)
+			}
+		}
+		s, err := partitionDefinitionList(mergedInto)
+		if err != nil {
+			return nil, err
+		}
+		clauses = append(clauses, "REORGANIZE PARTITION "+strings.Join(mergedNames, ", ")+" INTO "+s)
+	}
+
+	// partitions that are new are either appended at the end, or in the
+	// case of RANGE partitioning, split out of the partition that follows
+	// them using REORGANIZE PARTITION.
+	//
+	// REORGANIZE PARTITION cannot change the range that is covered by the
+	// partitions, except at the end. when the upper bound of a RANGE
+	// partition changes, so does the lower bound of the partition that
+	// follows it, so they are reorganized together
+	isRange := to.Type() == model.PartitionTypeRange
+	var last [2]model.PartitionDefinition // the last partition whose bound changed
+	var names []string
+	var into, added []model.PartitionDefinition
+	reorganize := func() error {
+		s, err := partitionDefinitionList(into)
+		if err != nil {
+			return err
+		}
+		clauses = append(clauses, "REORGANIZE PARTITION "+strings.Join(names, ", ")+" INTO "+s)
+		names, into = nil, nil
+		return nil
+	}
+
+	for def := range to.Definitions() {
+		if _, ok := merged[def.Name()]; ok {
+			continue
+		}
+		before, ok := from.LookupDefinition(def.Name())
+		if !ok {
+			if isRange {
+				into = append(into, def)
+			} else {
+				added = append(added, def)
+			}
+			continue
+		}
+		if len(into) == 0 && before.ID() == def.ID() {
+			continue
+		}
+
+		names = append(names, util.Backquote(def.Name()))
+		into = append(into, def)
+		if isRange && before.Values() != def.Values() {
+			// the partition that follows is reorganized along with it
+			last = [2]model.PartitionDefinition{before, def}
+			continue
+		}
+		if err := reorganize(); err != nil {
+			return nil, err
+		}
+	}
+
+	switch {
+	case len(names) > 0:
+		// the last partition can only be extended, along with the
+		// partitions that are added after it. otherwise the table is
+		// partitioned again, which keeps the rows of the partitions
+		// that are removed, unlike dropping them
+		if !extendsRange(last[0].Values(), last[1].Values()) {
+			var buf bytes.Buffer
+			if err := format.SQL(&buf, to); err != nil {
+				return nil, err
+			}
+			return []string{buf.String()}, nil
+		}
+		if err := reorganize(); err != nil {
+			return nil, err
+		}
+	case len(into) > 0:
+		added = into
+	}
+
+	if len(added) > 0 {
+		s, err := partitionDefinitionList(added)
+		if err != nil {
+			return nil, err
+		}
+		clauses = append(clauses, "ADD PARTITION "+s)
+	}
+	return clauses, nil
+}
+
+// mergedListPartitions returns the names of the LIST partitions that are
+// removed, but have values that are listed by partitions of the new
+// partitioning, along with the names of those partitions
+func mergedListPartitions(from, to model.Partitioning) map[string]struct{} {
+	owners := make(map[string][]string) // value -> partitions in the new partitioning
+	for def := range to.Definitions() {
+		for _, v := range listValues(def.Values()) {
+			owners[v] = append(owners[v], def.Name())
+		}
+	}
+
+	merged := make(map[string]struct{})
+	for def := range from.Definitions() {
+		if _, ok := to.LookupDefinition(def.Name()); ok {
+			continue
+		}
+		for _, v := range listValues(def.Values()) {
+			for _, name := range owners[v] {
+				merged[def.Name()] = struct{}{}
+				merged[name] = struct{}{}
+			}
+		}
+	}
+	return merged
+}
+
+// listValues returns the values of a LIST partition, such as
+// `IN (1, 2)`, normalized so that they can be compared. Values that are
+// tuples, as in LIST COLUMNS partitioning, are kept together
+func listValues(values string) []string {
+	toks := util.Tokenize(values)
+	if len(toks) == 0 || !strings.EqualFold(toks[0].Text, "IN") {
+		return nil
+	}
+	toks = toks[1:]
+	if len(toks) < 2 || toks[0].Text != "(" || toks[len(toks)-1].Text != ")" {
+		return nil
+	}
+	toks = toks[1 : len(toks)-1]
+
+	var list []string
+	var words []string
+	var depth int
+	for _, tok := range toks {
+		switch tok.Text {
+		case "(":
+			depth++
+		case ")":
+			depth--
+		case ",":
+			if depth == 0 {
+				list = append(list, strings.Join(words, " "))
+				words = nil
+				continue
+			}
+		}
+		text := tok.Text
+		if tok.Ident {
+			text = strings.ToLower(text)
+		}
+		words = append(words, text)
+	}
+	if len(words) > 0 {
+		list = append(list, strings.Join(words, " "))
+	}
+	return list
+}
+
+// extendsRange reports whether the bound of a RANGE partition, such as
+// `LESS THAN (10)`, is raised to the other one. Only bounds that are
+// integers or MAXVALUE are compared
+func extendsRange(before, after string) bool {
+	bound := func(values string) (int64, bool, bool) {
+		s := strings.TrimSpace(values)
+		if len(s) < len("LESS THAN") || !strings.EqualFold(s[:len("LESS THAN")], "LESS THAN") {
+			return 0, false, false
+		}
+		s = strings.TrimSpace(s[len("LESS THAN"):])
+		s = strings.TrimSpace(strings.TrimSuffix(strings.TrimPrefix(s, "("), ")"))
+		if strings.EqualFold(s, "MAXVALUE") {
+			return 0, true, true
+		}
+		n, err := strconv.ParseInt(s, 10, 64)
+		return n, false, err == nil
+	}
+
+	b, bmax, ok := bound(before)
+	if !ok || bmax {
+		return false
+	}
+	a, amax, ok := bound(after)
+	if !ok {
+		return false
+	}
+	return amax || a > b
+}
@@ -0,0 +1,76 @@
+package diff
+
+import (
+	"github.com/schemalex/schemalex/model"
+)
+
+// reverse returns the hints that describe the same renames, in the
+// opposite direction
+func (h RenameHints) reverse() RenameHints {
+	var r RenameHints
+	if len(h.Tables) > 0 {
+		r.Tables = make(map[string]string, len(h.Tables))
+		for oldName, newName := range h.Tables {
+			r.Tables[newName] = oldName
+		}
+	}
+
+	renamedTable := func(name string) string {
+		if v, ok := h.Tables[name]; ok {
+			return v
+		}
+		return name
+	}
+
+	reverseQualified := func(hints map[string]string) map[string]string {
+		if len(hints) == 0 {
+			return nil
+		}
+		m := make(map[string]string, len(hints))
+		for table, names := range splitQualifiedHints(hints) {
+			for oldName, newName := range names {
+				m[renamedTable(table)+"."+newName] = oldName
+			}
+		}
+		return m
+	}
+
+	r.Columns = reverseQualified(h.Columns)
+	r.Indexes = reverseQualified(h.Indexes)
+	return r
+}
+
+// computeReverse computes the changes that migrate `to` back to `from`.
+// Changes that restore tables or columns that were dropped on the way
+// from `from` to `to` are annotated with warnings, as their data is gone
+func computeReverse(from, to model.Stmts, options ...Option) (*Changeset, error) {
+	reversed := make([]Option, 0, len(options))
+	for _, o := range options {
+		switch o.Name() {
+		case optkeyRenameHints:
+			reversed = append(reversed, WithRenameHints(o.Value().(RenameHints).reverse()))
+		default:
+			reversed = append(reversed, o)
+		}
+	}
+
+	changes, err := Compute(to, from, reversed...)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, change := range changes.Changes {
+		switch c := change.(type) {
+		case *TableAdded:
+			changes.warn(c, "table `%s` is restored, but the data that it contained has been lost", c.Table.Name())
+		case *ColumnAdded:
+			// generated columns are computed from other columns, so
+			// there is nothing to be lost
+			if c.Column.IsGenerated() {
+				continue
+			}
+			changes.warn(c, "column `%s`.`%s` is restored, but the data that it contained has been lost", c.Table, c.Column.Name())
+		}
+	}
+	return changes, nil
+}
@@ -0,0 +1,214 @@
+package diff
+
+import (
+	"strconv"
+
+	"github.com/schemalex/schemalex/model"
+)
+
+// Safety describes how dangerous a change is to the data that is
+// stored in the database
+type Safety int
+
+// List of possible Safety values. SafetySafe changes do not affect the
+// existing data. SafetyLossy changes may truncate or convert some of the
+// existing data, or remove a constraint that the data relies on.
+// SafetyDestructive changes remove data altogether.
+const (
+	SafetySafe Safety = iota
+	SafetyLossy
+	SafetyDestructive
+)
+
+func (s Safety) String() string {
+	switch s {
+	case SafetySafe:
+		return "safe"
+	case SafetyLossy:
+		return "lossy"
+	case SafetyDestructive:
+		return "destructive"
+	}
+	return "unknown"
+}
+
+// SafetyAction describes what to do with the changes that are not
+// allowed by a SafetyPolicy
+type SafetyAction int
+
+// List of possible SafetyAction values. SafetyActionFail makes the diff
+// fail with an error. SafetyActionComment writes the statements commented
+// out, so that they are not executed
+const (
+	SafetyActionFail SafetyAction = iota
+	SafetyActionComment
+)
+
+// SafetyPolicy specifies the most dangerous class of changes that is
+// allowed to be generated, and the action to take for the changes that
+// are more dangerous than that
+type SafetyPolicy struct {
+	Allow  Safety
+	Action SafetyAction
+}
+
+// Classify returns how dangerous the change is to the existing data
+func Classify(change Change) Safety {
+	switch c := change.(type) {
+	case *TableDropped, *DatabaseDropped:
+		return SafetyDestructive
+	case *ColumnDropped:
+		// generated columns can be computed from other columns
+		if c.Column.IsGenerated() {
+			return SafetySafe
+		}
+		return SafetyDestructive
+	case *ColumnModified:
+		return classifyColumnModification(c.Before, c.After)
+	case *IndexDropped:
+		// the data is intact, but it is no longer guaranteed to be unique
+		if c.Index.IsPrimaryKey() || c.Index.IsUnique() {
+			return SafetyLossy
+		}
+	case *SystemVersioningModified:
+		// the history of the rows is dropped along with the versioning
+		if !c.Enabled {
+			return SafetyDestructive
+		}
+	case *PartitioningModified:
+		// dropping a RANGE or LIST partition drops the rows in it
+		if c.Before == nil || c.After == nil || c.Before.ID() != c.After.ID() {
+			return SafetySafe
+		}
+		for def := range c.Before.Definitions() {
+			if _, ok := c.After.LookupDefinition(def.Name()); !ok && def.HasValues() {
+				return SafetyDestructive
+			}
+		}
+	}
+	return SafetySafe
+}
+
+// ranks of the types within a group of types that can be converted to
+// each other without loss, as long as the rank does not decrease
+var columnTypeRanks = map[model.ColumnType]struct {
+	group int
+	rank  int
+}{
+	model.ColumnTypeTinyInt:    {group: 1, rank: 1},
+	model.ColumnTypeSmallInt:   {group: 1, rank: 2},
+	model.ColumnTypeMediumInt:  {group: 1, rank: 3},
+	model.ColumnTypeInt:        {group: 1, rank: 4},
+	model.ColumnTypeBigInt:     {group: 1, rank: 5},
+	model.ColumnTypeFloat:      {group: 2, rank: 1},
+	model.ColumnTypeDouble:     {group: 2, rank: 2},
+	model.ColumnTypeChar:       {group: 3, rank: 0},
+	model.ColumnTypeVarChar:    {group: 3, rank: 0},
+	model.ColumnTypeTinyText:   {group: 3, rank: 1},
+	model.ColumnTypeText:       {group: 3, rank: 2},
+	model.ColumnTypeMediumText: {group: 3, rank: 3},
+	model.ColumnTypeLongText:   {group: 3, rank: 4},
+	model.ColumnTypeBinary:     {group: 4, rank: 0},
+	model.ColumnTypeVarBinary:  {group: 4, rank: 0},
+	model.ColumnTypeTinyBlob:   {group: 4, rank: 1},
+	model.ColumnTypeBlob:       {group: 4, rank: 2},
+	model.ColumnTypeMediumBlob: {group: 4, rank: 3},
+	model.ColumnTypeLongBlob:   {group: 4, rank: 4},
+}
+
+func lengthOf(col model.TableColumn) (int, bool) {
+	if !col.HasLength() {
+		return 0, false
+	}
+	n, err := strconv.Atoi(col.Length().Length())
+	if err != nil {
+		return 0, false
+	}
+	return n, true
+}
+
+func decimalOf(col model.TableColumn) int {
+	if !col.HasLength() || !col.Length().HasDecimal() {
+		return 0
+	}
+	n, _ := strconv.Atoi(col.Length().Decimal())
+	return n
+}
+
+func classifyColumnModification(before, after model.TableColumn) Safety {
+	// generated columns are computed from other columns
+	if before.IsGenerated() || after.IsGenerated() {
+		return SafetySafe
+	}
+
+	// existing NULL values can not be stored anymore
+	if before.NullState() != model.NullStateNotNull && after.NullState() == model.NullStateNotNull {
+		return SafetyLossy
+	}
+
+	if before.IsUnsigned() != after.IsUnsigned() {
+		return SafetyLossy
+	}
+
+	if before.HasCharacterSet() && after.HasCharacterSet() && before.CharacterSet() != after.CharacterSet() {
+		return SafetyLossy
+	}
+
+	beforeType, afterType := before.Type().SynonymType(), after.Type().SynonymType()
+	if beforeType != afterType {
+		b, bok := columnTypeRanks[beforeType]
+		a, aok := columnTypeRanks[afterType]
+		if !bok || !aok || b.group != a.group || a.rank < b.rank {
+			return SafetyLossy
+		}
+		// CHAR(n) and VARCHAR(n) can be converted to TEXT and back, as
+		// long as the length is big enough. we only know the length of
+		// the former, so converting the other way around is lossy
+		if a.rank == 0 && b.rank > 0 {
+			return SafetyLossy
+		}
+	}
+
+	switch afterType {
+	case model.ColumnTypeChar, model.ColumnTypeVarChar, model.ColumnTypeBinary, model.ColumnTypeVarBinary, model.ColumnTypeBit:
+		beforeLen, bok := lengthOf(before)
+		afterLen, aok := lengthOf(after)
+		if bok && aok && afterLen < beforeLen {
+			return SafetyLossy
+		}
+	case model.ColumnTypeDecimal:
+		// the number of digits before and after the decimal point must
+		// not decrease
+		beforeLen, bok := lengthOf(before)
+		afterLen, aok := lengthOf(after)
+		if bok && aok {
+			beforeDec, afterDec := decimalOf(before), decimalOf(after)
+			if afterDec < beforeDec || afterLen-afterDec < beforeLen-beforeDec {
+				return SafetyLossy
+			}
+		}
+	case model.ColumnTypeEnum:
+		if !containsAll(after.EnumValues(), before.EnumValues()) {
+			return SafetyLossy
+		}
+	case model.ColumnTypeSet:
+		if !containsAll(after.SetValues(), before.SetValues()) {
+			return SafetyLossy
+		}
+	}
+
+	return SafetySafe
+}
+
+func containsAll(values, required chan string) bool {
+	m := make(map[string]struct{})
+	for v := range values {
+		m[v] = struct{}{}
+	}
+	for v := range required {
+		if _, ok := m[v]; !ok {
+			return false
+		}
+	}
+	return true
+}
@@ -3,7 +3,9 @@ package format
 import (
 	"bytes"
 	"io"
+	"strings"
 
+	"github.com/schemalex/schemalex"
 	"github.com/schemalex/schemalex/internal/errors"
 	"github.com/schemalex/schemalex/internal/util"
 	"github.com/schemalex/schemalex/model"
@@ -13,6 +15,9 @@ type fmtCtx struct {
 	curIndent string
 	dst       io.Writer
 	indent    string
+
+	// the server version that the output is meant for, if specified
+	version *schemalex.ServerVersion
 }
 
 func newFmtCtx(dst io.Writer) *fmtCtx {
@@ -26,11 +31,15 @@ func (ctx *fmtCtx) clone() *fmtCtx {
 		curIndent: ctx.curIndent,
 		dst:       ctx.dst,
 		indent:    ctx.indent,
+		version:   ctx.version,
 	}
 }
 
 // SQL takes an arbitrary `model.*` object and formats it as SQL,
-// writing its result to `dst`
+// writing its result to `dst`.
+//
+// If schemalex.WithServerVersion is specified, an error is returned
+// for the features that are not supported by that version
 func SQL(dst io.Writer, v interface{}, options ...Option) error {
 	ctx := newFmtCtx(dst)
 	for _, o := range options {
@@ -40,6 +49,14 @@ func SQL(dst io.Writer, v interface{}, options ...Option) error {
 		}
 	}
 
+	version, ok, err := schemalex.ServerVersionFromOptions(options...)
+	if err != nil {
+		return err
+	}
+	if ok {
+		ctx.version = &version
+	}
+
 	return format(ctx, v)
 }
 
@@ -58,6 +75,20 @@ func format(ctx *fmtCtx, v interface{}) error {
 		return nil
 	case model.Table:
 		return formatTable(ctx, v.(model.Table))
+	case model.AlterTable:
+		return formatAlterTable(ctx, v.(model.AlterTable))
+	case model.DropTable:
+		return formatDropTable(ctx, v.(model.DropTable))
+	case model.DropObject:
+		return formatDropObject(ctx, v.(model.DropObject))
+	case model.View:
+		return formatView(ctx, v.(model.View))
+	case model.Trigger:
+		return formatTrigger(ctx, v.(model.Trigger))
+	case model.Routine:
+		return formatRoutine(ctx, v.(model.Routine))
+	case model.Event:
+		return formatEvent(ctx, v.(model.Event))
 	case model.TableColumn:
 		return formatTableColumn(ctx, v.(model.TableColumn))
 	case model.TableOption:
@@ -66,6 +97,12 @@ func format(ctx *fmtCtx, v interface{}) error {
 		return formatIndex(ctx, v.(model.Index))
 	case model.Reference:
 		return formatReference(ctx, v.(model.Reference))
+	case model.CheckConstraint:
+		return formatCheckConstraint(ctx, v.(model.CheckConstraint))
+	case model.Partitioning:
+		return formatPartitioning(ctx, v.(model.Partitioning))
+	case model.PartitionDefinition:
+		return formatPartitionDefinition(ctx, v.(model.PartitionDefinition))
 	default:
 		return errors.New("unsupported model type")
 	}
@@ -79,6 +116,19 @@ func formatDatabase(ctx *fmtCtx, d model.Database) error {
 	}
 	buf.WriteByte(' ')
 	buf.WriteString(util.Backquote(d.Name()))
+	if d.HasCharacterSet() {
+		buf.WriteString(" DEFAULT CHARACTER SET = ")
+		buf.WriteString(d.CharacterSet())
+	}
+	if d.HasCollation() {
+		buf.WriteString(" DEFAULT COLLATE = ")
+		buf.WriteString(d.Collation())
+	}
+	if d.HasEncryption() {
+		buf.WriteString(" ENCRYPTION = '")
+		buf.WriteString(d.Encryption())
+		buf.WriteByte('\'')
+	}
 	buf.WriteByte(';')
 
 	if _, err := buf.WriteTo(ctx.dst); err != nil {
@@ -87,7 +137,211 @@ func formatDatabase(ctx *fmtCtx, d model.Database) error {
 	return nil
 }
 
+func formatView(ctx *fmtCtx, view model.View) error {
+	var buf bytes.Buffer
+
+	buf.WriteString("CREATE")
+	if view.IsOrReplace() {
+		buf.WriteString(" OR REPLACE")
+	}
+	if view.HasAlgorithm() {
+		buf.WriteString(" ALGORITHM = ")
+		buf.WriteString(view.Algorithm())
+	}
+	if view.HasDefiner() {
+		buf.WriteString(" DEFINER = ")
+		buf.WriteString(view.Definer())
+	}
+	if view.HasSQLSecurity() {
+		buf.WriteString(" SQL SECURITY ")
+		buf.WriteString(view.SQLSecurity())
+	}
+
+	buf.WriteString(" VIEW ")
+	buf.WriteString(util.BackquoteQualified(view.Schema(), view.Name()))
+
+	var columns []string
+	for col := range view.Columns() {
+		columns = append(columns, util.Backquote(col))
+	}
+	if len(columns) > 0 {
+		buf.WriteString(" (")
+		buf.WriteString(strings.Join(columns, ", "))
+		buf.WriteByte(')')
+	}
+
+	buf.WriteString(" AS ")
+	buf.WriteString(view.Definition())
+
+	if view.HasCheckOption() {
+		buf.WriteString(" WITH ")
+		buf.WriteString(view.CheckOption())
+		buf.WriteString(" CHECK OPTION")
+	}
+
+	if _, err := buf.WriteTo(ctx.dst); err != nil {
+		return err
+	}
+	return nil
+}
+
+func formatTrigger(ctx *fmtCtx, trigger model.Trigger) error {
+	var buf bytes.Buffer
+
+	buf.WriteString("CREATE")
+	if trigger.HasDefiner() {
+		buf.WriteString(" DEFINER = ")
+		buf.WriteString(trigger.Definer())
+	}
+
+	buf.WriteString(" TRIGGER ")
+	buf.WriteString(util.BackquoteQualified(trigger.Schema(), trigger.Name()))
+	buf.WriteByte(' ')
+	buf.WriteString(trigger.Timing())
+	buf.WriteByte(' ')
+	buf.WriteString(trigger.Event())
+	buf.WriteString(" ON ")
+	buf.WriteString(util.BackquoteQualified(trigger.Schema(), trigger.TableName()))
+	buf.WriteString(" FOR EACH ROW")
+
+	if trigger.HasOrder() {
+		buf.WriteByte(' ')
+		buf.WriteString(trigger.Order())
+		buf.WriteByte(' ')
+		buf.WriteString(util.Backquote(trigger.OtherTrigger()))
+	}
+
+	buf.WriteByte(' ')
+	buf.WriteString(trigger.Body())
+
+	if _, err := buf.WriteTo(ctx.dst); err != nil {
+		return err
+	}
+	return nil
+}
+
+func formatRoutine(ctx *fmtCtx, routine model.Routine) error {
+	var buf bytes.Buffer
+
+	buf.WriteString("CREATE")
+	if routine.HasDefiner() {
+		buf.WriteString(" DEFINER = ")
+		buf.WriteString(routine.Definer())
+	}
+
+	if routine.IsFunction() {
+		buf.WriteString(" FUNCTION ")
+	} else {
+		buf.WriteString(" PROCEDURE ")
+	}
+	buf.WriteString(util.BackquoteQualified(routine.Schema(), routine.Name()))
+
+	buf.WriteString(" (")
+	var i int
+	for param := range routine.Parameters() {
+		if i > 0 {
+			buf.WriteString(", ")
+		}
+		i++
+		if param.HasDirection() {
+			buf.WriteString(param.Direction())
+			buf.WriteByte(' ')
+		}
+		buf.WriteString(util.Backquote(param.Name()))
+		buf.WriteByte(' ')
+		buf.WriteString(param.Type())
+	}
+	buf.WriteByte(')')
+
+	if routine.IsFunction() {
+		buf.WriteString(" RETURNS ")
+		buf.WriteString(routine.Returns())
+	}
+
+	if routine.HasComment() {
+		buf.WriteString(" COMMENT ")
+		buf.WriteString(util.SingleQuote(routine.Comment()))
+	}
+	if routine.IsDeterministic() {
+		buf.WriteString(" DETERMINISTIC")
+	}
+	if routine.HasDataAccess() {
+		buf.WriteByte(' ')
+		buf.WriteString(routine.DataAccess())
+	}
+	if routine.HasSQLSecurity() {
+		buf.WriteString(" SQL SECURITY ")
+		buf.WriteString(routine.SQLSecurity())
+	}
+
+	buf.WriteByte(' ')
+	buf.WriteString(routine.Body())
+
+	if _, err := buf.WriteTo(ctx.dst); err != nil {
+		return err
+	}
+	return nil
+}
+
+func formatEvent(ctx *fmtCtx, event model.Event) error {
+	var buf bytes.Buffer
+
+	buf.WriteString("CREATE")
+	if event.HasDefiner() {
+		buf.WriteString(" DEFINER = ")
+		buf.WriteString(event.Definer())
+	}
+
+	buf.WriteString(" EVENT ")
+	buf.WriteString(util.BackquoteQualified(event.Schema(), event.Name()))
+	buf.WriteString(" ON SCHEDULE ")
+	writeEventSchedule(&buf, event)
+
+	if event.IsPreserve() {
+		buf.WriteString(" ON COMPLETION PRESERVE")
+	}
+	if event.HasStatus() {
+		buf.WriteByte(' ')
+		buf.WriteString(event.Status())
+	}
+	if event.HasComment() {
+		buf.WriteString(" COMMENT ")
+		buf.WriteString(util.SingleQuote(event.Comment()))
+	}
+
+	buf.WriteString(" DO ")
+	buf.WriteString(event.Body())
+
+	if _, err := buf.WriteTo(ctx.dst); err != nil {
+		return err
+	}
+	return nil
+}
+
+func writeEventSchedule(buf *bytes.Buffer, event model.Event) {
+	if event.HasAt() {
+		buf.WriteString("AT ")
+		buf.WriteString(event.At())
+		return
+	}
+
+	buf.WriteString("EVERY ")
+	buf.WriteString(event.Every())
+	if event.HasStarts() {
+		buf.WriteString(" STARTS ")
+		buf.WriteString(event.Starts())
+	}
+	if event.HasEnds() {
+		buf.WriteString(" ENDS ")
+		buf.WriteString(event.Ends())
+	}
+}
+
 func formatTableOption(ctx *fmtCtx, option model.TableOption) error {
+	if ctx.version != nil && !ctx.version.SupportsTableOption(option.Key()) {
+		return errors.Errorf(`table option %s is not supported by %s`, option.Key(), ctx.version)
+	}
+
 	var buf bytes.Buffer
 	buf.WriteString(option.Key())
 	buf.WriteString(" = ")
@@ -119,7 +373,7 @@ func formatTable(ctx *fmtCtx, table model.Table) error {
 	}
 
 	buf.WriteByte(' ')
-	buf.WriteString(util.Backquote(table.Name()))
+	buf.WriteString(util.BackquoteQualified(table.Schema(), table.Name()))
 
 	if table.HasLikeTable() {
 		buf.WriteString(" LIKE ")
@@ -134,8 +388,10 @@ func formatTable(ctx *fmtCtx, table model.Table) error {
 
 		colch := table.Columns()
 		idxch := table.Indexes()
+		checkch := table.CheckConstraints()
 		colchmax := len(colch)
 		idxchmax := len(idxch)
+		checkchmax := len(checkch)
 
 		var i int
 		for col := range colch {
@@ -143,7 +399,7 @@ func formatTable(ctx *fmtCtx, table model.Table) error {
 			if err := formatTableColumn(newctx, col); err != nil {
 				return err
 			}
-			if i < colchmax-1 || idxchmax > 0 {
+			if i < colchmax-1 || idxchmax > 0 || checkchmax > 0 {
 				buf.WriteByte(',')
 			}
 			i++
@@ -155,7 +411,19 @@ func formatTable(ctx *fmtCtx, table model.Table) error {
 			if err := formatIndex(newctx, idx); err != nil {
 				return err
 			}
-			if i < idxchmax-1 {
+			if i < idxchmax-1 || checkchmax > 0 {
+				buf.WriteByte(',')
+			}
+			i++
+		}
+
+		i = 0
+		for check := range checkch {
+			buf.WriteByte('\n')
+			if err := formatCheckConstraint(newctx, check); err != nil {
+				return err
+			}
+			if i < checkchmax-1 {
 				buf.WriteByte(',')
 			}
 			i++
@@ -178,6 +446,48 @@ func formatTable(ctx *fmtCtx, table model.Table) error {
 				i++
 			}
 		}
+
+		if table.IsSystemVersioned() {
+			if ctx.version != nil && !ctx.version.SupportsSystemVersioning() {
+				return errors.Errorf(`system-versioned tables are not supported by %s`, ctx.version)
+			}
+			buf.WriteString(" WITH SYSTEM VERSIONING")
+		}
+
+		if table.HasPartitioning() {
+			buf.WriteByte('\n')
+			if err := formatPartitioning(newctx, table.Partitioning()); err != nil {
+				return err
+			}
+		}
+	}
+
+	if _, err := buf.WriteTo(ctx.dst); err != nil {
+		return err
+	}
+	return nil
+}
+
+func formatAlterTable(ctx *fmtCtx, alter model.AlterTable) error {
+	var buf bytes.Buffer
+
+	buf.WriteString("ALTER TABLE ")
+	buf.WriteString(util.BackquoteQualified(alter.Schema(), alter.Name()))
+
+	newctx := ctx.clone()
+	newctx.curIndent = ""
+	newctx.dst = &buf
+
+	var i int
+	for spec := range alter.Specs() {
+		if i > 0 {
+			buf.WriteByte(',')
+		}
+		buf.WriteByte(' ')
+		if err := writeAlterTableSpec(newctx, &buf, spec); err != nil {
+			return err
+		}
+		i++
 	}
 
 	if _, err := buf.WriteTo(ctx.dst); err != nil {
@@ -186,6 +496,130 @@ func formatTable(ctx *fmtCtx, table model.Table) error {
 	return nil
 }
 
+func formatDropTable(ctx *fmtCtx, drop model.DropTable) error {
+	var buf bytes.Buffer
+
+	buf.WriteString("DROP")
+	if drop.IsTemporary() {
+		buf.WriteString(" TEMPORARY")
+	}
+	buf.WriteString(" TABLE")
+	if drop.IsIfExists() {
+		buf.WriteString(" IF EXISTS")
+	}
+	buf.WriteByte(' ')
+	buf.WriteString(util.BackquoteQualified(drop.Schema(), drop.Name()))
+
+	if _, err := buf.WriteTo(ctx.dst); err != nil {
+		return err
+	}
+	return nil
+}
+
+func formatDropObject(ctx *fmtCtx, drop model.DropObject) error {
+	var buf bytes.Buffer
+
+	buf.WriteString("DROP ")
+	buf.WriteString(drop.Kind())
+	if drop.IsIfExists() {
+		buf.WriteString(" IF EXISTS")
+	}
+	buf.WriteByte(' ')
+	buf.WriteString(util.BackquoteQualified(drop.Schema(), drop.Name()))
+
+	if _, err := buf.WriteTo(ctx.dst); err != nil {
+		return err
+	}
+	return nil
+}
+
+func writeAlterTableSpec(ctx *fmtCtx, buf *bytes.Buffer, spec model.AlterTableSpec) error {
+	switch spec.Kind() {
+	case model.AlterTableSpecAddColumn:
+		buf.WriteString("ADD COLUMN ")
+		if err := formatTableColumn(ctx, spec.Column()); err != nil {
+			return err
+		}
+		writeColumnPosition(buf, spec)
+	case model.AlterTableSpecDropColumn:
+		buf.WriteString("DROP COLUMN ")
+		buf.WriteString(util.Backquote(spec.Name()))
+	case model.AlterTableSpecModifyColumn:
+		if spec.Name() == spec.Column().Name() {
+			buf.WriteString("MODIFY COLUMN ")
+		} else {
+			buf.WriteString("CHANGE COLUMN ")
+			buf.WriteString(util.Backquote(spec.Name()))
+			buf.WriteByte(' ')
+		}
+		if err := formatTableColumn(ctx, spec.Column()); err != nil {
+			return err
+		}
+		writeColumnPosition(buf, spec)
+	case model.AlterTableSpecRenameColumn:
+		buf.WriteString("RENAME COLUMN ")
+		buf.WriteString(util.Backquote(spec.Name()))
+		buf.WriteString(" TO ")
+		buf.WriteString(util.Backquote(spec.NewName()))
+	case model.AlterTableSpecAddIndex:
+		buf.WriteString("ADD ")
+		if err := formatIndex(ctx, spec.Index()); err != nil {
+			return err
+		}
+	case model.AlterTableSpecDropIndex:
+		buf.WriteString("DROP INDEX ")
+		buf.WriteString(util.Backquote(spec.Name()))
+	case model.AlterTableSpecDropPrimaryKey:
+		buf.WriteString("DROP PRIMARY KEY")
+	case model.AlterTableSpecDropForeignKey:
+		buf.WriteString("DROP FOREIGN KEY ")
+		buf.WriteString(util.Backquote(spec.Name()))
+	case model.AlterTableSpecRenameIndex:
+		buf.WriteString("RENAME INDEX ")
+		buf.WriteString(util.Backquote(spec.Name()))
+		buf.WriteString(" TO ")
+		buf.WriteString(util.Backquote(spec.NewName()))
+	case model.AlterTableSpecAddCheckConstraint:
+		buf.WriteString("ADD ")
+		if err := formatCheckConstraint(ctx, spec.CheckConstraint()); err != nil {
+			return err
+		}
+	case model.AlterTableSpecDropCheckConstraint:
+		buf.WriteString("DROP CHECK ")
+		buf.WriteString(util.Backquote(spec.Name()))
+	case model.AlterTableSpecDropConstraint:
+		buf.WriteString("DROP CONSTRAINT ")
+		buf.WriteString(util.Backquote(spec.Name()))
+	case model.AlterTableSpecTableOptions:
+		var i int
+		for opt := range spec.Options() {
+			if i > 0 {
+				buf.WriteByte(' ')
+			}
+			if err := formatTableOption(ctx, opt); err != nil {
+				return err
+			}
+			i++
+		}
+	case model.AlterTableSpecRename:
+		buf.WriteString("RENAME TO ")
+		buf.WriteString(util.BackquoteQualified(spec.NewSchema(), spec.NewName()))
+	default:
+		return errors.New(`invalid alter specification`)
+	}
+	return nil
+}
+
+func writeColumnPosition(buf *bytes.Buffer, spec model.AlterTableSpec) {
+	switch {
+	case spec.IsFirst():
+		buf.WriteString(" FIRST")
+	case spec.HasAfter():
+		buf.WriteString(" AFTER ")
+		buf.WriteString(util.Backquote(spec.After()))
+	}
+}
+
 func formatColumnType(ctx *fmtCtx, col model.ColumnType) error {
 	if col <= model.ColumnTypeInvalid || col >= model.ColumnTypeMax {
 		return errors.New(`invalid column type`)
@@ -211,6 +645,9 @@ func formatTableColumn(ctx *fmtCtx, col model.TableColumn) error {
 	if err := formatColumnType(newctx, col.Type()); err != nil {
 		return err
 	}
+	if ctx.version != nil && !ctx.version.SupportsColumnType(col.Type()) {
+		return errors.Errorf(`%s columns are not supported by %s`, col.Type(), ctx.version)
+	}
 
 	switch col.Type() {
 	case model.ColumnTypeEnum:
@@ -268,6 +705,18 @@ func formatTableColumn(ctx *fmtCtx, col model.TableColumn) error {
 		buf.WriteString(util.Backquote(col.Collation()))
 	}
 
+	if col.IsGenerated() {
+		buf.WriteString(" GENERATED ALWAYS AS (")
+		buf.WriteString(col.GenerationExpression())
+		buf.WriteByte(')')
+		switch col.GenerationStorage() {
+		case model.GenerationStorageVirtual:
+			buf.WriteString(" VIRTUAL")
+		case model.GenerationStorageStored:
+			buf.WriteString(" STORED")
+		}
+	}
+
 	if col.HasAutoUpdate() {
 		buf.WriteString(" ON UPDATE ")
 		buf.WriteString(col.AutoUpdate())
@@ -290,10 +739,20 @@ func formatTableColumn(ctx *fmtCtx, col model.TableColumn) error {
 			buf.WriteString(col.Default())
 			buf.WriteByte('\'')
 		} else {
+			if ctx.version != nil && strings.HasPrefix(col.Default(), "(") && !ctx.version.SupportsExpressionDefaults() {
+				return errors.Errorf(`expressions as default values are not supported by %s`, ctx.version)
+			}
 			buf.WriteString(col.Default())
 		}
 	}
 
+	if col.IsInvisible() {
+		if ctx.version != nil && !ctx.version.SupportsInvisibleColumns() {
+			return errors.Errorf(`invisible columns are not supported by %s`, ctx.version)
+		}
+		buf.WriteString(" INVISIBLE")
+	}
+
 	if col.IsAutoIncrement() {
 		buf.WriteString(" AUTO_INCREMENT")
 	}
@@ -314,6 +773,15 @@ func formatTableColumn(ctx *fmtCtx, col model.TableColumn) error {
 		buf.WriteByte('\'')
 	}
 
+	if col.HasCheckExpression() {
+		if ctx.version != nil && !ctx.version.SupportsCheckConstraints() {
+			return errors.Errorf(`CHECK constraints are not supported by %s`, ctx.version)
+		}
+		buf.WriteString(" CHECK (")
+		buf.WriteString(col.CheckExpression())
+		buf.WriteByte(')')
+	}
+
 	if _, err := buf.WriteTo(ctx.dst); err != nil {
 		return err
 	}
@@ -366,7 +834,16 @@ func formatIndex(ctx *fmtCtx, index model.Index) error {
 
 	var i int
 	for col := range ch {
-		buf.WriteString(util.Backquote(col.Name()))
+		if col.IsExpression() {
+			if ctx.version != nil && !ctx.version.SupportsFunctionalIndexes() {
+				return errors.Errorf(`functional key parts are not supported by %s`, ctx.version)
+			}
+			buf.WriteByte('(')
+			buf.WriteString(col.Expression())
+			buf.WriteByte(')')
+		} else {
+			buf.WriteString(util.Backquote(col.Name()))
+		}
 		if col.HasLength() {
 			buf.WriteByte('(')
 			buf.WriteString(col.Length())
@@ -404,6 +881,13 @@ func formatIndex(ctx *fmtCtx, index model.Index) error {
 		}
 	}
 
+	if index.IsInvisible() {
+		if ctx.version != nil && !ctx.version.SupportsInvisibleIndexes() {
+			return errors.Errorf(`invisible indexes are not supported by %s`, ctx.version)
+		}
+		buf.WriteString(" INVISIBLE")
+	}
+
 	if ref := index.Reference(); ref != nil {
 		newctx := ctx.clone()
 		newctx.dst = &buf
@@ -420,12 +904,170 @@ func formatIndex(ctx *fmtCtx, index model.Index) error {
 	return nil
 }
 
+func formatCheckConstraint(ctx *fmtCtx, check model.CheckConstraint) error {
+	if ctx.version != nil && !ctx.version.SupportsCheckConstraints() {
+		return errors.Errorf(`CHECK constraints are not supported by %s`, ctx.version)
+	}
+
+	var buf bytes.Buffer
+
+	buf.WriteString(ctx.curIndent)
+	if check.HasName() {
+		buf.WriteString("CONSTRAINT ")
+		buf.WriteString(util.Backquote(check.Name()))
+		buf.WriteByte(' ')
+	}
+	buf.WriteString("CHECK (")
+	buf.WriteString(check.Expression())
+	buf.WriteByte(')')
+
+	if !check.IsEnforced() {
+		buf.WriteString(" NOT ENFORCED")
+	}
+
+	if _, err := buf.WriteTo(ctx.dst); err != nil {
+		return err
+	}
+	return nil
+}
+
+func formatPartitioning(ctx *fmtCtx, partitioning model.Partitioning) error {
+	var buf bytes.Buffer
+
+	buf.WriteString("PARTITION BY ")
+	if err := writePartitionMethod(&buf, partitioning); err != nil {
+		return err
+	}
+	if partitioning.HasPartitionCount() {
+		buf.WriteString(" PARTITIONS ")
+		buf.WriteString(partitioning.PartitionCount())
+	}
+
+	if partitioning.HasSubPartitioning() {
+		sub := partitioning.SubPartitioning()
+		buf.WriteString("\nSUBPARTITION BY ")
+		if err := writePartitionMethod(&buf, sub); err != nil {
+			return err
+		}
+		if sub.HasPartitionCount() {
+			buf.WriteString(" SUBPARTITIONS ")
+			buf.WriteString(sub.PartitionCount())
+		}
+	}
+
+	defch := partitioning.Definitions()
+	if l := len(defch); l > 0 {
+		newctx := ctx.clone()
+		newctx.curIndent = newctx.indent + newctx.curIndent
+		newctx.dst = &buf
+
+		buf.WriteString("\n(")
+		var i int
+		for def := range defch {
+			if i > 0 {
+				buf.WriteString(",\n")
+			}
+			if err := formatPartitionDefinition(newctx, def); err != nil {
+				return err
+			}
+			i++
+		}
+		buf.WriteByte(')')
+	}
+
+	if _, err := buf.WriteTo(ctx.dst); err != nil {
+		return err
+	}
+	return nil
+}
+
+func writePartitionMethod(buf *bytes.Buffer, partitioning model.Partitioning) error {
+	if partitioning.IsLinear() {
+		buf.WriteString("LINEAR ")
+	}
+
+	switch partitioning.Type() {
+	case model.PartitionTypeRange:
+		buf.WriteString("RANGE")
+	case model.PartitionTypeList:
+		buf.WriteString("LIST")
+	case model.PartitionTypeHash:
+		buf.WriteString("HASH")
+	case model.PartitionTypeKey:
+		buf.WriteString("KEY")
+		if partitioning.HasAlgorithm() {
+			buf.WriteString(" ALGORITHM = ")
+			buf.WriteString(partitioning.Algorithm())
+		}
+	default:
+		return errors.New("unknown partition type")
+	}
+
+	if partitioning.IsColumns() {
+		buf.WriteString(" COLUMNS")
+	}
+
+	buf.WriteString(" (")
+	buf.WriteString(partitioning.Expression())
+	buf.WriteByte(')')
+	return nil
+}
+
+func formatPartitionDefinition(ctx *fmtCtx, def model.PartitionDefinition) error {
+	return writePartitionDefinition(ctx, def, "PARTITION")
+}
+
+func writePartitionDefinition(ctx *fmtCtx, def model.PartitionDefinition, keyword string) error {
+	var buf bytes.Buffer
+
+	buf.WriteString(ctx.curIndent)
+	buf.WriteString(keyword)
+	buf.WriteByte(' ')
+	buf.WriteString(util.Backquote(def.Name()))
+
+	if def.HasValues() {
+		buf.WriteString(" VALUES ")
+		buf.WriteString(def.Values())
+	}
+
+	newctx := ctx.clone()
+	newctx.curIndent = ""
+	newctx.dst = &buf
+	for opt := range def.Options() {
+		buf.WriteByte(' ')
+		if err := formatTableOption(newctx, opt); err != nil {
+			return err
+		}
+	}
+
+	subch := def.SubPartitions()
+	if l := len(subch); l > 0 {
+		buf.WriteString(" (")
+		var i int
+		for sub := range subch {
+			if err := writePartitionDefinition(newctx, sub, "SUBPARTITION"); err != nil {
+				return err
+			}
+			if i < l-1 {
+				buf.WriteString(", ")
+			}
+			i++
+		}
+		buf.WriteByte(')')
+	}
+
+	if _, err := buf.WriteTo(ctx.dst); err != nil {
+		return err
+	}
+	return nil
+}
+
 func formatReference(ctx *fmtCtx, r model.Reference) error {
 	var buf bytes.Buffer
 
 	buf.WriteString(ctx.curIndent)
 	buf.WriteString("REFERENCES ")
-	buf.WriteString(util.Backquote(r.TableName()))
+	buf.WriteString(util.BackquoteQualified(r.Schema(), r.TableName()))
 	buf.WriteString(" (")
 
 	ch := r.Columns()
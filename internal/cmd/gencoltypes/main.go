@@ -73,6 +73,9 @@ func _main() error {
 		"Bool",
 		"JSON",
 		"GEOMETRY",
+		"UUID",
+		"INET6",
+		"INET4",
 	}
 
 	buf.WriteString(`// Code generated by internal/cmd/gencoltypes/main.go; DO NOT EDIT.`)
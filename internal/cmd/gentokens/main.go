@@ -64,6 +64,7 @@ func _main() error {
 		{Ident: "RPAREN", Comment: ")"},
 		{Ident: "COMMA", Comment: ","},
 		{Ident: "SEMICOLON", Comment: ";"},
+		{Ident: "INNER_SEMICOLON", Comment: "; while DELIMITER is changed"},
 		{Ident: "DOT", Comment: "."},
 		{Ident: "SLASH", Comment: "/"},
 		{Ident: "ASTERISK", Comment: "*"},
@@ -188,6 +189,34 @@ func _main() error {
 		{Ident: "ASC"},
 		{Ident: "DESC"},
 		{Ident: "NOW"},
+		{Ident: "ENFORCED"},
+		{Ident: "GENERATED"},
+		{Ident: "ALWAYS"},
+		{Ident: "AS"},
+		{Ident: "VIRTUAL"},
+		{Ident: "STORED"},
+		{Ident: "PARTITION"},
+		{Ident: "PARTITIONS"},
+		{Ident: "SUBPARTITION"},
+		{Ident: "SUBPARTITIONS"},
+		{Ident: "BY"},
+		{Ident: "RANGE"},
+		{Ident: "LIST"},
+		{Ident: "LINEAR"},
+		{Ident: "COLUMNS"},
+		{Ident: "ALGORITHM"},
+		{Ident: "VALUES"},
+		{Ident: "LESS"},
+		{Ident: "THAN"},
+		{Ident: "MAXVALUE"},
+		{Ident: "IN"},
+		{Ident: "NODEGROUP"},
+		{Ident: "ALTER"},
+		{Ident: "ADD"},
+		{Ident: "CHANGE"},
+		{Ident: "COLUMN"},
+		{Ident: "RENAME"},
+		{Ident: "TO"},
 	}
 
 	for _, tok := range tokens {
@@ -199,7 +228,7 @@ func _main() error {
 	buf.WriteString("\n)") // end const (
 
 	buf.WriteString("\n\nvar keywordIdentMap = map[string]TokenType{")
-	for _, tok := range tokens[20:] {
+	for _, tok := range tokens[21:] {
 		buf.WriteString("\n" + strconv.Quote(tok.Ident) + ": " + tok.Ident + ",")
 	}
 	buf.WriteString("\n}")
@@ -0,0 +1,115 @@
+package util
+
+import "strings"
+
+// Token is a token of an SQL expression. Ident is true for identifiers,
+// whether quoted or not, and for keywords, which are not told apart from
+// identifiers. Quoted identifiers are unquoted
+type Token struct {
+	Text   string
+	Ident  bool
+	Quoted bool
+}
+
+// Tokenize splits the SQL expression into tokens, skipping whitespace.
+// String literals are kept as they are, and the other characters, such
+// as operators and parentheses, are single tokens
+func Tokenize(expr string) []Token {
+	var toks []Token
+	for i := 0; i < len(expr); {
+		switch c := expr[i]; {
+		case c == '`':
+			j := i + 1
+			var name strings.Builder
+			for ; j < len(expr); j++ {
+				if expr[j] == '`' {
+					if j+1 < len(expr) && expr[j+1] == '`' {
+						name.WriteByte('`')
+						j++
+						continue
+					}
+					break
+				}
+				name.WriteByte(expr[j])
+			}
+			toks = append(toks, Token{Text: name.String(), Ident: true, Quoted: true})
+			i = j + 1
+		case c == '\'' || c == '"':
+			j := i + 1
+			for ; j < len(expr) && expr[j] != c; j++ {
+				if expr[j] == '\\' {
+					j++
+				}
+			}
+			if j >= len(expr) {
+				j = len(expr) - 1
+			}
+			toks = append(toks, Token{Text: expr[i : j+1]})
+			i = j + 1
+		case isIdentByte(c):
+			j := i
+			for ; j < len(expr) && isIdentByte(expr[j]); j++ {
+			}
+			toks = append(toks, Token{Text: expr[i:j], Ident: true})
+			i = j
+		case c == ' ' || c == '\t' || c == '\n' || c == '\r':
+			i++
+		default:
+			toks = append(toks, Token{Text: expr[i : i+1]})
+			i++
+		}
+	}
+	return toks
+}
+
+func isIdentByte(c byte) bool {
+	return c == '_' || c == '$' || c >= '0' && c <= '9' || c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= 0x80
+}
+
+// NormalizeExpression returns the expression in a form that can be
+// compared with the one that the server shows for it, such as the
+// expression of a check constraint or a generated column in the output
+// of SHOW CREATE TABLE. The server quotes the identifiers, writes the
+// keywords and the functions in lower case, and surrounds the expression
+// in parentheses. So the identifiers and the keywords are compared in
+// lower case and without quotes, and the parentheses that surround the
+// whole expression are removed.
+//
+// Other changes made by the server, such as adding parentheses around
+// the operands, are not undone
+func NormalizeExpression(expr string) string {
+	toks := Tokenize(expr)
+	for len(toks) >= 2 && enclosed(toks) {
+		toks = toks[1 : len(toks)-1]
+	}
+
+	words := make([]string, len(toks))
+	for i, tok := range toks {
+		words[i] = tok.Text
+		if tok.Ident {
+			words[i] = strings.ToLower(tok.Text)
+		}
+	}
+	return strings.Join(words, " ")
+}
+
+// enclosed returns true if the first token is a parenthesis that is
+// closed by the last token
+func enclosed(toks []Token) bool {
+	if toks[0].Text != "(" || toks[len(toks)-1].Text != ")" {
+		return false
+	}
+	var depth int
+	for i, tok := range toks {
+		switch tok.Text {
+		case "(":
+			depth++
+		case ")":
+			depth--
+			if depth == 0 && i < len(toks)-1 {
+				return false
+			}
+		}
+	}
+	return depth == 0
+}
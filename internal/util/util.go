@@ -1,7 +1,24 @@
 package util
 
+import "strings"
+
 // Backquote surrounds the given string in backquotes
 func Backquote(s string) string {
 	// XXX Does this require escaping
 	return "`" + s + "`"
 }
+
+// SingleQuote surrounds the given string in single quotes as a string
+// literal, doubling the single quotes it contains
+func SingleQuote(s string) string {
+	return "'" + strings.Replace(s, "'", "''", -1) + "'"
+}
+
+// BackquoteQualified surrounds the name, and the name of the database
+// that qualifies it if it is not empty, in backquotes
+func BackquoteQualified(schema, name string) string {
+	if schema == "" {
+		return Backquote(name)
+	}
+	return Backquote(schema) + "." + Backquote(name)
+}
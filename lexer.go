@@ -31,6 +31,14 @@ type lexer struct {
 	start position // position where we last emitted
 	cur   position // current position including read-ahead
 	width int
+
+	// delimiter is the statement delimiter set by the DELIMITER
+	// directive of the mysql client, if it is not ";". stmtStart is
+	// true if no tokens other than whitespace and comments have been
+	// emitted since the last statement delimiter, which is the only
+	// place that the directive is recognized
+	delimiter string
+	stmtStart bool
 }
 
 func lex(ctx context.Context, input []byte) chan *Token {
@@ -49,6 +57,7 @@ func newLexer(out chan *Token, input []byte) *lexer {
 	l.cur.line = 1
 	l.cur.col = 1
 	l.peekCount = -1
+	l.stmtStart = true
 	return &l
 }
 
@@ -74,6 +83,14 @@ func (l *lexer) emit(ctx context.Context, typ TokenType) {
 		}
 	}
 
+	switch typ {
+	case SPACE, COMMENT_IDENT:
+	case SEMICOLON:
+		l.stmtStart = true
+	default:
+		l.stmtStart = false
+	}
+
 	select {
 	case <-ctx.Done():
 	case l.out <- &t:
@@ -85,8 +102,17 @@ func (l *lexer) emit(ctx context.Context, typ TokenType) {
 	l.start.pos = l.start.pos - (l.peekCount + 1)
 }
 
+// pos returns the current position, not including read-ahead. Advancing
+// past the end of the input does not move the position beyond it
+func (l *lexer) pos() int {
+	if pos := l.cur.pos - (l.peekCount + 1); pos < len(l.input) {
+		return pos
+	}
+	return len(l.input)
+}
+
 func (l *lexer) str() string {
-	endpos := l.cur.pos - (l.peekCount + 1)
+	endpos := l.pos()
 	w := len(l.input[l.start.pos:])
 	if endpos-l.start.pos > w {
 		endpos = l.start.pos + w
@@ -105,6 +131,14 @@ OUTER:
 		default:
 		}
 
+		if l.delimiter != "" && bytes.HasPrefix(l.input[l.pos():], []byte(l.delimiter)) {
+			for range l.delimiter {
+				l.advance()
+			}
+			l.emit(ctx, SEMICOLON)
+			continue OUTER
+		}
+
 		r := l.peek()
 
 		// These require peek, and then consume
@@ -117,6 +151,10 @@ OUTER:
 		case isLetter(r):
 			t := l.runIdent()
 			s := l.str()
+			if l.stmtStart && strings.EqualFold(s, "DELIMITER") && l.runDelimiter() {
+				l.emit(ctx, COMMENT_IDENT)
+				continue OUTER
+			}
 			if typ, ok := keywordIdentMap[strings.ToUpper(s)]; ok {
 				t = typ
 			}
@@ -190,6 +228,10 @@ OUTER:
 		case ')':
 			l.emit(ctx, RPAREN)
 		case ';':
+			if l.delimiter != "" {
+				l.emit(ctx, INNER_SEMICOLON)
+				continue OUTER
+			}
 			l.emit(ctx, SEMICOLON)
 		case ',':
 			l.emit(ctx, COMMA)
@@ -340,6 +382,26 @@ func (l *lexer) runCComment() {
 	}
 }
 
+// runDelimiter reads the rest of the DELIMITER directive, which extends
+// to the end of the line, and changes the statement delimiter. It returns
+// false without reading anything if the DELIMITER is not followed by a
+// space, in which case it is just an identifier
+func (l *lexer) runDelimiter() bool {
+	if r := l.peek(); r != ' ' && r != '\t' {
+		return false
+	}
+
+	start := l.pos()
+	l.runToEOL()
+	if fields := strings.Fields(string(l.input[start:l.pos()])); len(fields) > 0 {
+		l.delimiter = fields[0]
+		if l.delimiter == ";" {
+			l.delimiter = ""
+		}
+	}
+	return true
+}
+
 func (l *lexer) runToEOL() TokenType {
 	for {
 		r := l.next()
@@ -110,3 +110,31 @@ func TestLexToken(t *testing.T) {
 		}
 	}
 }
+
+func TestLexDelimiter(t *testing.T) {
+	input := "DELIMITER //\nBEGIN x; END//\ndelimiter ;\nSELECT delimiter;"
+	expect := []TokenType{
+		COMMENT_IDENT, IDENT, SPACE, IDENT, INNER_SEMICOLON, SPACE, IDENT, SEMICOLON, SPACE,
+		COMMENT_IDENT, IDENT, SPACE, IDENT, SEMICOLON, EOF,
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	var types []TokenType
+	for tok := range lex(ctx, []byte(input)) {
+		types = append(types, tok.Type)
+	}
+	assert.Equal(t, expect, types, "token types match")
+
+	// the directive may end the input without a new line
+	input = "CREATE TABLE t (id INT);\nDELIMITER //"
+	types = nil
+	for tok := range lex(ctx, []byte(input)) {
+		types = append(types, tok.Type)
+	}
+	if assert.NotEmpty(t, types, "lexing should produce tokens") {
+		assert.Equal(t, COMMENT_IDENT, types[len(types)-2], "DELIMITER is a directive")
+		assert.Equal(t, EOF, types[len(types)-1], "input ends with EOF")
+	}
+}
@@ -0,0 +1,292 @@
+package migrations
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/schemalex/schemalex"
+	"github.com/schemalex/schemalex/internal/errors"
+	"github.com/schemalex/schemalex/internal/option"
+)
+
+// Option is an option for WriteFiles
+type Option = schemalex.Option
+
+const (
+	optkeyTime        = "time"
+	optkeyTransaction = "transaction"
+)
+
+// WithTime specifies the time used as the version of the migration, if
+// the migrations are versioned by timestamps. If unspecified, the
+// current time is used
+func WithTime(t time.Time) Option {
+	return option.New(optkeyTime, t)
+}
+
+// WithTransaction specifies if the migration should be run in a
+// transaction by the tools that do so on their own (see
+// Tool.Transactional). If unspecified, it is run in a transaction
+func WithTransaction(b bool) Option {
+	return option.New(optkeyTransaction, b)
+}
+
+// Tool is a migration tool, whose file layout and annotations are used
+// by WriteFiles
+type Tool string
+
+// List of possible Tool values
+const (
+	ToolGolangMigrate Tool = "golang-migrate"
+	ToolGoose         Tool = "goose"
+	ToolSQLMigrate    Tool = "sql-migrate"
+	ToolFlyway        Tool = "flyway"
+)
+
+// timestampFormat is the format of the versions that are timestamps,
+// which is the default of the tools that create new migration files
+const timestampFormat = "20060102150405"
+
+// versionPatterns match the names of the existing migration files of each
+// tool, capturing the version
+var versionPatterns = map[Tool]*regexp.Regexp{
+	ToolGolangMigrate: regexp.MustCompile(`^(\d+)_.*\.(?:up|down)\.sql$`),
+	ToolGoose:         regexp.MustCompile(`^(\d+)_.*\.(?:sql|go)$`),
+	ToolSQLMigrate:    regexp.MustCompile(`^(\d+)[-_].*\.sql$`),
+	ToolFlyway:        regexp.MustCompile(`^[VU](\d+)(?:[._]\d+)*__.*\.sql$`),
+}
+
+// ParseTool returns the Tool with the given name
+func ParseTool(name string) (Tool, error) {
+	tool := Tool(name)
+	if _, ok := versionPatterns[tool]; !ok {
+		return "", errors.Errorf(`unsupported migration tool '%s' (expected golang-migrate, goose, sql-migrate or flyway)`, name)
+	}
+	return tool, nil
+}
+
+// Transactional reports whether the tool runs each migration in a
+// transaction of its own. The statements written for such a tool should
+// not control transactions (see diff.WithTransaction)
+func (t Tool) Transactional() bool {
+	return t == ToolGoose || t == ToolSQLMigrate
+}
+
+// WriteFiles writes the up and down migrations to new files in the
+// directory, named and annotated as the tool expects them, and returns
+// the paths of the files. The name is used as the description of the
+// migration.
+//
+// The version of the migration follows the versions of the existing
+// migration files: if they are numbered sequentially, the next number is
+// used, padded to the same width. Otherwise, including when there are no
+// migration files yet, the version is a timestamp (see WithTime)
+func WriteFiles(dir string, tool Tool, name string, up, down []byte, options ...Option) ([]string, error) {
+	now := time.Now()
+	txn := true
+	for _, o := range options {
+		switch o.Name() {
+		case optkeyTime:
+			now = o.Value().(time.Time)
+		case optkeyTransaction:
+			txn = o.Value().(bool)
+		}
+	}
+
+	pattern, ok := versionPatterns[tool]
+	if !ok {
+		return nil, errors.Errorf(`unsupported migration tool '%s'`, tool)
+	}
+
+	desc := description(name)
+	if desc == "" {
+		return nil, errors.Errorf(`invalid migration name '%s'`, name)
+	}
+
+	entries, err := ioutil.ReadDir(dir)
+	if err != nil {
+		return nil, errors.Wrapf(err, `failed to list migration files in %s`, dir)
+	}
+	var latest string
+	for _, entry := range entries {
+		m := pattern.FindStringSubmatch(entry.Name())
+		if m == nil || entry.IsDir() {
+			continue
+		}
+		if latest == "" || lessNatural(latest, m[1]) {
+			latest = m[1]
+		}
+	}
+	version := nextVersion(latest, now)
+
+	upSQL := strings.TrimSpace(string(up))
+	downSQL := strings.TrimSpace(string(down))
+
+	var files []string
+	var contents [][]byte
+	switch tool {
+	case ToolGolangMigrate:
+		// the files are sent to the server as they are, so the statement
+		// delimiter cannot be changed
+		files = []string{
+			version + "_" + desc + ".up.sql",
+			version + "_" + desc + ".down.sql",
+		}
+		contents = [][]byte{
+			[]byte(rewriteDelimiters(upSQL, "", "") + "\n"),
+			[]byte(rewriteDelimiters(downSQL, "", "") + "\n"),
+		}
+	case ToolGoose:
+		var buf bytes.Buffer
+		if !txn {
+			buf.WriteString("-- +goose NO TRANSACTION\n")
+		}
+		buf.WriteString("-- +goose Up\n")
+		buf.WriteString(rewriteDelimiters(upSQL, "-- +goose StatementBegin", "-- +goose StatementEnd"))
+		buf.WriteString("\n\n-- +goose Down\n")
+		buf.WriteString(rewriteDelimiters(downSQL, "-- +goose StatementBegin", "-- +goose StatementEnd"))
+		buf.WriteByte('\n')
+		files = []string{version + "_" + desc + ".sql"}
+		contents = [][]byte{buf.Bytes()}
+	case ToolSQLMigrate:
+		var suffix string
+		if !txn {
+			suffix = " notransaction"
+		}
+		var buf bytes.Buffer
+		buf.WriteString("-- +migrate Up" + suffix + "\n")
+		buf.WriteString(rewriteDelimiters(upSQL, "-- +migrate StatementBegin", "-- +migrate StatementEnd"))
+		buf.WriteString("\n\n-- +migrate Down" + suffix + "\n")
+		buf.WriteString(rewriteDelimiters(downSQL, "-- +migrate StatementBegin", "-- +migrate StatementEnd"))
+		buf.WriteByte('\n')
+		files = []string{version + "-" + desc + ".sql"}
+		contents = [][]byte{buf.Bytes()}
+	case ToolFlyway:
+		// the down migration is written as an undo migration. flyway
+		// understands DELIMITER in MySQL scripts
+		files = []string{
+			"V" + version + "__" + desc + ".sql",
+			"U" + version + "__" + desc + ".sql",
+		}
+		contents = [][]byte{
+			[]byte(upSQL + "\n"),
+			[]byte(downSQL + "\n"),
+		}
+	}
+
+	var written []string
+	for i, file := range files {
+		path := filepath.Join(dir, file)
+		if err := writeNewFile(path, contents[i]); err != nil {
+			for _, path := range written {
+				os.Remove(path)
+			}
+			return nil, err
+		}
+		written = append(written, path)
+	}
+	return written, nil
+}
+
+// writeNewFile writes the contents to the file, which must not exist yet
+func writeNewFile(path string, content []byte) error {
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0644)
+	if err != nil {
+		return errors.Wrapf(err, `failed to create migration file %s`, path)
+	}
+	if _, err := f.Write(content); err != nil {
+		f.Close()
+		os.Remove(path)
+		return errors.Wrapf(err, `failed to write migration file %s`, path)
+	}
+	if err := f.Close(); err != nil {
+		os.Remove(path)
+		return errors.Wrapf(err, `failed to write migration file %s`, path)
+	}
+	return nil
+}
+
+// nextVersion returns the version that comes after the latest one. The
+// latest version is incremented, unless it is a timestamp (or there is
+// none), in which case the time is used
+func nextVersion(latest string, now time.Time) string {
+	if latest != "" && len(latest) != len(timestampFormat) {
+		return increment(latest)
+	}
+	version := now.UTC().Format(timestampFormat)
+	if latest != "" && !lessNatural(latest, version) {
+		// more than one migration in a second, or a clock that is behind
+		return increment(latest)
+	}
+	return version
+}
+
+// increment adds one to the decimal number, keeping its width
+func increment(s string) string {
+	b := []byte(s)
+	for i := len(b) - 1; i >= 0; i-- {
+		if b[i] < '9' {
+			b[i]++
+			return string(b)
+		}
+		b[i] = '0'
+	}
+	return "1" + string(b)
+}
+
+var nonWordRe = regexp.MustCompile(`[^a-z0-9]+`)
+
+// description turns the name into the part of the file names that
+// describes the migration, using only lower case letters, digits and
+// underscores
+func description(name string) string {
+	return strings.Trim(nonWordRe.ReplaceAllString(strings.ToLower(name), "_"), "_")
+}
+
+// rewriteDelimiters rewrites the statements that are enclosed in
+// DELIMITER ;; and DELIMITER ; (see writeStmt) for the tools that do not
+// understand DELIMITER: each of them is terminated by a semicolon instead,
+// and placed between the begin and end lines, if they are not empty
+func rewriteDelimiters(sql, begin, end string) string {
+	const open, close = "DELIMITER ;;\n", ";;\nDELIMITER ;"
+
+	var buf strings.Builder
+	for {
+		i := strings.Index(sql, open)
+		for i > 0 && sql[i-1] != '\n' {
+			// not at the start of a line, e.g. commented out
+			j := strings.Index(sql[i+1:], open)
+			if j < 0 {
+				i = -1
+				break
+			}
+			i += j + 1
+		}
+		if i < 0 {
+			break
+		}
+		j := strings.Index(sql[i:], close)
+		if j < 0 {
+			break
+		}
+		buf.WriteString(sql[:i])
+		if begin != "" {
+			buf.WriteString(begin)
+			buf.WriteByte('\n')
+		}
+		buf.WriteString(sql[i+len(open) : i+j])
+		buf.WriteByte(';')
+		if end != "" {
+			buf.WriteByte('\n')
+			buf.WriteString(end)
+		}
+		sql = sql[i+j+len(close):]
+	}
+	buf.WriteString(sql)
+	return buf.String()
+}
@@ -0,0 +1,259 @@
+// Package migrations contains a SchemaSource that replays a directory of
+// migration files, so that the schema they produce can be compared with
+// other sources. Importing this package registers the "migrations" URI
+// scheme with schemalex.NewSchemaSource.
+//
+// WriteFiles writes new migration files in the layouts of popular
+// migration tools
+package migrations
+
+import (
+	"bytes"
+	"io"
+	"net/url"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/schemalex/schemalex"
+	"github.com/schemalex/schemalex/format"
+	"github.com/schemalex/schemalex/internal/errors"
+	"github.com/schemalex/schemalex/model"
+)
+
+// DefaultPattern is the pattern of the names of the migration files that
+// are replayed, if none is specified
+const DefaultPattern = "*.up.sql"
+
+func init() {
+	// migrations:///path/to/dir?pattern=*.up.sql
+	schemalex.RegisterSchemaSource("migrations", func(u *url.URL) (schemalex.SchemaSource, error) {
+		if u.Host != "" && u.Host != "localhost" {
+			return nil, errors.New(`remote hosts for migrations:// sources are not supported`)
+		}
+		dir := u.Path
+		if u.Opaque != "" {
+			// migrations:relative/path/to/dir
+			dir = u.Opaque
+		}
+		return NewSource(dir, u.Query().Get("pattern")), nil
+	})
+}
+
+type source struct {
+	dir     string
+	pattern string
+}
+
+// NewSource creates a SchemaSource whose contents are the schema produced
+// by replaying the migration files in the directory, whose names match
+// the pattern (see path/filepath.Match), in the order given by Files. If
+// the pattern is empty, DefaultPattern is used
+func NewSource(dir, pattern string) schemalex.SchemaSource {
+	if pattern == "" {
+		pattern = DefaultPattern
+	}
+	return &source{
+		dir:     dir,
+		pattern: pattern,
+	}
+}
+
+func (s *source) WriteSchema(dst io.Writer) error {
+	files, err := Files(s.dir, s.pattern)
+	if err != nil {
+		return err
+	}
+	if len(files) == 0 {
+		return errors.Errorf(`no migration files matching '%s' found in %s`, s.pattern, s.dir)
+	}
+
+	p := schemalex.New(schemalex.WithDropTables(true))
+	var schema model.Stmts
+	for _, file := range files {
+		stmts, err := p.ParseFile(file)
+		if err != nil {
+			return errors.Wrapf(err, `failed to parse migration file %s`, file)
+		}
+		schema, err = Replay(schema, stmts)
+		if err != nil {
+			return errors.Wrapf(err, `failed to replay migration file %s`, file)
+		}
+	}
+
+	var buf bytes.Buffer
+	for i, stmt := range schema {
+		if i > 0 {
+			buf.WriteString("\n\n")
+		}
+		if err := writeStmt(&buf, stmt); err != nil {
+			return err
+		}
+	}
+	return schemalex.NewReaderSource(&buf).WriteSchema(dst)
+}
+
+// writeStmt writes the statement terminated by a semicolon. If the
+// statement itself contains semicolons, such as a trigger with a compound
+// statement as its body, the statement delimiter is changed around it
+func writeStmt(buf *bytes.Buffer, stmt model.Stmt) error {
+	var out bytes.Buffer
+	if err := format.SQL(&out, stmt); err != nil {
+		return errors.Wrap(err, `failed to format schema`)
+	}
+
+	// CREATE DATABASE is already terminated
+	s := strings.TrimSuffix(out.String(), ";")
+	if !strings.Contains(s, ";") {
+		buf.WriteString(s)
+		buf.WriteByte(';')
+		return nil
+	}
+	buf.WriteString("DELIMITER ;;\n")
+	buf.WriteString(s)
+	buf.WriteString(";;\nDELIMITER ;")
+	return nil
+}
+
+// Files returns the paths of the files in the directory whose names match
+// the pattern (see path/filepath.Match). The numbers in the names are
+// compared by their values, so that "2_foo.up.sql" comes before
+// "10_bar.up.sql", even though they are not padded with zeros
+func Files(dir, pattern string) ([]string, error) {
+	if _, err := filepath.Match(pattern, ""); err != nil {
+		return nil, errors.Wrapf(err, `invalid pattern '%s'`, pattern)
+	}
+
+	files, err := filepath.Glob(filepath.Join(dir, pattern))
+	if err != nil {
+		return nil, errors.Wrapf(err, `failed to list migration files in %s`, dir)
+	}
+	sort.SliceStable(files, func(i, j int) bool {
+		return lessNatural(filepath.Base(files[i]), filepath.Base(files[j]))
+	})
+	return files, nil
+}
+
+// lessNatural compares the strings byte by byte, except that runs of
+// digits are compared by their numeric values
+func lessNatural(a, b string) bool {
+	for a != "" && b != "" {
+		if isDigit(a[0]) && isDigit(b[0]) {
+			i, j := digits(a), digits(b)
+			na, nb := strings.TrimLeft(a[:i], "0"), strings.TrimLeft(b[:j], "0")
+			if len(na) != len(nb) {
+				return len(na) < len(nb)
+			}
+			if na != nb {
+				return na < nb
+			}
+			a, b = a[i:], b[j:]
+			continue
+		}
+		if a[0] != b[0] {
+			return a[0] < b[0]
+		}
+		a, b = a[1:], b[1:]
+	}
+	return len(a) < len(b)
+}
+
+func isDigit(c byte) bool {
+	return c >= '0' && c <= '9'
+}
+
+// digits returns the length of the run of digits at the start of s
+func digits(s string) int {
+	var i int
+	for i < len(s) && isDigit(s[i]) {
+		i++
+	}
+	return i
+}
+
+// Replay applies the statements to the schema in order, in the same way
+// as MySQL would execute them, and returns the resulting schema. ALTER
+// TABLE (and RENAME TABLE, CREATE INDEX and DROP INDEX, which are parsed
+// as such) is applied by model.Apply, DROP TABLE by model.Drop, and the
+// statements that drop the other objects by model.Remove. The statements
+// that create objects add them to the schema. The given schema is not
+// modified
+func Replay(schema model.Stmts, stmts model.Stmts) (model.Stmts, error) {
+	for _, stmt := range stmts {
+		var err error
+		switch stmt := stmt.(type) {
+		case model.AlterTable:
+			schema, err = model.Apply(schema, stmt)
+		case model.DropTable:
+			schema, err = model.Drop(schema, stmt)
+		case model.DropObject:
+			schema, err = model.Remove(schema, stmt)
+		case model.Table:
+			schema, err = createTable(schema, stmt)
+		default:
+			schema, err = create(schema, stmt)
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+	return schema, nil
+}
+
+func createTable(schema model.Stmts, table model.Table) (model.Stmts, error) {
+	if _, ok := schema.Lookup(table.ID()); ok {
+		if table.IsIfNotExists() {
+			return schema, nil
+		}
+		return nil, errors.Errorf(`table '%s' already exists`, table.Name())
+	}
+
+	if table.HasLikeTable() {
+		// the table is created with the same definition as the other
+		// one, which is the same as renaming a copy of it
+		like := model.NewTable(table.LikeTable())
+		like.SetSchema(table.Schema())
+		stmt, ok := schema.Lookup(like.ID())
+		if !ok {
+			return nil, errors.Errorf(`table '%s' does not exist`, table.LikeTable())
+		}
+		alter := model.NewAlterTable(like.Name())
+		alter.SetSchema(like.Schema())
+		alter.AddSpecs(model.NewAlterTableSpec(model.AlterTableSpecRename).SetNewName(table.Name()).SetNewSchema(table.Schema()))
+		copied, err := model.Apply(model.Stmts{stmt}, alter)
+		if err != nil {
+			return nil, err
+		}
+		return appendStmt(schema, copied[0]), nil
+	}
+	return appendStmt(schema, table), nil
+}
+
+func create(schema model.Stmts, stmt model.Stmt) (model.Stmts, error) {
+	for i, v := range schema {
+		if v.ID() != stmt.ID() {
+			continue
+		}
+		switch stmt := stmt.(type) {
+		case model.View:
+			if stmt.IsOrReplace() {
+				result := appendStmt(schema[:i:i], stmt)
+				return append(result, schema[i+1:]...), nil
+			}
+		case model.Database:
+			if stmt.IsIfNotExists() {
+				return schema, nil
+			}
+		}
+		return nil, errors.Errorf(`%s already exists`, stmt.ID())
+	}
+	return appendStmt(schema, stmt), nil
+}
+
+// appendStmt appends the statement to a copy of the schema, so that the
+// schema that was given to Replay is not modified
+func appendStmt(schema model.Stmts, stmt model.Stmt) model.Stmts {
+	result := make(model.Stmts, len(schema), len(schema)+1)
+	copy(result, schema)
+	return append(result, stmt)
+}
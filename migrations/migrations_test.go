@@ -0,0 +1,284 @@
+package migrations_test
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/schemalex/schemalex"
+	"github.com/schemalex/schemalex/migrations"
+	"github.com/stretchr/testify/assert"
+)
+
+func writeFiles(t *testing.T, files map[string]string) string {
+	t.Helper()
+
+	dir, err := ioutil.TempDir("", "schemalex-migrations-")
+	if err != nil {
+		t.Fatalf("creating temporary directory should succeed: %s", err)
+	}
+	for name, content := range files {
+		if err := ioutil.WriteFile(filepath.Join(dir, name), []byte(content), 0644); err != nil {
+			os.RemoveAll(dir)
+			t.Fatalf("writing %s should succeed: %s", name, err)
+		}
+	}
+	return dir
+}
+
+func TestFiles(t *testing.T) {
+	dir := writeFiles(t, map[string]string{
+		"10_c.up.sql":  "",
+		"2_b.up.sql":   "",
+		"2_b.down.sql": "",
+		"001_a.up.sql": "",
+		"README.md":    "",
+	})
+	defer os.RemoveAll(dir)
+
+	files, err := migrations.Files(dir, "*.up.sql")
+	if !assert.NoError(t, err, "Files should succeed") {
+		return
+	}
+	var names []string
+	for _, file := range files {
+		names = append(names, filepath.Base(file))
+	}
+	assert.Equal(t, []string{"001_a.up.sql", "2_b.up.sql", "10_c.up.sql"}, names)
+
+	_, err = migrations.Files(dir, "[")
+	assert.Error(t, err, "Files should fail for invalid patterns")
+}
+
+func TestSource(t *testing.T) {
+	type testCase struct {
+		name   string
+		files  map[string]string
+		expect string
+		error  bool
+	}
+
+	for _, tc := range []testCase{
+		{
+			name: "Replay",
+			files: map[string]string{
+				"0001_init.up.sql":       "CREATE TABLE users (id INT NOT NULL, name VARCHAR(10) NOT NULL, PRIMARY KEY (id));\nCREATE TABLE tmp (id INT NOT NULL);",
+				"0001_init.down.sql":     "DROP TABLE users;",
+				"0002_profile.up.sql":    "ALTER TABLE users ADD COLUMN email VARCHAR(255) NOT NULL AFTER id, DROP COLUMN name;\nINSERT INTO users (id, email) VALUES (1, 'a@example.com');",
+				"0003_rename.up.sql":     "RENAME TABLE users TO accounts;\nDROP TABLE IF EXISTS tmp, missing;",
+				"0004_copy.up.sql":       "CREATE TABLE accounts_copy LIKE accounts;\nCREATE TABLE IF NOT EXISTS accounts (id INT);",
+				"0010_triggers.up.sql":   "DELIMITER ;;\nCREATE TRIGGER t BEFORE INSERT ON accounts FOR EACH ROW BEGIN SET NEW.id = 1; END;;\nDELIMITER ;",
+				"0010_triggers.down.sql": "DROP TRIGGER t;",
+			},
+			expect: "CREATE TABLE `accounts` (\n`id` INT (11) NOT NULL,\n`email` VARCHAR (255) NOT NULL,\nPRIMARY KEY (`id`)\n);\n\n" +
+				"CREATE TABLE `accounts_copy` (\n`id` INT (11) NOT NULL,\n`email` VARCHAR (255) NOT NULL,\nPRIMARY KEY (`id`)\n);\n\n" +
+				"DELIMITER ;;\nCREATE TRIGGER `t` BEFORE INSERT ON `accounts` FOR EACH ROW BEGIN SET NEW.id = 1; END;;\nDELIMITER ;",
+		},
+		{
+			name: "DropObjects",
+			files: map[string]string{
+				"1.up.sql": "CREATE DATABASE app; CREATE DATABASE other; USE other; CREATE TABLE t (id INT NOT NULL); CREATE VIEW w AS SELECT 1;\n" +
+					"USE app; CREATE TABLE users (id INT NOT NULL, email VARCHAR(255) NOT NULL); CREATE VIEW v AS SELECT 1; CREATE PROCEDURE p () SELECT 1; CREATE FUNCTION p () RETURNS INT RETURN 1; CREATE EVENT e ON SCHEDULE EVERY 1 DAY DO SELECT 1;",
+				"2.up.sql": "USE app; CREATE INDEX idx_email ON users (email); CREATE UNIQUE INDEX uniq_id ON users (id);\n" +
+					"DROP INDEX idx_email ON users; DROP VIEW v; DROP PROCEDURE p; DROP EVENT IF EXISTS e; DROP TRIGGER IF EXISTS missing; DROP DATABASE other;",
+			},
+			expect: "CREATE DATABASE `app`;\n\n" +
+				"CREATE TABLE `app`.`users` (\n`id` INT (11) NOT NULL,\n`email` VARCHAR (255) NOT NULL,\nUNIQUE INDEX `uniq_id` (`id`)\n);\n\n" +
+				"CREATE FUNCTION `app`.`p` () RETURNS INT RETURN 1;",
+		},
+		{
+			name: "DropMissingView",
+			files: map[string]string{
+				"1.up.sql": "CREATE VIEW v AS SELECT 1; DROP VIEW w;",
+			},
+			error: true,
+		},
+		{
+			name: "AlterMissingTable",
+			files: map[string]string{
+				"1.up.sql": "ALTER TABLE users ADD COLUMN id INT;",
+			},
+			error: true,
+		},
+		{
+			name: "CreateExistingTable",
+			files: map[string]string{
+				"1.up.sql": "CREATE TABLE users (id INT);",
+				"2.up.sql": "CREATE TABLE users (id INT);",
+			},
+			error: true,
+		},
+		{
+			name: "DropMissingTable",
+			files: map[string]string{
+				"1.up.sql": "DROP TABLE users;",
+			},
+			error: true,
+		},
+		{
+			name:  "NoFiles",
+			files: map[string]string{},
+			error: true,
+		},
+	} {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			dir := writeFiles(t, tc.files)
+			defer os.RemoveAll(dir)
+
+			src, err := schemalex.NewSchemaSource("migrations://" + filepath.ToSlash(dir) + "?pattern=*.up.sql")
+			if !assert.NoError(t, err, "NewSchemaSource should succeed") {
+				return
+			}
+
+			var buf bytes.Buffer
+			err = src.WriteSchema(&buf)
+			if tc.error {
+				assert.Error(t, err, "WriteSchema should fail")
+				return
+			}
+			if !assert.NoError(t, err, "WriteSchema should succeed") {
+				return
+			}
+			if !assert.Equal(t, tc.expect, buf.String()) {
+				return
+			}
+
+			// the output can be parsed as a schema
+			_, err = schemalex.New().Parse(buf.Bytes())
+			assert.NoError(t, err, "parsing the output should succeed")
+		})
+	}
+}
+
+func TestWriteFiles(t *testing.T) {
+	type testCase struct {
+		name     string
+		tool     migrations.Tool
+		existing map[string]string
+		options  []migrations.Option
+		expect   map[string]string
+		error    bool
+	}
+
+	const up = "\nBEGIN;\n\nSET FOREIGN_KEY_CHECKS = 0;\n\nDELIMITER ;;\nCREATE TRIGGER `t` BEFORE INSERT ON `hoge` FOR EACH ROW BEGIN SET NEW.id = 1; END;;\nDELIMITER ;\n\nSET FOREIGN_KEY_CHECKS = 1;\n\nCOMMIT;"
+	const down = "DROP TRIGGER `t`;"
+	now := time.Date(2023, 4, 5, 6, 7, 8, 0, time.UTC)
+
+	for _, tc := range []testCase{
+		{
+			name: "GolangMigrateTimestamp",
+			tool: migrations.ToolGolangMigrate,
+			existing: map[string]string{
+				"20230101000000_init.up.sql":   "",
+				"20230101000000_init.down.sql": "",
+			},
+			expect: map[string]string{
+				"20230405060708_add_trigger.up.sql":   "BEGIN;\n\nSET FOREIGN_KEY_CHECKS = 0;\n\nCREATE TRIGGER `t` BEFORE INSERT ON `hoge` FOR EACH ROW BEGIN SET NEW.id = 1; END;\n\nSET FOREIGN_KEY_CHECKS = 1;\n\nCOMMIT;\n",
+				"20230405060708_add_trigger.down.sql": "DROP TRIGGER `t`;\n",
+			},
+		},
+		{
+			name: "GolangMigrateSequence",
+			tool: migrations.ToolGolangMigrate,
+			existing: map[string]string{
+				"000009_init.up.sql":     "",
+				"000010_profile.up.sql":  "",
+				"000002_profile.up.sql":  "",
+				"20230101000000_x.txt":   "",
+				"not_a_migration.up.sql": "",
+			},
+			expect: map[string]string{
+				"000011_add_trigger.up.sql":   "BEGIN;\n\nSET FOREIGN_KEY_CHECKS = 0;\n\nCREATE TRIGGER `t` BEFORE INSERT ON `hoge` FOR EACH ROW BEGIN SET NEW.id = 1; END;\n\nSET FOREIGN_KEY_CHECKS = 1;\n\nCOMMIT;\n",
+				"000011_add_trigger.down.sql": "DROP TRIGGER `t`;\n",
+			},
+		},
+		{
+			name: "GooseNoTransaction",
+			tool: migrations.ToolGoose,
+			existing: map[string]string{
+				"9_init.sql": "",
+			},
+			options: []migrations.Option{migrations.WithTransaction(false)},
+			expect: map[string]string{
+				"10_add_trigger.sql": "-- +goose NO TRANSACTION\n-- +goose Up\nBEGIN;\n\nSET FOREIGN_KEY_CHECKS = 0;\n\n-- +goose StatementBegin\nCREATE TRIGGER `t` BEFORE INSERT ON `hoge` FOR EACH ROW BEGIN SET NEW.id = 1; END;\n-- +goose StatementEnd\n\nSET FOREIGN_KEY_CHECKS = 1;\n\nCOMMIT;\n\n-- +goose Down\nDROP TRIGGER `t`;\n",
+			},
+		},
+		{
+			name: "SQLMigrate",
+			tool: migrations.ToolSQLMigrate,
+			expect: map[string]string{
+				"20230405060708-add_trigger.sql": "-- +migrate Up\nBEGIN;\n\nSET FOREIGN_KEY_CHECKS = 0;\n\n-- +migrate StatementBegin\nCREATE TRIGGER `t` BEFORE INSERT ON `hoge` FOR EACH ROW BEGIN SET NEW.id = 1; END;\n-- +migrate StatementEnd\n\nSET FOREIGN_KEY_CHECKS = 1;\n\nCOMMIT;\n\n-- +migrate Down\nDROP TRIGGER `t`;\n",
+			},
+		},
+		{
+			name: "Flyway",
+			tool: migrations.ToolFlyway,
+			existing: map[string]string{
+				"V1__init.sql":   "",
+				"V2_1__fix.sql":  "",
+				"R__views.sql":   "",
+				"U1__init.sql":   "",
+				"V10__other.txt": "",
+			},
+			expect: map[string]string{
+				"V3__add_trigger.sql": strings.TrimSpace(up) + "\n",
+				"U3__add_trigger.sql": down + "\n",
+			},
+		},
+		{
+			name: "ClockBehind",
+			tool: migrations.ToolGolangMigrate,
+			existing: map[string]string{
+				"20231231235959_init.up.sql": "",
+			},
+			expect: map[string]string{
+				"20231231235960_add_trigger.up.sql":   "BEGIN;\n\nSET FOREIGN_KEY_CHECKS = 0;\n\nCREATE TRIGGER `t` BEFORE INSERT ON `hoge` FOR EACH ROW BEGIN SET NEW.id = 1; END;\n\nSET FOREIGN_KEY_CHECKS = 1;\n\nCOMMIT;\n",
+				"20231231235960_add_trigger.down.sql": "DROP TRIGGER `t`;\n",
+			},
+		},
+		{
+			name:  "UnknownTool",
+			tool:  migrations.Tool("liquibase"),
+			error: true,
+		},
+	} {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			dir := writeFiles(t, tc.existing)
+			defer os.RemoveAll(dir)
+
+			options := append([]migrations.Option{migrations.WithTime(now)}, tc.options...)
+			files, err := migrations.WriteFiles(dir, tc.tool, "Add trigger", []byte(up), []byte(down), options...)
+			if tc.error {
+				assert.Error(t, err, "WriteFiles should fail")
+				return
+			}
+			if !assert.NoError(t, err, "WriteFiles should succeed") {
+				return
+			}
+
+			written := make(map[string]string)
+			for _, file := range files {
+				content, err := ioutil.ReadFile(file)
+				if !assert.NoError(t, err, "reading %s should succeed", file) {
+					return
+				}
+				written[filepath.Base(file)] = string(content)
+			}
+			assert.Equal(t, tc.expect, written)
+
+			// the next migration in the same second gets the next version,
+			// instead of overwriting the files
+			next, err := migrations.WriteFiles(dir, tc.tool, "Add trigger", []byte(up), []byte(down), options...)
+			if !assert.NoError(t, err, "WriteFiles should succeed") {
+				return
+			}
+			assert.NotEqual(t, files, next)
+		})
+	}
+}
@@ -0,0 +1,254 @@
+package model
+
+import "strings"
+
+// NewAlterTable creates a new `ALTER TABLE` statement for the table
+// with the given name
+func NewAlterTable(name string) AlterTable {
+	return &alterTable{
+		name: name,
+	}
+}
+
+func (a *alterTable) ID() string {
+	if a.schema != "" {
+		return "alter_table#" + a.schema + "." + a.name
+	}
+	return "alter_table#" + a.name
+}
+
+func (a *alterTable) Name() string {
+	return a.name
+}
+
+func (a *alterTable) Schema() string {
+	return a.schema
+}
+
+func (a *alterTable) SetSchema(s string) AlterTable {
+	a.schema = s
+	return a
+}
+
+func (a *alterTable) AddSpecs(l ...AlterTableSpec) AlterTable {
+	a.specs = append(a.specs, l...)
+	return a
+}
+
+func (a *alterTable) Specs() chan AlterTableSpec {
+	ch := make(chan AlterTableSpec, len(a.specs))
+	for _, spec := range a.specs {
+		ch <- spec
+	}
+	close(ch)
+	return ch
+}
+
+// NewAlterTableSpec creates a new alter specification of the given kind
+func NewAlterTableSpec(kind AlterTableSpecKind) AlterTableSpec {
+	return &alterTableSpec{
+		kind: kind,
+	}
+}
+
+func (s *alterTableSpec) Kind() AlterTableSpecKind {
+	return s.kind
+}
+
+func (s *alterTableSpec) Name() string {
+	return s.name
+}
+
+func (s *alterTableSpec) SetName(v string) AlterTableSpec {
+	s.name = v
+	return s
+}
+
+func (s *alterTableSpec) NewName() string {
+	return s.newName
+}
+
+func (s *alterTableSpec) SetNewName(v string) AlterTableSpec {
+	s.newName = v
+	return s
+}
+
+func (s *alterTableSpec) NewSchema() string {
+	return s.newSchema
+}
+
+func (s *alterTableSpec) SetNewSchema(v string) AlterTableSpec {
+	s.newSchema = v
+	return s
+}
+
+func (s *alterTableSpec) Column() TableColumn {
+	return s.column
+}
+
+func (s *alterTableSpec) SetColumn(v TableColumn) AlterTableSpec {
+	s.column = v
+	return s
+}
+
+func (s *alterTableSpec) IsFirst() bool {
+	return s.first
+}
+
+func (s *alterTableSpec) SetFirst(v bool) AlterTableSpec {
+	s.first = v
+	return s
+}
+
+func (s *alterTableSpec) HasAfter() bool {
+	return s.after.Valid
+}
+
+func (s *alterTableSpec) After() string {
+	return s.after.Value
+}
+
+func (s *alterTableSpec) SetAfter(v string) AlterTableSpec {
+	s.after.Valid = true
+	s.after.Value = v
+	return s
+}
+
+func (s *alterTableSpec) Index() Index {
+	return s.index
+}
+
+func (s *alterTableSpec) SetIndex(v Index) AlterTableSpec {
+	s.index = v
+	return s
+}
+
+func (s *alterTableSpec) CheckConstraint() CheckConstraint {
+	return s.check
+}
+
+func (s *alterTableSpec) SetCheckConstraint(v CheckConstraint) AlterTableSpec {
+	s.check = v
+	return s
+}
+
+func (s *alterTableSpec) AddOption(v TableOption) AlterTableSpec {
+	s.options = append(s.options, v)
+	return s
+}
+
+func (s *alterTableSpec) Options() chan TableOption {
+	ch := make(chan TableOption, len(s.options))
+	for _, opt := range s.options {
+		ch <- opt
+	}
+	close(ch)
+	return ch
+}
+
+// NewDropTable creates a new `DROP TABLE` statement for the table with
+// the given name
+func NewDropTable(name string) DropTable {
+	return &dropTable{
+		name: name,
+	}
+}
+
+func (d *dropTable) ID() string {
+	if d.schema != "" {
+		return "drop_table#" + d.schema + "." + d.name
+	}
+	return "drop_table#" + d.name
+}
+
+func (d *dropTable) Name() string {
+	return d.name
+}
+
+func (d *dropTable) Schema() string {
+	return d.schema
+}
+
+func (d *dropTable) SetSchema(s string) DropTable {
+	d.schema = s
+	return d
+}
+
+func (d *dropTable) IsTemporary() bool {
+	return d.temporary
+}
+
+func (d *dropTable) SetTemporary(v bool) DropTable {
+	d.temporary = v
+	return d
+}
+
+func (d *dropTable) IsIfExists() bool {
+	return d.ifexists
+}
+
+func (d *dropTable) SetIfExists(v bool) DropTable {
+	d.ifexists = v
+	return d
+}
+
+// NewDropObject creates a new `DROP` statement for the object of the
+// given kind (see DropObject.Kind) with the given name
+func NewDropObject(kind, name string) DropObject {
+	return &dropObject{
+		kind: kind,
+		name: name,
+	}
+}
+
+func (d *dropObject) ID() string {
+	prefix := "drop_" + strings.ToLower(d.kind) + "#"
+	if d.schema != "" {
+		return prefix + d.schema + "." + d.name
+	}
+	return prefix + d.name
+}
+
+func (d *dropObject) Kind() string {
+	return d.kind
+}
+
+func (d *dropObject) Name() string {
+	return d.name
+}
+
+func (d *dropObject) Schema() string {
+	return d.schema
+}
+
+func (d *dropObject) SetSchema(s string) DropObject {
+	d.schema = s
+	return d
+}
+
+func (d *dropObject) IsIfExists() bool {
+	return d.ifexists
+}
+
+func (d *dropObject) SetIfExists(v bool) DropObject {
+	d.ifexists = v
+	return d
+}
+
+func (d *dropObject) Target() string {
+	switch d.kind {
+	case "VIEW":
+		return NewView(d.name).SetSchema(d.schema).ID()
+	case "TRIGGER":
+		return NewTrigger(d.name).SetSchema(d.schema).ID()
+	case "PROCEDURE":
+		return NewProcedure(d.name).SetSchema(d.schema).ID()
+	case "FUNCTION":
+		return NewFunction(d.name).SetSchema(d.schema).ID()
+	case "EVENT":
+		return NewEvent(d.name).SetSchema(d.schema).ID()
+	case "DATABASE":
+		return NewDatabase(d.name).ID()
+	}
+	return ""
+}
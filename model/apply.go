@@ -0,0 +1,498 @@
+package model
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/schemalex/schemalex/internal/errors"
+)
+
+// Apply applies the `ALTER TABLE` statement to the table that it alters,
+// and returns the statements with the table replaced by the altered one.
+// The given statements and the table are not modified. An error is
+// returned if the table does not exist, or if any of the specifications
+// cannot be applied, much like MySQL would refuse to execute the statement
+func Apply(stmts Stmts, alter AlterTable) (Stmts, error) {
+	id := NewTable(alter.Name()).SetSchema(alter.Schema()).ID()
+
+	pos := -1
+	for i, stmt := range stmts {
+		if stmt.ID() == id {
+			pos = i
+			break
+		}
+	}
+	if pos < 0 {
+		return nil, errors.Errorf(`table '%s' does not exist`, alter.Name())
+	}
+	table, ok := stmts[pos].(Table)
+	if !ok {
+		return nil, errors.Errorf(`%s is not a model.Table`, id)
+	}
+
+	a := newAlteration(table)
+	for spec := range alter.Specs() {
+		if err := a.apply(spec); err != nil {
+			return nil, errors.Wrapf(err, `failed to alter table '%s'`, alter.Name())
+		}
+	}
+
+	altered := a.build()
+	if altered.ID() != id {
+		if _, ok := stmts.Lookup(altered.ID()); ok {
+			return nil, errors.Errorf(`failed to alter table '%s': table '%s' already exists`, alter.Name(), altered.Name())
+		}
+	}
+
+	result := make(Stmts, len(stmts))
+	copy(result, stmts)
+	result[pos] = altered
+	return result, nil
+}
+
+// Drop removes the table that the `DROP TABLE` statement drops from the
+// statements, and returns the remaining ones. The given statements are
+// not modified. An error is returned if the table does not exist, unless
+// `IF EXISTS` was specified
+func Drop(stmts Stmts, drop DropTable) (Stmts, error) {
+	id := NewTable(drop.Name()).SetSchema(drop.Schema()).ID()
+
+	result := make(Stmts, 0, len(stmts))
+	for _, stmt := range stmts {
+		if stmt.ID() != id {
+			result = append(result, stmt)
+		}
+	}
+	if len(result) == len(stmts) && !drop.IsIfExists() {
+		return nil, errors.Errorf(`table '%s' does not exist`, drop.Name())
+	}
+	return result, nil
+}
+
+// Remove removes the object that the `DROP` statement drops from the
+// statements, and returns the remaining ones. Dropping a database removes
+// the objects that belong to it as well. The given statements are not
+// modified. An error is returned if the object does not exist, unless
+// `IF EXISTS` was specified
+func Remove(stmts Stmts, drop DropObject) (Stmts, error) {
+	target := drop.Target()
+
+	var found bool
+	result := make(Stmts, 0, len(stmts))
+	for _, stmt := range stmts {
+		if stmt.ID() == target {
+			found = true
+			continue
+		}
+		if drop.Kind() == "DATABASE" {
+			if v, ok := stmt.(interface{ Schema() string }); ok && v.Schema() == drop.Name() {
+				continue
+			}
+		}
+		result = append(result, stmt)
+	}
+	if !found && !drop.IsIfExists() {
+		return nil, errors.Errorf(`%s '%s' does not exist`, strings.ToLower(drop.Kind()), drop.Name())
+	}
+	return result, nil
+}
+
+// alteration keeps the parts of the table that is being altered
+type alteration struct {
+	source  Table
+	name    string
+	schema  string
+	columns []TableColumn
+	indexes []Index
+	checks  []CheckConstraint
+	options []TableOption
+}
+
+func newAlteration(table Table) *alteration {
+	a := &alteration{
+		source: table,
+		name:   table.Name(),
+		schema: table.Schema(),
+	}
+	for col := range table.Columns() {
+		a.columns = append(a.columns, col)
+	}
+	for idx := range table.Indexes() {
+		a.indexes = append(a.indexes, idx)
+	}
+	for check := range table.CheckConstraints() {
+		a.checks = append(a.checks, check)
+	}
+	for opt := range table.Options() {
+		a.options = append(a.options, opt)
+	}
+	return a
+}
+
+func (a *alteration) apply(spec AlterTableSpec) error {
+	switch spec.Kind() {
+	case AlterTableSpecAddColumn:
+		col := spec.Column()
+		if a.lookupColumn(col.Name()) >= 0 {
+			return errors.Errorf(`duplicate column name '%s'`, col.Name())
+		}
+		return a.insertColumn(len(a.columns), col, spec)
+	case AlterTableSpecDropColumn:
+		i := a.lookupColumn(spec.Name())
+		if i < 0 {
+			return errors.Errorf(`column '%s' does not exist`, spec.Name())
+		}
+		a.columns = append(a.columns[:i:i], a.columns[i+1:]...)
+		a.updateKeyParts(spec.Name(), "")
+	case AlterTableSpecModifyColumn:
+		i := a.lookupColumn(spec.Name())
+		if i < 0 {
+			return errors.Errorf(`column '%s' does not exist`, spec.Name())
+		}
+		col := spec.Column()
+		if j := a.lookupColumn(col.Name()); j >= 0 && j != i {
+			return errors.Errorf(`duplicate column name '%s'`, col.Name())
+		}
+		a.columns = append(a.columns[:i:i], a.columns[i+1:]...)
+		if err := a.insertColumn(i, col, spec); err != nil {
+			return err
+		}
+		if col.Name() != spec.Name() {
+			a.updateKeyParts(spec.Name(), col.Name())
+		}
+	case AlterTableSpecRenameColumn:
+		i := a.lookupColumn(spec.Name())
+		if i < 0 {
+			return errors.Errorf(`column '%s' does not exist`, spec.Name())
+		}
+		if j := a.lookupColumn(spec.NewName()); j >= 0 && j != i {
+			return errors.Errorf(`duplicate column name '%s'`, spec.NewName())
+		}
+		col := a.columns[i].Clone()
+		col.SetName(spec.NewName())
+		a.columns[i] = col
+		a.updateKeyParts(spec.Name(), spec.NewName())
+	case AlterTableSpecAddIndex:
+		idx := spec.Index()
+		switch {
+		case idx.IsForeignKey() && !idx.HasSymbol():
+			idx = idx.Clone()
+			idx.SetSymbol(a.nextForeignKeyName())
+		case !idx.IsForeignKey() && !idx.IsPrimaryKey() && !idx.HasName():
+			idx = idx.Clone()
+			idx.SetName(a.nextIndexName(idx))
+		}
+		switch {
+		case idx.IsPrimaryKey():
+			if a.lookupPrimaryKey() >= 0 {
+				return errors.New(`multiple primary key defined`)
+			}
+		case idx.HasName() && a.lookupIndex(idx.Name()) >= 0:
+			return errors.Errorf(`duplicate key name '%s'`, idx.Name())
+		}
+		a.indexes = append(a.indexes, idx)
+	case AlterTableSpecDropIndex:
+		i := a.lookupIndex(spec.Name())
+		if i < 0 {
+			return errors.Errorf(`key '%s' does not exist`, spec.Name())
+		}
+		a.indexes = append(a.indexes[:i:i], a.indexes[i+1:]...)
+	case AlterTableSpecDropPrimaryKey:
+		i := a.lookupPrimaryKey()
+		if i < 0 {
+			return errors.New(`primary key does not exist`)
+		}
+		a.indexes = append(a.indexes[:i:i], a.indexes[i+1:]...)
+	case AlterTableSpecDropForeignKey:
+		i := a.lookupForeignKey(spec.Name())
+		if i < 0 {
+			return errors.Errorf(`foreign key '%s' does not exist`, spec.Name())
+		}
+		a.indexes = append(a.indexes[:i:i], a.indexes[i+1:]...)
+	case AlterTableSpecRenameIndex:
+		i := a.lookupIndex(spec.Name())
+		if i < 0 {
+			return errors.Errorf(`key '%s' does not exist`, spec.Name())
+		}
+		if j := a.lookupIndex(spec.NewName()); j >= 0 && j != i {
+			return errors.Errorf(`duplicate key name '%s'`, spec.NewName())
+		}
+		idx := a.indexes[i].Clone()
+		idx.SetName(spec.NewName())
+		a.indexes[i] = idx
+	case AlterTableSpecAddCheckConstraint:
+		check := spec.CheckConstraint()
+		if !check.HasName() {
+			check = check.Clone()
+			check.SetName(a.nextCheckName())
+		}
+		if a.lookupCheckConstraint(check.Name()) >= 0 {
+			return errors.Errorf(`duplicate check constraint name '%s'`, check.Name())
+		}
+		a.checks = append(a.checks, check)
+	case AlterTableSpecDropCheckConstraint:
+		i := a.lookupCheckConstraint(spec.Name())
+		if i < 0 {
+			return errors.Errorf(`check constraint '%s' does not exist`, spec.Name())
+		}
+		a.checks = append(a.checks[:i:i], a.checks[i+1:]...)
+	case AlterTableSpecDropConstraint:
+		if i := a.lookupCheckConstraint(spec.Name()); i >= 0 {
+			a.checks = append(a.checks[:i:i], a.checks[i+1:]...)
+			return nil
+		}
+		if i := a.lookupForeignKey(spec.Name()); i >= 0 {
+			a.indexes = append(a.indexes[:i:i], a.indexes[i+1:]...)
+			return nil
+		}
+		if i := a.lookupIndex(spec.Name()); i >= 0 && a.indexes[i].IsUnique() {
+			a.indexes = append(a.indexes[:i:i], a.indexes[i+1:]...)
+			return nil
+		}
+		return errors.Errorf(`constraint '%s' does not exist`, spec.Name())
+	case AlterTableSpecTableOptions:
+		for opt := range spec.Options() {
+			a.setOption(opt)
+		}
+	case AlterTableSpecRename:
+		a.name = spec.NewName()
+		a.schema = spec.NewSchema()
+	default:
+		return errors.Errorf(`unsupported alter specification %d`, spec.Kind())
+	}
+	return nil
+}
+
+// insertColumn inserts the column at the position given by FIRST or AFTER
+// in the specification, or at i if neither was specified
+func (a *alteration) insertColumn(i int, col TableColumn, spec AlterTableSpec) error {
+	switch {
+	case spec.IsFirst():
+		i = 0
+	case spec.HasAfter():
+		j := a.lookupColumn(spec.After())
+		if j < 0 {
+			return errors.Errorf(`column '%s' does not exist`, spec.After())
+		}
+		i = j + 1
+	}
+	a.columns = append(a.columns[:i], append([]TableColumn{col}, a.columns[i:]...)...)
+	return nil
+}
+
+// updateKeyParts renames the key parts that refer to the column in all of
+// the indexes. If to is empty, the key parts are removed instead, along
+// with the indexes that have no key parts left
+func (a *alteration) updateKeyParts(from, to string) {
+	var indexes []Index
+	for _, idx := range a.indexes {
+		var changed bool
+		var parts []IndexColumn
+		for col := range idx.Columns() {
+			if col.IsExpression() || !strings.EqualFold(col.Name(), from) {
+				parts = append(parts, col)
+				continue
+			}
+			changed = true
+			if to == "" {
+				continue
+			}
+			renamed := NewIndexColumn(to)
+			if col.HasLength() {
+				renamed.SetLength(col.Length())
+			}
+			switch {
+			case col.IsAscending():
+				renamed.SetSortDirection(SortDirectionAscending)
+			case col.IsDescending():
+				renamed.SetSortDirection(SortDirectionDescending)
+			}
+			parts = append(parts, renamed)
+		}
+
+		if changed {
+			if len(parts) == 0 {
+				continue
+			}
+			if v, ok := idx.Clone().(*index); ok {
+				v.columns = parts
+				idx = v
+			}
+		}
+		indexes = append(indexes, idx)
+	}
+	a.indexes = indexes
+}
+
+func (a *alteration) setOption(opt TableOption) {
+	for i, v := range a.options {
+		if v.Key() == opt.Key() {
+			a.options[i] = opt
+			return
+		}
+	}
+	a.options = append(a.options, opt)
+}
+
+// Column, index and constraint names are not case sensitive in MySQL
+
+func (a *alteration) lookupColumn(name string) int {
+	for i, col := range a.columns {
+		if strings.EqualFold(col.Name(), name) {
+			return i
+		}
+	}
+	return -1
+}
+
+func (a *alteration) lookupIndex(name string) int {
+	for i, idx := range a.indexes {
+		if idx.IsForeignKey() {
+			continue
+		}
+		if idx.IsPrimaryKey() && strings.EqualFold(name, "PRIMARY") {
+			return i
+		}
+		if idx.HasName() && strings.EqualFold(idx.Name(), name) {
+			return i
+		}
+	}
+	return -1
+}
+
+func (a *alteration) lookupPrimaryKey() int {
+	for i, idx := range a.indexes {
+		if idx.IsPrimaryKey() {
+			return i
+		}
+	}
+	return -1
+}
+
+func (a *alteration) lookupForeignKey(name string) int {
+	for i, idx := range a.indexes {
+		if !idx.IsForeignKey() {
+			continue
+		}
+		if idx.HasSymbol() && strings.EqualFold(idx.Symbol(), name) || !idx.HasSymbol() && idx.HasName() && strings.EqualFold(idx.Name(), name) {
+			return i
+		}
+	}
+	return -1
+}
+
+func (a *alteration) lookupCheckConstraint(name string) int {
+	for i, check := range a.checks {
+		if check.HasName() && strings.EqualFold(check.Name(), name) {
+			return i
+		}
+	}
+	return -1
+}
+
+// nextCheckName returns the name that MySQL gives to a check constraint
+// that is added without a name, continuing from the largest number used
+// by the existing ones
+func (a *alteration) nextCheckName() string {
+	re := regexp.MustCompile(`^` + regexp.QuoteMeta(a.source.Name()) + `_chk_(\d+)$`)
+	var n int
+	for _, check := range a.checks {
+		if m := re.FindStringSubmatch(check.Name()); m != nil {
+			if v, err := strconv.Atoi(m[1]); err == nil && v > n {
+				n = v
+			}
+		}
+	}
+	return a.source.Name() + "_chk_" + strconv.Itoa(n+1)
+}
+
+// nextForeignKeyName returns the name that MySQL gives to a foreign key
+// that is added without a name, continuing from the largest number used
+// by the existing ones. Foreign keys that were declared without a name
+// when the table was created are numbered from 1 by MySQL, so they are
+// counted as well
+func (a *alteration) nextForeignKeyName() string {
+	re := regexp.MustCompile(`^` + regexp.QuoteMeta(a.source.Name()) + `_ibfk_(\d+)$`)
+	var n, unnamed int
+	for _, idx := range a.indexes {
+		if !idx.IsForeignKey() {
+			continue
+		}
+		if !idx.HasSymbol() {
+			unnamed++
+			continue
+		}
+		if m := re.FindStringSubmatch(idx.Symbol()); m != nil {
+			if v, err := strconv.Atoi(m[1]); err == nil && v > n {
+				n = v
+			}
+		}
+	}
+	if unnamed > n {
+		n = unnamed
+	}
+	return a.source.Name() + "_ibfk_" + strconv.Itoa(n+1)
+}
+
+// nextIndexName returns the name that MySQL gives to an index that is
+// added without a name: the name of its first column, or
+// "functional_index" if it is an expression, followed by _2, _3 and so
+// on if the name is already taken
+func (a *alteration) nextIndexName(idx Index) string {
+	base := "functional_index"
+	for col := range idx.Columns() {
+		if !col.IsExpression() {
+			base = col.Name()
+		}
+		break
+	}
+
+	name := base
+	for i := 2; a.lookupIndex(name) >= 0; i++ {
+		name = base + "_" + strconv.Itoa(i)
+	}
+	return name
+}
+
+// build creates the altered table. The indexes and the check constraints
+// are copied so that they belong to the new table, which matters if the
+// table was renamed
+func (a *alteration) build() Table {
+	tbl := NewTable(a.name)
+	tbl.SetSchema(a.schema)
+	tbl.SetIfNotExists(a.source.IsIfNotExists())
+	tbl.SetTemporary(a.source.IsTemporary())
+
+	for _, col := range a.columns {
+		tbl.AddColumn(col)
+	}
+
+	for _, idx := range a.indexes {
+		if v, ok := idx.Clone().(*index); ok {
+			v.table = tbl.ID()
+			idx = v
+		}
+		tbl.AddIndex(idx)
+	}
+
+	for _, check := range a.checks {
+		if v, ok := check.Clone().(*checkConstraint); ok {
+			v.table = tbl.ID()
+			check = v
+		}
+		tbl.AddCheckConstraint(check)
+	}
+
+	for _, opt := range a.options {
+		tbl.AddOption(opt)
+	}
+
+	if a.source.HasPartitioning() {
+		tbl.SetPartitioning(a.source.Partitioning())
+	}
+	tbl.SetSystemVersioned(a.source.IsSystemVersioned())
+
+	tbl, _ = tbl.Normalize()
+	return tbl
+}
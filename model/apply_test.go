@@ -0,0 +1,160 @@
+package model_test
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/schemalex/schemalex"
+	"github.com/schemalex/schemalex/format"
+	"github.com/schemalex/schemalex/model"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestApply(t *testing.T) {
+	type testCase struct {
+		name   string
+		before string
+		alter  string
+		expect string
+		error  bool
+	}
+
+	for _, tc := range []testCase{
+		{
+			name:   "AddColumn",
+			before: "CREATE TABLE hoge (id INT NOT NULL, name TEXT)",
+			alter:  "ALTER TABLE hoge ADD COLUMN age INT NOT NULL AFTER id, ADD created DATETIME, ADD COLUMN pk INT FIRST",
+			expect: "CREATE TABLE `hoge` (\n`pk` INT (11) DEFAULT NULL,\n`id` INT (11) NOT NULL,\n`age` INT (11) NOT NULL,\n`name` TEXT,\n`created` DATETIME DEFAULT NULL\n)",
+		},
+		{
+			name:   "AddDuplicateColumn",
+			before: "CREATE TABLE hoge (id INT NOT NULL)",
+			alter:  "ALTER TABLE hoge ADD COLUMN id INT",
+			error:  true,
+		},
+		{
+			name:   "DropColumn",
+			before: "CREATE TABLE hoge (id INT NOT NULL, a INT NOT NULL, b INT NOT NULL, INDEX idx_a (a), INDEX idx_ab (a, b))",
+			alter:  "ALTER TABLE hoge DROP COLUMN a",
+			expect: "CREATE TABLE `hoge` (\n`id` INT (11) NOT NULL,\n`b` INT (11) NOT NULL,\nINDEX `idx_ab` (`b`)\n)",
+		},
+		{
+			name:   "DropMissingColumn",
+			before: "CREATE TABLE hoge (id INT NOT NULL)",
+			alter:  "ALTER TABLE hoge DROP COLUMN a",
+			error:  true,
+		},
+		{
+			name:   "ModifyAndChangeColumn",
+			before: "CREATE TABLE hoge (id INT NOT NULL, a INT NOT NULL, b INT NOT NULL, INDEX idx_a (a))",
+			alter:  "ALTER TABLE hoge MODIFY b BIGINT NOT NULL FIRST, CHANGE COLUMN a c VARCHAR(10) NOT NULL",
+			expect: "CREATE TABLE `hoge` (\n`b` BIGINT (20) NOT NULL,\n`id` INT (11) NOT NULL,\n`c` VARCHAR (10) NOT NULL,\nINDEX `idx_a` (`c`)\n)",
+		},
+		{
+			name:   "RenameColumn",
+			before: "CREATE TABLE hoge (id INT NOT NULL, a INT NOT NULL, PRIMARY KEY (id, a))",
+			alter:  "ALTER TABLE hoge RENAME COLUMN a TO b",
+			expect: "CREATE TABLE `hoge` (\n`id` INT (11) NOT NULL,\n`b` INT (11) NOT NULL,\nPRIMARY KEY (`id`, `b`)\n)",
+		},
+		{
+			name:   "Indexes",
+			before: "CREATE TABLE hoge (id INT NOT NULL, a INT NOT NULL, PRIMARY KEY (id), INDEX idx_a (a), INDEX idx_b (a))",
+			alter:  "ALTER TABLE hoge DROP PRIMARY KEY, ADD PRIMARY KEY (id, a), DROP INDEX idx_a, RENAME INDEX idx_b TO idx_c, ADD UNIQUE KEY uniq_a (a)",
+			expect: "CREATE TABLE `hoge` (\n`id` INT (11) NOT NULL,\n`a` INT (11) NOT NULL,\nINDEX `idx_c` (`a`),\nPRIMARY KEY (`id`, `a`),\nUNIQUE INDEX `uniq_a` (`a`)\n)",
+		},
+		{
+			name:   "DuplicatePrimaryKey",
+			before: "CREATE TABLE hoge (id INT NOT NULL PRIMARY KEY)",
+			alter:  "ALTER TABLE hoge ADD PRIMARY KEY (id)",
+			error:  true,
+		},
+		{
+			name:   "ForeignKeys",
+			before: "CREATE TABLE hoge (id INT NOT NULL, fuga_id INT NOT NULL, CONSTRAINT fk_fuga FOREIGN KEY (fuga_id) REFERENCES fuga (id))",
+			alter:  "ALTER TABLE hoge DROP FOREIGN KEY fk_fuga, ADD CONSTRAINT fk_piyo FOREIGN KEY (id) REFERENCES piyo (id)",
+			expect: "CREATE TABLE `hoge` (\n`id` INT (11) NOT NULL,\n`fuga_id` INT (11) NOT NULL,\nINDEX `fk_fuga` (`fuga_id`),\nINDEX `fk_piyo` (`id`),\nCONSTRAINT `fk_piyo` FOREIGN KEY (`id`) REFERENCES `piyo` (`id`)\n)",
+		},
+		{
+			name:   "UnnamedIndexes",
+			before: "CREATE TABLE hoge (id INT NOT NULL, a INT NOT NULL)",
+			alter:  "ALTER TABLE hoge ADD INDEX (a), ADD UNIQUE (a, id), DROP INDEX a",
+			expect: "CREATE TABLE `hoge` (\n`id` INT (11) NOT NULL,\n`a` INT (11) NOT NULL,\nUNIQUE INDEX `a_2` (`a`, `id`)\n)",
+		},
+		{
+			name:   "UnnamedForeignKeys",
+			before: "CREATE TABLE hoge (id INT NOT NULL, pid INT NOT NULL, CONSTRAINT hoge_ibfk_1 FOREIGN KEY (id) REFERENCES fuga (id))",
+			alter:  "ALTER TABLE hoge ADD FOREIGN KEY (pid) REFERENCES piyo (id), DROP FOREIGN KEY hoge_ibfk_2",
+			expect: "CREATE TABLE `hoge` (\n`id` INT (11) NOT NULL,\n`pid` INT (11) NOT NULL,\nINDEX `hoge_ibfk_1` (`id`),\nCONSTRAINT `hoge_ibfk_1` FOREIGN KEY (`id`) REFERENCES `fuga` (`id`)\n)",
+		},
+		{
+			name:   "CheckConstraints",
+			before: "CREATE TABLE hoge (id INT NOT NULL, CHECK (id > 0), CONSTRAINT chk_id CHECK (id < 100))",
+			alter:  "ALTER TABLE hoge DROP CHECK chk_id, ADD CHECK (id <> 10)",
+			expect: "CREATE TABLE `hoge` (\n`id` INT (11) NOT NULL,\nCONSTRAINT `hoge_chk_1` CHECK (id > 0),\nCONSTRAINT `hoge_chk_2` CHECK (id <> 10)\n)",
+		},
+		{
+			name:   "TableOptions",
+			before: "CREATE TABLE hoge (id INT NOT NULL) ENGINE=InnoDB, COMMENT='hoge'",
+			alter:  "ALTER TABLE hoge COMMENT 'fuga' DEFAULT CHARSET utf8mb4, ALGORITHM=INPLACE, LOCK=NONE",
+			expect: "CREATE TABLE `hoge` (\n`id` INT (11) NOT NULL\n) ENGINE = InnoDB, COMMENT = 'fuga', DEFAULT CHARACTER SET = utf8mb4",
+		},
+		{
+			name:   "RenameTable",
+			before: "CREATE TABLE hoge (id INT NOT NULL, PRIMARY KEY (id), CHECK (id > 0))",
+			alter:  "ALTER TABLE hoge RENAME TO app.fuga",
+			expect: "CREATE TABLE `app`.`fuga` (\n`id` INT (11) NOT NULL,\nPRIMARY KEY (`id`),\nCONSTRAINT `hoge_chk_1` CHECK (id > 0)\n)",
+		},
+		{
+			name:   "RenameToExistingTable",
+			before: "CREATE TABLE hoge (id INT NOT NULL); CREATE TABLE fuga (id INT NOT NULL)",
+			alter:  "ALTER TABLE hoge RENAME fuga",
+			error:  true,
+		},
+		{
+			name:   "MissingTable",
+			before: "CREATE TABLE hoge (id INT NOT NULL)",
+			alter:  "ALTER TABLE fuga ADD COLUMN a INT",
+			error:  true,
+		},
+	} {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			p := schemalex.New()
+			stmts, err := p.ParseString(tc.before)
+			if !assert.NoError(t, err, "parse should succeed") {
+				return
+			}
+			alters, err := p.ParseString(tc.alter)
+			if !assert.NoError(t, err, "parse should succeed") {
+				return
+			}
+
+			var original bytes.Buffer
+			if !assert.NoError(t, format.SQL(&original, stmts), "format should succeed") {
+				return
+			}
+
+			altered, err := model.Apply(stmts, alters[0].(model.AlterTable))
+			if tc.error {
+				assert.Error(t, err, "apply should fail")
+				return
+			}
+			if !assert.NoError(t, err, "apply should succeed") {
+				return
+			}
+
+			var buf bytes.Buffer
+			if !assert.NoError(t, format.SQL(&buf, altered), "format should succeed") {
+				return
+			}
+			assert.Equal(t, tc.expect, buf.String())
+
+			// the original statements are left as they were
+			buf.Reset()
+			if !assert.NoError(t, format.SQL(&buf, stmts), "format should succeed") {
+				return
+			}
+			assert.Equal(t, original.String(), buf.String())
+		})
+	}
+}
@@ -0,0 +1,67 @@
+package model
+
+import (
+	"crypto/sha256"
+	"fmt"
+
+	"github.com/schemalex/schemalex/internal/util"
+)
+
+// NewCheckConstraint creates a new, enforced check constraint
+// belonging to the given table.
+func NewCheckConstraint(table string) CheckConstraint {
+	return &checkConstraint{
+		table: table,
+	}
+}
+
+func (c *checkConstraint) ID() string {
+	// Much like indexes, check constraints may not have a name, so
+	// we include the expression in the ID as well. The expression is
+	// normalized, so that it matches the one shown by the server
+	name := "check"
+	if c.HasName() {
+		name = name + "#" + c.Name()
+	}
+	h := sha256.New()
+	fmt.Fprintf(h, "%s.%s.%t", c.table, util.NormalizeExpression(c.expression), c.notEnforced)
+	return fmt.Sprintf("%s#%x", name, h.Sum(nil))
+}
+
+func (c *checkConstraint) HasName() bool {
+	return c.name.Valid
+}
+
+func (c *checkConstraint) Name() string {
+	return c.name.Value
+}
+
+func (c *checkConstraint) SetName(s string) CheckConstraint {
+	c.name.Valid = true
+	c.name.Value = s
+	return c
+}
+
+func (c *checkConstraint) Expression() string {
+	return c.expression
+}
+
+func (c *checkConstraint) SetExpression(s string) CheckConstraint {
+	c.expression = s
+	return c
+}
+
+func (c *checkConstraint) IsEnforced() bool {
+	return !c.notEnforced
+}
+
+func (c *checkConstraint) SetEnforced(v bool) CheckConstraint {
+	c.notEnforced = !v
+	return c
+}
+
+func (c *checkConstraint) Clone() CheckConstraint {
+	newcheck := &checkConstraint{}
+	*newcheck = *c
+	return newcheck
+}
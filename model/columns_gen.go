@@ -43,6 +43,9 @@ const (
 	ColumnTypeBool
 	ColumnTypeJSON
 	ColumnTypeGEOMETRY
+	ColumnTypeUUID
+	ColumnTypeINET6
+	ColumnTypeINET4
 
 	ColumnTypeMax
 )
@@ -119,6 +122,12 @@ func (c ColumnType) String() string {
 		return "JSON"
 	case ColumnTypeGEOMETRY:
 		return "GEOMETRY"
+	case ColumnTypeUUID:
+		return "UUID"
+	case ColumnTypeINET6:
+		return "INET6"
+	case ColumnTypeINET4:
+		return "INET4"
 	default:
 		return "(invalid)"
 	}
@@ -27,3 +27,45 @@ func (d *database) SetIfNotExists(v bool) Database {
 	d.ifnotexists = v
 	return d
 }
+
+func (d *database) HasCharacterSet() bool {
+	return d.characterSet.Valid
+}
+
+func (d *database) CharacterSet() string {
+	return d.characterSet.Value
+}
+
+func (d *database) SetCharacterSet(s string) Database {
+	d.characterSet.Valid = true
+	d.characterSet.Value = s
+	return d
+}
+
+func (d *database) HasCollation() bool {
+	return d.collation.Valid
+}
+
+func (d *database) Collation() string {
+	return d.collation.Value
+}
+
+func (d *database) SetCollation(s string) Database {
+	d.collation.Valid = true
+	d.collation.Value = s
+	return d
+}
+
+func (d *database) HasEncryption() bool {
+	return d.encryption.Valid
+}
+
+func (d *database) Encryption() string {
+	return d.encryption.Value
+}
+
+func (d *database) SetEncryption(s string) Database {
+	d.encryption.Valid = true
+	d.encryption.Value = s
+	return d
+}
@@ -0,0 +1,150 @@
+package model
+
+// NewEvent creates a new event with the given name
+func NewEvent(name string) Event {
+	return &event{
+		name: name,
+	}
+}
+
+func (e *event) ID() string {
+	if e.schema != "" {
+		return "event#" + e.schema + "." + e.name
+	}
+	return "event#" + e.name
+}
+
+func (e *event) Name() string {
+	return e.name
+}
+
+func (e *event) Schema() string {
+	return e.schema
+}
+
+func (e *event) SetSchema(s string) Event {
+	e.schema = s
+	return e
+}
+
+func (e *event) HasDefiner() bool {
+	return e.definer.Valid
+}
+
+func (e *event) Definer() string {
+	return e.definer.Value
+}
+
+func (e *event) SetDefiner(s string) Event {
+	e.definer.Valid = true
+	e.definer.Value = s
+	return e
+}
+
+func (e *event) HasAt() bool {
+	return e.at.Valid
+}
+
+func (e *event) At() string {
+	return e.at.Value
+}
+
+func (e *event) SetAt(s string) Event {
+	e.at.Valid = true
+	e.at.Value = s
+	return e
+}
+
+func (e *event) HasEvery() bool {
+	return e.every.Valid
+}
+
+func (e *event) Every() string {
+	return e.every.Value
+}
+
+func (e *event) SetEvery(s string) Event {
+	e.every.Valid = true
+	e.every.Value = s
+	return e
+}
+
+func (e *event) HasStarts() bool {
+	return e.starts.Valid
+}
+
+func (e *event) Starts() string {
+	return e.starts.Value
+}
+
+func (e *event) SetStarts(s string) Event {
+	e.starts.Valid = true
+	e.starts.Value = s
+	return e
+}
+
+func (e *event) HasEnds() bool {
+	return e.ends.Valid
+}
+
+func (e *event) Ends() string {
+	return e.ends.Value
+}
+
+func (e *event) SetEnds(s string) Event {
+	e.ends.Valid = true
+	e.ends.Value = s
+	return e
+}
+
+func (e *event) IsPreserve() bool {
+	return e.preserve
+}
+
+func (e *event) SetPreserve(b bool) Event {
+	e.preserve = b
+	return e
+}
+
+func (e *event) HasStatus() bool {
+	return e.status.Valid
+}
+
+func (e *event) Status() string {
+	return e.status.Value
+}
+
+func (e *event) SetStatus(s string) Event {
+	e.status.Valid = true
+	e.status.Value = s
+	return e
+}
+
+func (e *event) HasComment() bool {
+	return e.comment.Valid
+}
+
+func (e *event) Comment() string {
+	return e.comment.Value
+}
+
+func (e *event) SetComment(s string) Event {
+	e.comment.Valid = true
+	e.comment.Value = s
+	return e
+}
+
+func (e *event) Body() string {
+	return e.body
+}
+
+func (e *event) SetBody(s string) Event {
+	e.body = s
+	return e
+}
+
+func (e *event) Clone() Event {
+	ne := &event{}
+	*ne = *e
+	return ne
+}
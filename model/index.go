@@ -46,6 +46,9 @@ func (stmt *index) ID() string {
 		fmt.Fprintf(h, ".")
 		fmt.Fprintf(h, stmt.reference.ID())
 	}
+	if stmt.invisible {
+		fmt.Fprintf(h, ".invisible")
+	}
 	return fmt.Sprintf("%s#%x", name, h.Sum(nil))
 }
 
@@ -154,6 +157,15 @@ func (stmt *index) Options() chan IndexOption {
 	return ch
 }
 
+func (stmt *index) IsInvisible() bool {
+	return stmt.invisible
+}
+
+func (stmt *index) SetInvisible(v bool) Index {
+	stmt.invisible = v
+	return stmt
+}
+
 func (stmt *index) Normalize() (Index, bool) {
 	return stmt, false
 }
@@ -170,7 +182,18 @@ func NewIndexColumn(name string) IndexColumn {
 	}
 }
 
+// NewIndexExpression creates a new functional key part, which indexes
+// the value of the expression
+func NewIndexExpression(expr string) IndexColumn {
+	return &indexColumn{
+		expression: maybeString{Valid: true, Value: expr},
+	}
+}
+
 func (col *indexColumn) ID() string {
+	if col.IsExpression() {
+		return "index_column#(" + col.Expression() + ")"
+	}
 	if col.HasLength() {
 		return "index_column#" + col.Name() + "-" + col.Length()
 	}
@@ -181,6 +204,14 @@ func (col *indexColumn) Name() string {
 	return col.name
 }
 
+func (col *indexColumn) IsExpression() bool {
+	return col.expression.Valid
+}
+
+func (col *indexColumn) Expression() string {
+	return col.expression.Value
+}
+
 func (col *indexColumn) HasLength() bool {
 	return col.length.Valid
 }
@@ -39,6 +39,12 @@ const (
 type IndexColumn interface {
 	ID() string
 	Name() string
+
+	// IsExpression returns true if the key part is an expression (i.e. a
+	// functional key part) instead of a column
+	IsExpression() bool
+	Expression() string
+
 	SetLength(string) IndexColumn
 	HasLength() bool
 	Length() string
@@ -74,6 +80,10 @@ type Index interface {
 	AddOption(IndexOption) Index
 	Options() chan IndexOption
 
+	// IsInvisible returns true if the index is not used by the optimizer
+	IsInvisible() bool
+	SetInvisible(bool) Index
+
 	// Normalize returns normalized index. If a normalization was performed
 	// and the index is modified, returns a new instance of the Table object
 	// along with a true value as the second return value.
@@ -113,6 +123,7 @@ const (
 // name or name(length)
 type indexColumn struct {
 	name          string
+	expression    maybeString
 	length        maybeString
 	sortDirection IndexColumnSortDirection
 }
@@ -134,6 +145,7 @@ type index struct {
 	columns   []IndexColumn
 	reference Reference
 	options   []IndexOption
+	invisible bool
 }
 
 type indexopt struct {
@@ -142,6 +154,128 @@ type indexopt struct {
 	needQuotes bool
 }
 
+// CheckConstraint describes a CHECK constraint on a table, such as
+// `CONSTRAINT chk_positive CHECK (amount > 0) NOT ENFORCED`
+type CheckConstraint interface {
+	Stmt
+
+	HasName() bool
+	Name() string
+	SetName(string) CheckConstraint
+
+	// Expression returns the expression text as written between the
+	// parenthesis following CHECK
+	Expression() string
+	SetExpression(string) CheckConstraint
+
+	IsEnforced() bool
+	SetEnforced(bool) CheckConstraint
+
+	// Clone returns the cloned check constraint
+	Clone() CheckConstraint
+}
+
+type checkConstraint struct {
+	table       string
+	name        maybeString
+	expression  string
+	notEnforced bool
+}
+
+// PartitionType describes the partitioning method of a table
+type PartitionType int
+
+// List of possible PartitionType values
+const (
+	PartitionTypeNone PartitionType = iota
+	PartitionTypeRange
+	PartitionTypeList
+	PartitionTypeHash
+	PartitionTypeKey
+)
+
+// Partitioning describes the `PARTITION BY` clause of a table. The
+// `SUBPARTITION BY` clause is described by a Partitioning as well, which
+// can be retrieved via SubPartitioning()
+type Partitioning interface {
+	// ID identifies the partitioning scheme, excluding the list of
+	// partition definitions and the number of partitions. Two
+	// partitionings with the same ID can be converted into each other
+	// by adding, dropping or reorganizing partitions
+	Stmt
+
+	Type() PartitionType
+	SetType(PartitionType) Partitioning
+	IsLinear() bool
+	SetLinear(bool) Partitioning
+	// IsColumns returns true for RANGE COLUMNS and LIST COLUMNS
+	IsColumns() bool
+	SetColumns(bool) Partitioning
+
+	// Expression returns the expression or the list of columns as
+	// written between the parenthesis following the partitioning type
+	Expression() string
+	SetExpression(string) Partitioning
+
+	// Algorithm is only applicable to KEY partitioning
+	HasAlgorithm() bool
+	Algorithm() string
+	SetAlgorithm(string) Partitioning
+
+	HasPartitionCount() bool
+	PartitionCount() string
+	SetPartitionCount(string) Partitioning
+
+	HasSubPartitioning() bool
+	SubPartitioning() Partitioning
+	SetSubPartitioning(Partitioning) Partitioning
+
+	AddDefinition(PartitionDefinition) Partitioning
+	Definitions() chan PartitionDefinition
+	LookupDefinition(string) (PartitionDefinition, bool)
+}
+
+type partitioning struct {
+	typ             PartitionType
+	linear          bool
+	columns         bool
+	expression      string
+	algorithm       maybeString
+	partitionCount  maybeString
+	subpartitioning Partitioning
+	definitions     []PartitionDefinition
+}
+
+// PartitionDefinition describes a single partition (or a subpartition)
+// in the partitioning of a table, such as
+// `PARTITION p0 VALUES LESS THAN (10) ENGINE = InnoDB`
+type PartitionDefinition interface {
+	Stmt
+
+	Name() string
+
+	// Values returns the text following VALUES, such as
+	// `LESS THAN (10)`, `LESS THAN MAXVALUE` or `IN (1, 2, 3)`
+	HasValues() bool
+	Values() string
+	SetValues(string) PartitionDefinition
+
+	// Partitions accept the same set of options as tables do,
+	// e.g. `ENGINE = InnoDB`
+	AddOption(TableOption) PartitionDefinition
+	Options() chan TableOption
+
+	AddSubPartition(PartitionDefinition) PartitionDefinition
+	SubPartitions() chan PartitionDefinition
+}
+
+type partitionDefinition struct {
+	name          string
+	values        maybeString
+	options       []TableOption
+	subpartitions []PartitionDefinition
+}
+
 // Reference describes a possible reference from one table to another
 type Reference interface {
 	ColumnContainer
@@ -149,9 +283,14 @@ type Reference interface {
 	ID() string
 	String() string
 	TableName() string
+	// Schema returns the name of the database that the referenced table
+	// belongs to, or an empty string if it is the same database as the
+	// table that holds the reference
+	Schema() string
 	OnDelete() ReferenceOption
 	OnUpdate() ReferenceOption
 	SetTableName(string) Reference
+	SetSchema(string) Reference
 	SetMatch(ReferenceMatch) Reference
 	SetOnDelete(ReferenceOption) Reference
 	SetOnUpdate(ReferenceOption) Reference
@@ -162,6 +301,7 @@ type Reference interface {
 
 type reference struct {
 	tableName string
+	schema    string
 	columns   []IndexColumn
 	match     ReferenceMatch
 	onDelete  ReferenceOption
@@ -197,6 +337,15 @@ type Table interface {
 	Stmt
 
 	Name() string
+
+	// Schema returns the name of the database that the table belongs
+	// to, or an empty string if the table name is not qualified and
+	// no database was selected by USE. As the IDs of the columns and
+	// the indexes are derived from the ID of the table, SetSchema must
+	// be called before they are added
+	Schema() string
+	SetSchema(string) Table
+
 	IsTemporary() bool
 	SetTemporary(bool) Table
 	IsIfNotExists() bool
@@ -222,6 +371,19 @@ type Table interface {
 
 	LookupIndex(string) (Index, bool)
 
+	AddCheckConstraint(CheckConstraint) Table
+	CheckConstraints() chan CheckConstraint
+	LookupCheckConstraint(string) (CheckConstraint, bool)
+
+	HasPartitioning() bool
+	Partitioning() Partitioning
+	SetPartitioning(Partitioning) Table
+
+	// IsSystemVersioned returns true if the table keeps the history of
+	// its rows, i.e. `WITH SYSTEM VERSIONING` was specified (MariaDB)
+	IsSystemVersioned() bool
+	SetSystemVersioned(bool) Table
+
 	// Normalize returns normalized table. If a normalization was performed
 	// and the table is modified, returns a new instance of the Table object
 	// along with a true value as the second return value.
@@ -241,13 +403,17 @@ type TableOption interface {
 type table struct {
 	mu                sync.RWMutex
 	name              string
+	schema            string
 	temporary         bool
 	ifnotexists       bool
 	likeTable         maybeString
 	columns           []TableColumn
 	columnNameToIndex map[string]int
 	indexes           []Index
+	checks            []CheckConstraint
 	options           []TableOption
+	partitioning      Partitioning
+	systemVersioned   bool
 }
 
 type tableopt struct {
@@ -276,6 +442,19 @@ type Length interface {
 	Length() string
 }
 
+// GenerationStorage describes how the values of a generated column
+// are stored
+type GenerationStorage int
+
+// List of possible GenerationStorage values. GenerationStorageNone
+// specifies that the storage was not explicitly declared, which MySQL
+// treats as VIRTUAL
+const (
+	GenerationStorageNone GenerationStorage = iota
+	GenerationStorageVirtual
+	GenerationStorageStored
+)
+
 type length struct {
 	decimals maybeString
 	length   string
@@ -290,6 +469,7 @@ type TableColumn interface {
 	SetTableID(string) TableColumn
 
 	Name() string
+	SetName(string) TableColumn
 	Type() ColumnType
 	SetType(ColumnType) TableColumn
 
@@ -319,6 +499,14 @@ type TableColumn interface {
 	SetSetValues([]string) TableColumn
 	SetValues() chan string
 
+	// IsGenerated returns true if the column is a generated column,
+	// i.e. `[GENERATED ALWAYS] AS (expr)` was specified
+	IsGenerated() bool
+	GenerationExpression() string
+	SetGenerationExpression(string) TableColumn
+	GenerationStorage() GenerationStorage
+	SetGenerationStorage(GenerationStorage) TableColumn
+
 	NullState() NullState
 	SetNullState(NullState) TableColumn
 
@@ -337,6 +525,17 @@ type TableColumn interface {
 	IsZeroFill() bool
 	SetZeroFill(bool) TableColumn
 
+	// IsInvisible returns true if the column is hidden from
+	// `SELECT *` queries
+	IsInvisible() bool
+	SetInvisible(bool) TableColumn
+
+	// HasCheckExpression returns true if the column has a CHECK
+	// constraint in its definition, as MariaDB writes them
+	HasCheckExpression() bool
+	CheckExpression() string
+	SetCheckExpression(string) TableColumn
+
 	// NativeLength returns the "native" size of a column type. This is the length used if you do not explicitly specify it.
 	// Currently only supports numeric types, but may change later.
 	NativeLength() Length
@@ -374,6 +573,9 @@ type tablecol struct {
 	autoUpdate   maybeString
 	enumValues   []string
 	setValues    []string
+	generation   maybeString
+	storage      GenerationStorage
+	check        maybeString
 	autoincr     bool
 	binary       bool
 	key          bool
@@ -382,6 +584,270 @@ type tablecol struct {
 	unsigned     bool
 	fulltext     bool
 	zerofill     bool
+	invisible    bool
+}
+
+// View describes a view. The SELECT statement that defines the view is
+// kept as text, with the whitespace normalized
+type View interface {
+	Stmt
+
+	Name() string
+
+	// Schema returns the name of the database that the view belongs
+	// to, in the same way as Table.Schema
+	Schema() string
+	SetSchema(string) View
+
+	// IsOrReplace returns true if the view was created using
+	// `CREATE OR REPLACE VIEW`
+	IsOrReplace() bool
+	SetOrReplace(bool) View
+
+	HasAlgorithm() bool
+	Algorithm() string
+	SetAlgorithm(string) View
+	HasDefiner() bool
+	Definer() string
+	SetDefiner(string) View
+	HasSQLSecurity() bool
+	SQLSecurity() string
+	SetSQLSecurity(string) View
+
+	// Columns returns the names of the columns, if they were listed
+	// explicitly after the name of the view
+	Columns() chan string
+	AddColumns(...string) View
+
+	Definition() string
+	SetDefinition(string) View
+
+	// HasCheckOption returns true if `WITH [CASCADED | LOCAL] CHECK OPTION`
+	// was specified. CheckOption returns either "CASCADED" or "LOCAL"
+	HasCheckOption() bool
+	CheckOption() string
+	SetCheckOption(string) View
+
+	// Clone returns the cloned view
+	Clone() View
+}
+
+type view struct {
+	schema      string
+	name        string
+	orReplace   bool
+	algorithm   maybeString
+	definer     maybeString
+	sqlSecurity maybeString
+	columns     []string
+	definition  string
+	checkOption maybeString
+}
+
+// Trigger describes a trigger. The body of the trigger is kept as text,
+// with the whitespace normalized
+type Trigger interface {
+	Stmt
+
+	Name() string
+
+	// Schema returns the name of the database that the trigger belongs
+	// to, in the same way as Table.Schema
+	Schema() string
+	SetSchema(string) Trigger
+
+	HasDefiner() bool
+	Definer() string
+	SetDefiner(string) Trigger
+
+	// Timing returns either "BEFORE" or "AFTER"
+	Timing() string
+	SetTiming(string) Trigger
+
+	// Event returns one of "INSERT", "UPDATE" or "DELETE"
+	Event() string
+	SetEvent(string) Trigger
+
+	// TableName returns the name of the table that the trigger is
+	// associated with
+	TableName() string
+	SetTableName(string) Trigger
+
+	// HasOrder returns true if `FOLLOWS` or `PRECEDES` was specified.
+	// Order returns either of them, and OtherTrigger returns the name of
+	// the trigger that it is ordered relative to
+	HasOrder() bool
+	Order() string
+	OtherTrigger() string
+	SetOrder(order, other string) Trigger
+
+	Body() string
+	SetBody(string) Trigger
+
+	// Clone returns the cloned trigger
+	Clone() Trigger
+}
+
+type trigger struct {
+	schema       string
+	name         string
+	definer      maybeString
+	timing       string
+	event        string
+	table        string
+	order        maybeString
+	otherTrigger string
+	body         string
+}
+
+// Routine describes a stored procedure or a stored function. The data
+// types and the body of the routine are kept as text, with the whitespace
+// normalized
+type Routine interface {
+	Stmt
+
+	Name() string
+
+	// Schema returns the name of the database that the routine belongs
+	// to, in the same way as Table.Schema
+	Schema() string
+	SetSchema(string) Routine
+
+	// IsFunction returns true if the routine is a stored function, and
+	// false if it is a stored procedure
+	IsFunction() bool
+
+	HasDefiner() bool
+	Definer() string
+	SetDefiner(string) Routine
+
+	Parameters() chan RoutineParameter
+	AddParameters(...RoutineParameter) Routine
+
+	// Returns returns the data type of the return value of a function
+	Returns() string
+	SetReturns(string) Routine
+
+	HasComment() bool
+	Comment() string
+	SetComment(string) Routine
+	IsDeterministic() bool
+	SetDeterministic(bool) Routine
+	// HasDataAccess returns true if one of `CONTAINS SQL`, `NO SQL`,
+	// `READS SQL DATA` or `MODIFIES SQL DATA` was specified. DataAccess
+	// returns the specified one
+	HasDataAccess() bool
+	DataAccess() string
+	SetDataAccess(string) Routine
+	HasSQLSecurity() bool
+	SQLSecurity() string
+	SetSQLSecurity(string) Routine
+
+	Body() string
+	SetBody(string) Routine
+
+	// Clone returns the cloned routine
+	Clone() Routine
+}
+
+type routine struct {
+	schema        string
+	name          string
+	function      bool
+	definer       maybeString
+	parameters    []RoutineParameter
+	returns       string
+	comment       maybeString
+	deterministic bool
+	dataAccess    maybeString
+	sqlSecurity   maybeString
+	body          string
+}
+
+// RoutineParameter describes a parameter of a routine
+type RoutineParameter interface {
+	Name() string
+	Type() string
+
+	// HasDirection returns true if one of `IN`, `OUT` or `INOUT` was
+	// specified. Only the parameters of procedures have directions
+	HasDirection() bool
+	Direction() string
+	SetDirection(string) RoutineParameter
+}
+
+type routineParameter struct {
+	name      string
+	typ       string
+	direction maybeString
+}
+
+// Event describes a scheduled event. The expressions in the schedule and
+// the body of the event are kept as text, with the whitespace normalized
+type Event interface {
+	Stmt
+
+	Name() string
+
+	// Schema returns the name of the database that the event belongs
+	// to, in the same way as Table.Schema
+	Schema() string
+	SetSchema(string) Event
+
+	HasDefiner() bool
+	Definer() string
+	SetDefiner(string) Event
+
+	// HasAt returns true if the event is scheduled to run once, at the
+	// time given by At. Otherwise the event is scheduled to run repeatedly,
+	// at the interval given by Every (such as "1 DAY"), between the times
+	// given by Starts and Ends
+	HasAt() bool
+	At() string
+	SetAt(string) Event
+	HasEvery() bool
+	Every() string
+	SetEvery(string) Event
+	HasStarts() bool
+	Starts() string
+	SetStarts(string) Event
+	HasEnds() bool
+	Ends() string
+	SetEnds(string) Event
+
+	// IsPreserve returns true if `ON COMPLETION PRESERVE` was specified
+	IsPreserve() bool
+	SetPreserve(bool) Event
+
+	// HasStatus returns true if one of `ENABLE`, `DISABLE` or
+	// `DISABLE ON SLAVE` was specified. Status returns the specified one
+	HasStatus() bool
+	Status() string
+	SetStatus(string) Event
+
+	HasComment() bool
+	Comment() string
+	SetComment(string) Event
+
+	Body() string
+	SetBody(string) Event
+
+	// Clone returns the cloned event
+	Clone() Event
+}
+
+type event struct {
+	schema   string
+	name     string
+	definer  maybeString
+	at       maybeString
+	every    maybeString
+	starts   maybeString
+	ends     maybeString
+	preserve bool
+	status   maybeString
+	comment  maybeString
+	body     string
 }
 
 // Database represents a database definition
@@ -399,9 +865,190 @@ type Database interface {
 	Name() string
 	IsIfNotExists() bool
 	SetIfNotExists(bool) Database
+
+	HasCharacterSet() bool
+	CharacterSet() string
+	SetCharacterSet(string) Database
+	HasCollation() bool
+	Collation() string
+	SetCollation(string) Database
+
+	// HasEncryption returns true if `ENCRYPTION` was specified. Encryption
+	// returns either "Y" or "N"
+	HasEncryption() bool
+	Encryption() string
+	SetEncryption(string) Database
 }
 
 type database struct {
-	name        string
-	ifnotexists bool
+	name         string
+	ifnotexists  bool
+	characterSet maybeString
+	collation    maybeString
+	encryption   maybeString
+}
+
+// AlterTable describes an `ALTER TABLE` statement. It is not a part of
+// the schema by itself, but is applied to the table that it alters by
+// Apply
+type AlterTable interface {
+	Stmt
+
+	Name() string
+
+	// Schema returns the name of the database that the table belongs
+	// to, in the same way as Table.Schema
+	Schema() string
+	SetSchema(string) AlterTable
+
+	AddSpecs(...AlterTableSpec) AlterTable
+	Specs() chan AlterTableSpec
+}
+
+type alterTable struct {
+	name   string
+	schema string
+	specs  []AlterTableSpec
+}
+
+// AlterTableSpecKind describes the kind of an alter specification
+type AlterTableSpecKind int
+
+// List of possible AlterTableSpecKinds. AlterTableSpecModifyColumn is
+// used for both `MODIFY COLUMN` and `CHANGE COLUMN`, the latter of which
+// may also rename the column. AlterTableSpecDropConstraint drops a check
+// constraint, a foreign key or a unique key by its name, whichever is
+// found. AlterTableSpecRename renames the table
+const (
+	AlterTableSpecInvalid AlterTableSpecKind = iota
+	AlterTableSpecAddColumn
+	AlterTableSpecDropColumn
+	AlterTableSpecModifyColumn
+	AlterTableSpecRenameColumn
+	AlterTableSpecAddIndex
+	AlterTableSpecDropIndex
+	AlterTableSpecDropPrimaryKey
+	AlterTableSpecDropForeignKey
+	AlterTableSpecRenameIndex
+	AlterTableSpecAddCheckConstraint
+	AlterTableSpecDropCheckConstraint
+	AlterTableSpecDropConstraint
+	AlterTableSpecTableOptions
+	AlterTableSpecRename
+)
+
+// AlterTableSpec describes a single alter specification of an
+// `ALTER TABLE` statement, such as `ADD COLUMN` or `DROP INDEX`
+type AlterTableSpec interface {
+	Kind() AlterTableSpecKind
+
+	// Name returns the name of the column, the index, the foreign key
+	// or the check constraint that is dropped, modified or renamed
+	Name() string
+	SetName(string) AlterTableSpec
+
+	// NewName returns the name that the column, the index or the table
+	// is renamed to. NewSchema returns the name of the database that
+	// the table is moved to
+	NewName() string
+	SetNewName(string) AlterTableSpec
+	NewSchema() string
+	SetNewSchema(string) AlterTableSpec
+
+	// Column returns the definition of the column that is added or
+	// modified
+	Column() TableColumn
+	SetColumn(TableColumn) AlterTableSpec
+
+	// IsFirst returns true if `FIRST` was specified. HasAfter returns
+	// true if `AFTER` was specified, and After returns the name of the
+	// column that the column is placed after
+	IsFirst() bool
+	SetFirst(bool) AlterTableSpec
+	HasAfter() bool
+	After() string
+	SetAfter(string) AlterTableSpec
+
+	// Index returns the index or the foreign key that is added
+	Index() Index
+	SetIndex(Index) AlterTableSpec
+
+	// CheckConstraint returns the check constraint that is added
+	CheckConstraint() CheckConstraint
+	SetCheckConstraint(CheckConstraint) AlterTableSpec
+
+	AddOption(TableOption) AlterTableSpec
+	Options() chan TableOption
+}
+
+type alterTableSpec struct {
+	kind      AlterTableSpecKind
+	name      string
+	newName   string
+	newSchema string
+	column    TableColumn
+	first     bool
+	after     maybeString
+	index     Index
+	check     CheckConstraint
+	options   []TableOption
+}
+
+// DropTable describes a `DROP TABLE` statement for a single table. A
+// statement that drops several tables is parsed into one DropTable
+// for each of them
+type DropTable interface {
+	Stmt
+
+	Name() string
+
+	// Schema returns the name of the database that the table belongs
+	// to, in the same way as Table.Schema
+	Schema() string
+	SetSchema(string) DropTable
+
+	IsTemporary() bool
+	SetTemporary(bool) DropTable
+	IsIfExists() bool
+	SetIfExists(bool) DropTable
+}
+
+type dropTable struct {
+	name      string
+	schema    string
+	temporary bool
+	ifexists  bool
+}
+
+// DropObject describes a `DROP` statement for an object other than a
+// table: a view, a trigger, a stored procedure or function, an event or
+// a database. A `DROP VIEW` statement that drops several views is parsed
+// into one DropObject for each of them
+type DropObject interface {
+	Stmt
+
+	// Kind returns the type of the object that is dropped, which is
+	// one of "VIEW", "TRIGGER", "PROCEDURE", "FUNCTION", "EVENT" or
+	// "DATABASE"
+	Kind() string
+	Name() string
+
+	// Schema returns the name of the database that the object belongs
+	// to, in the same way as Table.Schema. It is always empty for
+	// databases
+	Schema() string
+	SetSchema(string) DropObject
+
+	IsIfExists() bool
+	SetIfExists(bool) DropObject
+
+	// Target returns the ID of the object that is dropped
+	Target() string
+}
+
+type dropObject struct {
+	kind     string
+	name     string
+	schema   string
+	ifexists bool
 }
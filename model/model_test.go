@@ -13,4 +13,17 @@ func TestStatement(t *testing.T) {
 	stmts = append(stmts, model.NewTable("test"))
 	stmts = append(stmts, model.NewTableColumn("test"))
 	stmts = append(stmts, model.NewIndex(model.IndexKindPrimaryKey, stmts[1].ID()))
+	stmts = append(stmts, model.NewView("test"))
+	stmts = append(stmts, model.NewTrigger("test"))
+	stmts = append(stmts, model.NewProcedure("test"))
+	stmts = append(stmts, model.NewFunction("test"))
+	stmts = append(stmts, model.NewEvent("test"))
+
+	if _, ok := stmts.Lookup("function#test"); !ok {
+		t.Errorf("function#test should be found")
+	}
+
+	if _, ok := stmts.Lookup("view#test"); !ok {
+		t.Errorf("view#test should be found")
+	}
 }
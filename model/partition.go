@@ -0,0 +1,195 @@
+package model
+
+import (
+	"crypto/sha256"
+	"fmt"
+)
+
+// NewPartitioning creates a new partitioning of the given type
+func NewPartitioning(typ PartitionType) Partitioning {
+	return &partitioning{
+		typ: typ,
+	}
+}
+
+func (p *partitioning) ID() string {
+	h := sha256.New()
+	fmt.Fprintf(h,
+		"%d.%t.%t.%s.%s",
+		p.typ,
+		p.linear,
+		p.columns,
+		p.expression,
+		p.algorithm.Value,
+	)
+	if sub := p.subpartitioning; sub != nil {
+		fmt.Fprintf(h, ".%s.%s", sub.ID(), sub.PartitionCount())
+	}
+	return fmt.Sprintf("partitioning#%x", h.Sum(nil))
+}
+
+func (p *partitioning) Type() PartitionType {
+	return p.typ
+}
+
+func (p *partitioning) SetType(v PartitionType) Partitioning {
+	p.typ = v
+	return p
+}
+
+func (p *partitioning) IsLinear() bool {
+	return p.linear
+}
+
+func (p *partitioning) SetLinear(v bool) Partitioning {
+	p.linear = v
+	return p
+}
+
+func (p *partitioning) IsColumns() bool {
+	return p.columns
+}
+
+func (p *partitioning) SetColumns(v bool) Partitioning {
+	p.columns = v
+	return p
+}
+
+func (p *partitioning) Expression() string {
+	return p.expression
+}
+
+func (p *partitioning) SetExpression(s string) Partitioning {
+	p.expression = s
+	return p
+}
+
+func (p *partitioning) HasAlgorithm() bool {
+	return p.algorithm.Valid
+}
+
+func (p *partitioning) Algorithm() string {
+	return p.algorithm.Value
+}
+
+func (p *partitioning) SetAlgorithm(s string) Partitioning {
+	p.algorithm.Valid = true
+	p.algorithm.Value = s
+	return p
+}
+
+func (p *partitioning) HasPartitionCount() bool {
+	return p.partitionCount.Valid
+}
+
+func (p *partitioning) PartitionCount() string {
+	return p.partitionCount.Value
+}
+
+func (p *partitioning) SetPartitionCount(s string) Partitioning {
+	p.partitionCount.Valid = true
+	p.partitionCount.Value = s
+	return p
+}
+
+func (p *partitioning) HasSubPartitioning() bool {
+	return p.subpartitioning != nil
+}
+
+func (p *partitioning) SubPartitioning() Partitioning {
+	return p.subpartitioning
+}
+
+func (p *partitioning) SetSubPartitioning(v Partitioning) Partitioning {
+	p.subpartitioning = v
+	return p
+}
+
+func (p *partitioning) AddDefinition(v PartitionDefinition) Partitioning {
+	p.definitions = append(p.definitions, v)
+	return p
+}
+
+func (p *partitioning) Definitions() chan PartitionDefinition {
+	ch := make(chan PartitionDefinition, len(p.definitions))
+	for _, def := range p.definitions {
+		ch <- def
+	}
+	close(ch)
+	return ch
+}
+
+func (p *partitioning) LookupDefinition(name string) (PartitionDefinition, bool) {
+	for _, def := range p.definitions {
+		if def.Name() == name {
+			return def, true
+		}
+	}
+	return nil, false
+}
+
+// NewPartitionDefinition creates a new partition definition with the
+// given name. The same type is used for subpartition definitions
+func NewPartitionDefinition(name string) PartitionDefinition {
+	return &partitionDefinition{
+		name: name,
+	}
+}
+
+func (d *partitionDefinition) ID() string {
+	h := sha256.New()
+	fmt.Fprintf(h, "%s", d.values.Value)
+	for _, opt := range d.options {
+		fmt.Fprintf(h, ".%s=%s", opt.Key(), opt.Value())
+	}
+	for _, sub := range d.subpartitions {
+		fmt.Fprintf(h, ".%s", sub.ID())
+	}
+	return fmt.Sprintf("partition#%s#%x", d.name, h.Sum(nil))
+}
+
+func (d *partitionDefinition) Name() string {
+	return d.name
+}
+
+func (d *partitionDefinition) HasValues() bool {
+	return d.values.Valid
+}
+
+func (d *partitionDefinition) Values() string {
+	return d.values.Value
+}
+
+func (d *partitionDefinition) SetValues(s string) PartitionDefinition {
+	d.values.Valid = true
+	d.values.Value = s
+	return d
+}
+
+func (d *partitionDefinition) AddOption(v TableOption) PartitionDefinition {
+	d.options = append(d.options, v)
+	return d
+}
+
+func (d *partitionDefinition) Options() chan TableOption {
+	ch := make(chan TableOption, len(d.options))
+	for _, opt := range d.options {
+		ch <- opt
+	}
+	close(ch)
+	return ch
+}
+
+func (d *partitionDefinition) AddSubPartition(v PartitionDefinition) PartitionDefinition {
+	d.subpartitions = append(d.subpartitions, v)
+	return d
+}
+
+func (d *partitionDefinition) SubPartitions() chan PartitionDefinition {
+	ch := make(chan PartitionDefinition, len(d.subpartitions))
+	for _, sub := range d.subpartitions {
+		ch <- sub
+	}
+	close(ch)
+	return ch
+}
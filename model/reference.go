@@ -17,7 +17,8 @@ func NewReference() Reference {
 func (r *reference) ID() string {
 	h := sha256.New()
 	fmt.Fprintf(h,
-		"%s.%s.%s.%s",
+		"%s.%s.%s.%s.%s",
+		r.schema,
 		r.tableName,
 		r.match,
 		r.onDelete,
@@ -47,6 +48,10 @@ func (r *reference) TableName() string {
 	return r.tableName
 }
 
+func (r *reference) Schema() string {
+	return r.schema
+}
+
 func (r *reference) MatchFull() bool {
 	return r.match == ReferenceMatchFull
 }
@@ -87,11 +92,16 @@ func (r *reference) SetTableName(v string) Reference {
 	return r
 }
 
+func (r *reference) SetSchema(v string) Reference {
+	r.schema = v
+	return r
+}
+
 func (r reference) String() string {
 	var buf bytes.Buffer
 
 	buf.WriteString("REFERENCES ")
-	buf.WriteString(util.Backquote(r.TableName()))
+	buf.WriteString(util.BackquoteQualified(r.Schema(), r.TableName()))
 	buf.WriteString(" (")
 
 	ch := r.Columns()
@@ -0,0 +1,179 @@
+package model
+
+// NewProcedure creates a new stored procedure with the given name
+func NewProcedure(name string) Routine {
+	return &routine{
+		name: name,
+	}
+}
+
+// NewFunction creates a new stored function with the given name
+func NewFunction(name string) Routine {
+	return &routine{
+		name:     name,
+		function: true,
+	}
+}
+
+func (r *routine) ID() string {
+	name := r.name
+	if r.schema != "" {
+		name = r.schema + "." + r.name
+	}
+	if r.function {
+		return "function#" + name
+	}
+	return "procedure#" + name
+}
+
+func (r *routine) Name() string {
+	return r.name
+}
+
+func (r *routine) Schema() string {
+	return r.schema
+}
+
+func (r *routine) SetSchema(s string) Routine {
+	r.schema = s
+	return r
+}
+
+func (r *routine) IsFunction() bool {
+	return r.function
+}
+
+func (r *routine) HasDefiner() bool {
+	return r.definer.Valid
+}
+
+func (r *routine) Definer() string {
+	return r.definer.Value
+}
+
+func (r *routine) SetDefiner(s string) Routine {
+	r.definer.Valid = true
+	r.definer.Value = s
+	return r
+}
+
+func (r *routine) Parameters() chan RoutineParameter {
+	ch := make(chan RoutineParameter, len(r.parameters))
+	for _, param := range r.parameters {
+		ch <- param
+	}
+	close(ch)
+	return ch
+}
+
+func (r *routine) AddParameters(params ...RoutineParameter) Routine {
+	r.parameters = append(r.parameters, params...)
+	return r
+}
+
+func (r *routine) Returns() string {
+	return r.returns
+}
+
+func (r *routine) SetReturns(s string) Routine {
+	r.returns = s
+	return r
+}
+
+func (r *routine) HasComment() bool {
+	return r.comment.Valid
+}
+
+func (r *routine) Comment() string {
+	return r.comment.Value
+}
+
+func (r *routine) SetComment(s string) Routine {
+	r.comment.Valid = true
+	r.comment.Value = s
+	return r
+}
+
+func (r *routine) IsDeterministic() bool {
+	return r.deterministic
+}
+
+func (r *routine) SetDeterministic(b bool) Routine {
+	r.deterministic = b
+	return r
+}
+
+func (r *routine) HasDataAccess() bool {
+	return r.dataAccess.Valid
+}
+
+func (r *routine) DataAccess() string {
+	return r.dataAccess.Value
+}
+
+func (r *routine) SetDataAccess(s string) Routine {
+	r.dataAccess.Valid = true
+	r.dataAccess.Value = s
+	return r
+}
+
+func (r *routine) HasSQLSecurity() bool {
+	return r.sqlSecurity.Valid
+}
+
+func (r *routine) SQLSecurity() string {
+	return r.sqlSecurity.Value
+}
+
+func (r *routine) SetSQLSecurity(s string) Routine {
+	r.sqlSecurity.Valid = true
+	r.sqlSecurity.Value = s
+	return r
+}
+
+func (r *routine) Body() string {
+	return r.body
+}
+
+func (r *routine) SetBody(s string) Routine {
+	r.body = s
+	return r
+}
+
+func (r *routine) Clone() Routine {
+	nr := &routine{}
+	*nr = *r
+	nr.parameters = append([]RoutineParameter(nil), r.parameters...)
+	return nr
+}
+
+// NewRoutineParameter creates a new parameter of a routine with the
+// given name and data type
+func NewRoutineParameter(name, typ string) RoutineParameter {
+	return &routineParameter{
+		name: name,
+		typ:  typ,
+	}
+}
+
+func (p *routineParameter) Name() string {
+	return p.name
+}
+
+func (p *routineParameter) Type() string {
+	return p.typ
+}
+
+func (p *routineParameter) HasDirection() bool {
+	return p.direction.Valid
+}
+
+func (p *routineParameter) Direction() string {
+	return p.direction.Value
+}
+
+func (p *routineParameter) SetDirection(s string) RoutineParameter {
+	p.direction.Valid = true
+	p.direction.Value = s
+	return p
+}
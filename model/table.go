@@ -1,5 +1,7 @@
 package model
 
+import "strconv"
+
 // NewTable create a new table with the given name
 func NewTable(name string) Table {
 	return &table{
@@ -9,6 +11,9 @@ func NewTable(name string) Table {
 }
 
 func (t *table) ID() string {
+	if t.schema != "" {
+		return "table#" + t.schema + "." + t.name
+	}
 	return "table#" + t.name
 }
 
@@ -58,6 +63,15 @@ func (t *table) LookupIndex(id string) (Index, bool) {
 	return nil, false
 }
 
+func (t *table) LookupCheckConstraint(id string) (CheckConstraint, bool) {
+	for check := range t.CheckConstraints() {
+		if check.ID() == id {
+			return check, true
+		}
+	}
+	return nil, false
+}
+
 func (t *table) AddColumn(v TableColumn) Table {
 	t.mu.Lock()
 	defer t.mu.Unlock()
@@ -77,6 +91,11 @@ func (t *table) AddIndex(v Index) Table {
 	return t
 }
 
+func (t *table) AddCheckConstraint(v CheckConstraint) Table {
+	t.checks = append(t.checks, v)
+	return t
+}
+
 func (t *table) AddOption(v TableOption) Table {
 	t.options = append(t.options, v)
 	return t
@@ -86,6 +105,15 @@ func (t *table) Name() string {
 	return t.name
 }
 
+func (t *table) Schema() string {
+	return t.schema
+}
+
+func (t *table) SetSchema(s string) Table {
+	t.schema = s
+	return t
+}
+
 func (t *table) IsIfNotExists() bool {
 	return t.ifnotexists
 }
@@ -136,6 +164,37 @@ func (t *table) Indexes() chan Index {
 	return ch
 }
 
+func (t *table) CheckConstraints() chan CheckConstraint {
+	ch := make(chan CheckConstraint, len(t.checks))
+	for _, check := range t.checks {
+		ch <- check
+	}
+	close(ch)
+	return ch
+}
+
+func (t *table) HasPartitioning() bool {
+	return t.partitioning != nil
+}
+
+func (t *table) Partitioning() Partitioning {
+	return t.partitioning
+}
+
+func (t *table) SetPartitioning(v Partitioning) Table {
+	t.partitioning = v
+	return t
+}
+
+func (t *table) IsSystemVersioned() bool {
+	return t.systemVersioned
+}
+
+func (t *table) SetSystemVersioned(v bool) Table {
+	t.systemVersioned = v
+	return t
+}
+
 func (t *table) Options() chan TableOption {
 	ch := make(chan TableOption, len(t.options))
 	for _, idx := range t.options {
@@ -227,11 +286,26 @@ func (t *table) Normalize() (Table, bool) {
 		seen[nidx.Name()] = struct{}{}
 	}
 
+	// MySQL names check constraints that were declared without a name
+	// as <table>_chk_<n>. Do the same so that we can refer to them later
+	var checks []CheckConstraint
+	var checkCount int
+	for check := range t.CheckConstraints() {
+		if !check.HasName() {
+			clone = true
+			checkCount++
+			check = check.Clone()
+			check.SetName(t.Name() + "_chk_" + strconv.Itoa(checkCount))
+		}
+		checks = append(checks, check)
+	}
+
 	if !clone {
 		return t, false
 	}
 
 	tbl := NewTable(t.Name())
+	tbl.SetSchema(t.Schema())
 	tbl.SetIfNotExists(t.IsIfNotExists())
 	tbl.SetTemporary(t.IsTemporary())
 
@@ -247,9 +321,18 @@ func (t *table) Normalize() (Table, bool) {
 		tbl.AddIndex(idx)
 	}
 
+	for _, check := range checks {
+		tbl.AddCheckConstraint(check)
+	}
+
 	for opt := range t.Options() {
 		tbl.AddOption(opt)
 	}
+
+	if t.HasPartitioning() {
+		tbl.SetPartitioning(t.Partitioning())
+	}
+	tbl.SetSystemVersioned(t.IsSystemVersioned())
 	return tbl, true
 }
 
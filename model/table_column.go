@@ -138,6 +138,11 @@ func (t *tablecol) Name() string {
 	return t.name
 }
 
+func (t *tablecol) SetName(s string) TableColumn {
+	t.name = s
+	return t
+}
+
 func (t *tablecol) NullState() NullState {
 	return t.nullstate
 }
@@ -209,6 +214,29 @@ func (t *tablecol) SetZeroFill(v bool) TableColumn {
 	return t
 }
 
+func (t *tablecol) IsInvisible() bool {
+	return t.invisible
+}
+
+func (t *tablecol) SetInvisible(v bool) TableColumn {
+	t.invisible = v
+	return t
+}
+
+func (t *tablecol) HasCheckExpression() bool {
+	return t.check.Valid
+}
+
+func (t *tablecol) CheckExpression() string {
+	return t.check.Value
+}
+
+func (t *tablecol) SetCheckExpression(s string) TableColumn {
+	t.check.Valid = true
+	t.check.Value = s
+	return t
+}
+
 func (t *tablecol) HasAutoUpdate() bool {
 	return t.autoUpdate.Valid
 }
@@ -259,6 +287,29 @@ func (t *tablecol) SetValues() chan string {
 	return ch
 }
 
+func (t *tablecol) IsGenerated() bool {
+	return t.generation.Valid
+}
+
+func (t *tablecol) GenerationExpression() string {
+	return t.generation.Value
+}
+
+func (t *tablecol) SetGenerationExpression(s string) TableColumn {
+	t.generation.Valid = true
+	t.generation.Value = s
+	return t
+}
+
+func (t *tablecol) GenerationStorage() GenerationStorage {
+	return t.storage
+}
+
+func (t *tablecol) SetGenerationStorage(v GenerationStorage) TableColumn {
+	t.storage = v
+	return t
+}
+
 func (t *tablecol) NativeLength() Length {
 	// I referred to perl: SQL::Translator::Parser::MySQL#normalize_field https://metacpan.org/source/SQL::Translator::Parser::MySQL#L1072
 	unsigned := 0
@@ -300,6 +351,7 @@ func (t *tablecol) Normalize() (TableColumn, bool) {
 	var synonym ColumnType
 	var removeQuotes bool
 	var setDefaultNull bool
+	var setVirtual bool
 
 	if !t.HasLength() {
 		if l := t.NativeLength(); l != nil {
@@ -321,6 +373,12 @@ func (t *tablecol) Normalize() (TableColumn, bool) {
 		nullState = NullStateNone
 	}
 
+	// Generated columns are VIRTUAL unless otherwise specified
+	if t.IsGenerated() && t.GenerationStorage() == GenerationStorageNone {
+		clone = true
+		setVirtual = true
+	}
+
 	if t.HasDefault() {
 		switch t.Type() {
 		case ColumnTypeTinyInt, ColumnTypeSmallInt,
@@ -341,7 +399,8 @@ func (t *tablecol) Normalize() (TableColumn, bool) {
 				t.SetDefault("0", false)
 			}
 		}
-	} else {
+	} else if !t.IsGenerated() {
+		// generated columns can not have a default value
 		switch t.Type() {
 		case ColumnTypeTinyText, ColumnTypeTinyBlob,
 			ColumnTypeBlob, ColumnTypeText,
@@ -378,6 +437,10 @@ func (t *tablecol) Normalize() (TableColumn, bool) {
 	if setDefaultNull {
 		col.SetDefault("NULL", false)
 	}
+
+	if setVirtual {
+		col.SetGenerationStorage(GenerationStorageVirtual)
+	}
 	return col, true
 }
 
@@ -0,0 +1,103 @@
+package model
+
+// NewTrigger creates a new trigger with the given name
+func NewTrigger(name string) Trigger {
+	return &trigger{
+		name: name,
+	}
+}
+
+func (t *trigger) ID() string {
+	if t.schema != "" {
+		return "trigger#" + t.schema + "." + t.name
+	}
+	return "trigger#" + t.name
+}
+
+func (t *trigger) Name() string {
+	return t.name
+}
+
+func (t *trigger) Schema() string {
+	return t.schema
+}
+
+func (t *trigger) SetSchema(s string) Trigger {
+	t.schema = s
+	return t
+}
+
+func (t *trigger) HasDefiner() bool {
+	return t.definer.Valid
+}
+
+func (t *trigger) Definer() string {
+	return t.definer.Value
+}
+
+func (t *trigger) SetDefiner(s string) Trigger {
+	t.definer.Valid = true
+	t.definer.Value = s
+	return t
+}
+
+func (t *trigger) Timing() string {
+	return t.timing
+}
+
+func (t *trigger) SetTiming(s string) Trigger {
+	t.timing = s
+	return t
+}
+
+func (t *trigger) Event() string {
+	return t.event
+}
+
+func (t *trigger) SetEvent(s string) Trigger {
+	t.event = s
+	return t
+}
+
+func (t *trigger) TableName() string {
+	return t.table
+}
+
+func (t *trigger) SetTableName(s string) Trigger {
+	t.table = s
+	return t
+}
+
+func (t *trigger) HasOrder() bool {
+	return t.order.Valid
+}
+
+func (t *trigger) Order() string {
+	return t.order.Value
+}
+
+func (t *trigger) OtherTrigger() string {
+	return t.otherTrigger
+}
+
+func (t *trigger) SetOrder(order, other string) Trigger {
+	t.order.Valid = true
+	t.order.Value = order
+	t.otherTrigger = other
+	return t
+}
+
+func (t *trigger) Body() string {
+	return t.body
+}
+
+func (t *trigger) SetBody(s string) Trigger {
+	t.body = s
+	return t
+}
+
+func (t *trigger) Clone() Trigger {
+	nt := &trigger{}
+	*nt = *t
+	return nt
+}
@@ -0,0 +1,123 @@
+package model
+
+// NewView creates a new view with the given name
+func NewView(name string) View {
+	return &view{
+		name: name,
+	}
+}
+
+func (v *view) ID() string {
+	if v.schema != "" {
+		return "view#" + v.schema + "." + v.name
+	}
+	return "view#" + v.name
+}
+
+func (v *view) Name() string {
+	return v.name
+}
+
+func (v *view) Schema() string {
+	return v.schema
+}
+
+func (v *view) SetSchema(s string) View {
+	v.schema = s
+	return v
+}
+
+func (v *view) IsOrReplace() bool {
+	return v.orReplace
+}
+
+func (v *view) SetOrReplace(b bool) View {
+	v.orReplace = b
+	return v
+}
+
+func (v *view) HasAlgorithm() bool {
+	return v.algorithm.Valid
+}
+
+func (v *view) Algorithm() string {
+	return v.algorithm.Value
+}
+
+func (v *view) SetAlgorithm(s string) View {
+	v.algorithm.Valid = true
+	v.algorithm.Value = s
+	return v
+}
+
+func (v *view) HasDefiner() bool {
+	return v.definer.Valid
+}
+
+func (v *view) Definer() string {
+	return v.definer.Value
+}
+
+func (v *view) SetDefiner(s string) View {
+	v.definer.Valid = true
+	v.definer.Value = s
+	return v
+}
+
+func (v *view) HasSQLSecurity() bool {
+	return v.sqlSecurity.Valid
+}
+
+func (v *view) SQLSecurity() string {
+	return v.sqlSecurity.Value
+}
+
+func (v *view) SetSQLSecurity(s string) View {
+	v.sqlSecurity.Valid = true
+	v.sqlSecurity.Value = s
+	return v
+}
+
+func (v *view) Columns() chan string {
+	ch := make(chan string, len(v.columns))
+	for _, col := range v.columns {
+		ch <- col
+	}
+	close(ch)
+	return ch
+}
+
+func (v *view) AddColumns(names ...string) View {
+	v.columns = append(v.columns, names...)
+	return v
+}
+
+func (v *view) Definition() string {
+	return v.definition
+}
+
+func (v *view) SetDefinition(s string) View {
+	v.definition = s
+	return v
+}
+
+func (v *view) HasCheckOption() bool {
+	return v.checkOption.Valid
+}
+
+func (v *view) CheckOption() string {
+	return v.checkOption.Value
+}
+
+func (v *view) SetCheckOption(s string) View {
+	v.checkOption.Valid = true
+	v.checkOption.Value = s
+	return v
+}
+
+func (v *view) Clone() View {
+	nv := &view{}
+	*nv = *v
+	nv.columns = append([]string(nil), v.columns...)
+	return nv
+}
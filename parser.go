@@ -6,6 +6,7 @@ import (
 	"strings"
 
 	"github.com/schemalex/schemalex/internal/errors"
+	"github.com/schemalex/schemalex/internal/option"
 	"github.com/schemalex/schemalex/model"
 )
 
@@ -35,6 +36,7 @@ const (
 	coloptAutoIncrement = coloptEverythingElse
 	coloptKey           = coloptEverythingElse
 	coloptComment       = coloptEverythingElse
+	coloptGenerated     = coloptEverythingElse
 )
 
 const (
@@ -50,11 +52,44 @@ const (
 )
 
 // Parser is responsible to parse a set of SQL statements
-type Parser struct{}
+type Parser struct {
+	// the server version that the input is meant for, if specified
+	version *ServerVersion
+	err     error
 
-// New creates a new Parser
-func New() *Parser {
-	return &Parser{}
+	// whether DROP TABLE statements are parsed, see WithDropTables
+	dropTables bool
+}
+
+const optkeyDropTables = "drop-tables"
+
+// WithDropTables specifies whether the parser creates a model.DropTable
+// for each of the tables dropped by `DROP TABLE`. By default, they are
+// skipped along with the other DROP statements, as schema files such as
+// the output of mysqldump drop the tables right before creating them
+func WithDropTables(v bool) Option {
+	return option.New(optkeyDropTables, v)
+}
+
+// New creates a new Parser. If WithServerVersion is specified, the
+// parser rejects the features that are not supported by that version,
+// and reserved words that are used as identifiers without quotes
+func New(options ...Option) *Parser {
+	var p Parser
+	version, ok, err := ServerVersionFromOptions(options...)
+	switch {
+	case err != nil:
+		p.err = err
+	case ok:
+		p.version = &version
+	}
+	for _, o := range options {
+		switch o.Name() {
+		case optkeyDropTables:
+			p.dropTables = o.Value().(bool)
+		}
+	}
+	return &p
 }
 
 type parseCtx struct {
@@ -63,6 +98,10 @@ type parseCtx struct {
 	lexsrc     chan *Token
 	peekCount  int
 	peekTokens [3]*Token
+
+	// database is the name of the database selected by the last USE
+	// statement. tables whose names are not qualified belong to it
+	database string
 }
 
 func newParseCtx(ctx context.Context) *parseCtx {
@@ -144,6 +183,10 @@ func (p *Parser) ParseString(src string) (model.Stmts, error) {
 // If it encounters errors while parsing, the returned error will be a
 // ParseError type.
 func (p *Parser) Parse(src []byte) (model.Stmts, error) {
+	if p.err != nil {
+		return nil, p.err
+	}
+
 	cctx, cancel := context.WithCancel(context.TODO())
 	defer cancel()
 
@@ -169,22 +212,47 @@ LOOP:
 				return nil, errors.Wrap(err, `failed to parse create`)
 			}
 			stmts = append(stmts, stmt)
+		case ALTER:
+			stmt, err := p.parseAlterTable(ctx)
+			if err != nil {
+				if pe, ok := err.(ParseError); ok {
+					return nil, pe
+				}
+				return nil, errors.Wrap(err, `failed to parse alter`)
+			}
+			stmts = append(stmts, stmt)
 		case COMMENT_IDENT:
 			ctx.advance()
-		case DROP, SET, USE:
+		case USE:
+			if err := p.parseUse(ctx); err != nil {
+				return nil, err
+			}
+		case DROP:
+			l, err := p.parseDrop(ctx)
+			if err != nil {
+				return nil, err
+			}
+			stmts = append(stmts, l...)
+		case RENAME:
+			l, err := p.parseRenameTable(ctx)
+			if err != nil {
+				return nil, err
+			}
+			stmts = append(stmts, l...)
+		case SET, DELETE, UPDATE:
 			// We don't do anything about these
-		S1:
-			for {
-				switch t := ctx.peek(); t.Type {
-				case SEMICOLON:
-					ctx.advance()
-					fallthrough
-				case EOF:
-					break S1
-				default:
-					ctx.advance()
-				}
+			ctx.skipStatement()
+		case IDENT:
+			// neither do the other statements that modify the data, or
+			// the ones that control transactions, which are often found in
+			// migrations along with the schema changes
+			switch {
+			case isKeyword(t, "INSERT"), isKeyword(t, "REPLACE"), isKeyword(t, "TRUNCATE"):
+			case isKeyword(t, "BEGIN"), isKeyword(t, "START"), isKeyword(t, "COMMIT"), isKeyword(t, "ROLLBACK"):
+			default:
+				return nil, newParseError(ctx, t, "expected CREATE, ALTER, COMMENT_IDENT, SEMICOLON or EOF")
 			}
+			ctx.skipStatement()
 		case SEMICOLON:
 			// you could have statements where it's just empty, followed by a
 			// semicolon. These are just empty lines, so we just skip and go
@@ -195,7 +263,7 @@ LOOP:
 			ctx.advance()
 			break LOOP
 		default:
-			return nil, newParseError(ctx, t, "expected CREATE, COMMENT_IDENT, SEMICOLON or EOF")
+			return nil, newParseError(ctx, t, "expected CREATE, ALTER, COMMENT_IDENT, SEMICOLON or EOF")
 		}
 	}
 
@@ -206,605 +274,2549 @@ func (p *Parser) parseCreate(ctx *parseCtx) (model.Stmt, error) {
 	if t := ctx.next(); t.Type != CREATE {
 		return nil, errors.New(`expected CREATE`)
 	}
-	ctx.skipWhiteSpaces()
-	switch t := ctx.peek(); t.Type {
-	case DATABASE:
-		if _, err := p.parseCreateDatabase(ctx); err != nil {
-			return nil, err
-		}
-		return nil, errors.Ignorable(nil)
-	case TABLE:
-		return p.parseCreateTable(ctx)
-	default:
-		return nil, newParseError(ctx, t, "expected DATABASE or TABLE")
-	}
-}
-
-// https://dev.mysql.com/doc/refman/5.5/en/create-database.html
-// TODO: charset, collation
-func (p *Parser) parseCreateDatabase(ctx *parseCtx) (model.Database, error) {
-	if t := ctx.next(); t.Type != DATABASE {
-		return nil, errors.New(`expected DATABASE`)
-	}
-
 	ctx.skipWhiteSpaces()
 
-	var notexists bool
-	if ctx.peek().Type == IF {
-		ctx.advance()
-		if _, err := p.parseIdents(ctx, NOT, EXISTS); err != nil {
-			return nil, err
-		}
-		notexists = true
+	var opts createOptions
+	if err := p.parseCreateOptions(ctx, &opts); err != nil {
+		return nil, err
 	}
 
-	ctx.skipWhiteSpaces()
-
-	var database model.Database
-	switch t := ctx.next(); t.Type {
-	case IDENT, BACKTICK_IDENT:
-		database = model.NewDatabase(t.Value)
+	switch t := ctx.peek(); {
+	case t.Type == DATABASE && opts.isZero():
+		return p.parseCreateDatabase(ctx)
+	case t.Type == TABLE && opts.isZero():
+		return p.parseCreateTable(ctx)
+	case isKeyword(t, "VIEW"):
+		return p.parseCreateView(ctx, &opts)
+	case isKeyword(t, "TRIGGER") && opts.onlyDefiner():
+		return p.parseCreateTrigger(ctx, &opts)
+	case (isKeyword(t, "PROCEDURE") || isKeyword(t, "FUNCTION")) && opts.onlyDefiner():
+		return p.parseCreateRoutine(ctx, &opts)
+	case isKeyword(t, "EVENT") && opts.onlyDefiner():
+		return p.parseCreateEvent(ctx, &opts)
+	case (t.Type == INDEX || t.Type == UNIQUE || t.Type == FULLTEXT || t.Type == SPATIAL) && opts.isZero():
+		return p.parseCreateIndex(ctx)
 	default:
-		return nil, newParseError(ctx, t, "expected IDENT, BACKTICK_IDENT")
+		return nil, newParseError(ctx, t, "expected DATABASE, TABLE, INDEX, VIEW, TRIGGER, PROCEDURE, FUNCTION or EVENT")
 	}
-
-	database.SetIfNotExists(notexists)
-	p.eol(ctx)
-	return database, nil
 }
 
-// http://dev.mysql.com/doc/refman/5.6/en/create-table.html
-func (p *Parser) parseCreateTable(ctx *parseCtx) (model.Table, error) {
-	if t := ctx.next(); t.Type != TABLE {
-		return nil, errors.New(`expected TABLE`)
+// parseCreateIndex parses
+// `CREATE [UNIQUE | FULLTEXT | SPATIAL] INDEX idx [USING type] ON tbl (key_part, ...)`.
+// As creating an index is the same as altering the table with
+// `ADD INDEX`, an AlterTable is created for the table
+//
+// https://dev.mysql.com/doc/refman/8.0/en/create-index.html
+func (p *Parser) parseCreateIndex(ctx *parseCtx) (model.AlterTable, error) {
+	kind := model.IndexKindNormal
+	switch t := ctx.next(); t.Type {
+	case UNIQUE:
+		kind = model.IndexKindUnique
+	case FULLTEXT:
+		kind = model.IndexKindFullText
+	case SPATIAL:
+		kind = model.IndexKindSpatial
 	}
-
-	var table model.Table
-
-	ctx.skipWhiteSpaces()
-	var temporary bool
-	if t := ctx.peek(); t.Type == TEMPORARY {
-		ctx.advance()
+	if kind != model.IndexKindNormal {
 		ctx.skipWhiteSpaces()
-		temporary = true
-	}
-
-	// IF NOT EXISTS
-	var notexists bool
-	if ctx.peek().Type == IF {
-		ctx.advance()
-		if _, err := p.parseIdents(ctx, NOT, EXISTS); err != nil {
-			return nil, err
+		if t := ctx.next(); t.Type != INDEX {
+			return nil, newParseError(ctx, t, "expected INDEX")
 		}
-		ctx.skipWhiteSpaces()
-		notexists = true
 	}
 
+	ctx.skipWhiteSpaces()
+	var name string
 	switch t := ctx.next(); t.Type {
 	case IDENT, BACKTICK_IDENT:
-		table = model.NewTable(t.Value)
+		if err := p.checkIdent(ctx, t); err != nil {
+			return nil, err
+		}
+		name = t.Value
 	default:
 		return nil, newParseError(ctx, t, "expected IDENT or BACKTICK_IDENT")
 	}
-	table.SetTemporary(temporary)
-	table.SetIfNotExists(notexists)
 
+	// the index type may be given before the table, as well as after the
+	// key parts
+	typ := model.IndexTypeNone
 	ctx.skipWhiteSpaces()
-	switch t := ctx.peek(); t.Type {
-	case LIKE:
-		// CREATE TABLE foo LIKE bar
+	if ctx.peek().Type == USING {
 		ctx.advance()
 		ctx.skipWhiteSpaces()
 		switch t := ctx.next(); t.Type {
-		case IDENT, BACKTICK_IDENT:
-			table.SetLikeTable(t.Value)
+		case BTREE:
+			typ = model.IndexTypeBtree
+		case HASH:
+			typ = model.IndexTypeHash
 		default:
-			return nil, newParseError(ctx, t, "expected table name after LIKE")
-		}
-
-		ctx.skipWhiteSpaces()
-		switch t := ctx.peek(); t.Type {
-		case EOF, SEMICOLON:
-			ctx.advance()
-		}
-		return table, nil
-	case IF:
-		ctx.advance()
-		if _, err := p.parseIdents(ctx, NOT, EXISTS); err != nil {
-			return nil, newParseError(ctx, t, "should NOT EXISTS")
+			return nil, newParseError(ctx, t, "expected BTREE or HASH")
 		}
 		ctx.skipWhiteSpaces()
-		table.SetIfNotExists(true)
 	}
 
-	if t := ctx.next(); t.Type != LPAREN {
-		return nil, newParseError(ctx, t, "expected LPAREN")
+	if t := ctx.next(); t.Type != ON {
+		return nil, newParseError(ctx, t, "expected ON")
+	}
+	alter, err := p.parseAlterTableName(ctx)
+	if err != nil {
+		return nil, err
 	}
 
-	if err := p.parseCreateTableFields(ctx, table); err != nil {
+	index := model.NewIndex(kind, model.NewTable(alter.Name()).SetSchema(alter.Schema()).ID())
+	index.SetName(name)
+	if typ != model.IndexTypeNone {
+		index.SetType(typ)
+	}
+	if err := p.parseColumnIndexColumns(ctx, index); err != nil {
+		return nil, err
+	}
+	if err := p.parseColumnIndexType(ctx, index); err != nil {
 		return nil, err
 	}
+	if err := p.parseColumnIndexVisibility(ctx, index); err != nil {
+		return nil, err
+	}
+	alter.AddSpecs(model.NewAlterTableSpec(model.AlterTableSpecAddIndex).SetIndex(index))
 
-	table, _ = table.Normalize()
-	return table, nil
+	if err := p.parseIndexLockOptions(ctx); err != nil {
+		return nil, err
+	}
+	return alter, nil
 }
 
-// Start parsing after `CREATE TABLE *** (`
-func (p *Parser) parseCreateTableFields(ctx *parseCtx, stmt model.Table) error {
+// parseIndexLockOptions parses the ALGORITHM and LOCK clauses that may end
+// `CREATE INDEX` and `DROP INDEX`, along with the end of the statement.
+// The clauses do not change the table, and are skipped
+func (p *Parser) parseIndexLockOptions(ctx *parseCtx) error {
 	for {
 		ctx.skipWhiteSpaces()
-		switch t := ctx.peek(); t.Type {
-		case CONSTRAINT:
-			if err := p.parseTableConstraint(ctx, stmt); err != nil {
-				return err
-			}
-		case PRIMARY:
-			if err := p.parseTablePrimaryKey(ctx, stmt); err != nil {
-				return err
-			}
-		case UNIQUE:
-			if err := p.parseTableUniqueKey(ctx, stmt); err != nil {
-				return err
-			}
-		case INDEX, KEY:
-			// TODO. separate to KEY and INDEX
-			if err := p.parseTableIndex(ctx, stmt); err != nil {
-				return err
-			}
-		case FULLTEXT:
-			if err := p.parseTableFulltextIndex(ctx, stmt); err != nil {
-				return err
-			}
-		case SPATIAL:
-			if err := p.parseTableSpatialIndex(ctx, stmt); err != nil {
-				return err
-			}
-		case FOREIGN:
-			if err := p.parseTableForeignKey(ctx, stmt); err != nil {
-				return err
+		switch t := ctx.next(); {
+		case t.Type == ALGORITHM, isKeyword(t, "LOCK"):
+			ctx.skipWhiteSpaces()
+			if ctx.peek().Type == EQUAL {
+				ctx.advance()
+				ctx.skipWhiteSpaces()
 			}
-		case CHECK: // TODO
-			return newParseError(ctx, t, "unsupported field: CHECK")
-		case IDENT, BACKTICK_IDENT:
-			if err := p.parseTableColumn(ctx, stmt); err != nil {
-				return err
+			if v := ctx.next(); !isWord(v) {
+				return newParseError(ctx, v, "expected %s value", strings.ToUpper(t.Value))
 			}
+		case t.Type == SEMICOLON, t.Type == EOF:
+			return nil
 		default:
-			return newParseError(ctx, t, "unexpected create table field token: %s", t.Type)
+			return newParseError(ctx, t, "expected ALGORITHM, LOCK, SEMICOLON or EOF")
 		}
+	}
+}
 
-		ctx.skipWhiteSpaces()
-		switch t := ctx.peek(); t.Type {
-		case RPAREN:
+// createOptions holds the clauses that may appear between CREATE and
+// the type of the object that is created, such as
+// `CREATE OR REPLACE ALGORITHM = MERGE DEFINER = CURRENT_USER VIEW ...`
+type createOptions struct {
+	orReplace   bool
+	algorithm   string
+	definer     string
+	sqlSecurity string
+}
+
+func (opts *createOptions) isZero() bool {
+	return *opts == createOptions{}
+}
+
+// onlyDefiner returns true if no options other than DEFINER were given
+func (opts *createOptions) onlyDefiner() bool {
+	return createOptions{definer: opts.definer} == *opts
+}
+
+// isKeyword returns true if the token is the given keyword. This is mostly
+// used for the keywords that are not tokens, as they may also be used as
+// identifiers, in which case the token is an unquoted identifier
+func isKeyword(t *Token, word string) bool {
+	if !strings.EqualFold(t.Value, word) {
+		return false
+	}
+	if typ, ok := keywordIdentMap[strings.ToUpper(word)]; ok {
+		return t.Type == typ
+	}
+	return t.Type == IDENT
+}
+
+// isWord returns true if the token is an unquoted identifier or a keyword
+func isWord(t *Token) bool {
+	if t.Type == IDENT {
+		return true
+	}
+	typ, ok := keywordIdentMap[strings.ToUpper(t.Value)]
+	return ok && t.Type == typ
+}
+
+func (p *Parser) parseCreateOptions(ctx *parseCtx, opts *createOptions) error {
+	for {
+		switch t := ctx.peek(); {
+		case isKeyword(t, "OR"):
 			ctx.advance()
-			if err := p.parseCreateTableOptions(ctx, stmt); err != nil {
+			ctx.skipWhiteSpaces()
+			if t := ctx.next(); !isKeyword(t, "REPLACE") {
+				return newParseError(ctx, t, "expected REPLACE")
+			}
+			opts.orReplace = true
+		case t.Type == ALGORITHM:
+			ctx.advance()
+			v, err := p.parseCreateOptionValue(ctx)
+			if err != nil {
 				return err
 			}
-			// partition option
-			if !p.eol(ctx) {
-				return newParseError(ctx, t, "expected EOL")
+			opts.algorithm = strings.ToUpper(v)
+		case isKeyword(t, "DEFINER"):
+			ctx.advance()
+			v, err := p.parseCreateOptionValue(ctx)
+			if err != nil {
+				return err
 			}
-			return nil
-		case COMMA:
+			opts.definer = v
+		case isKeyword(t, "SQL"):
 			ctx.advance()
-			// Expecting another table field, keep looping
+			ctx.skipWhiteSpaces()
+			if t := ctx.next(); !isKeyword(t, "SECURITY") {
+				return newParseError(ctx, t, "expected SECURITY")
+			}
+			ctx.skipWhiteSpaces()
+			t := ctx.next()
+			if t.Type != IDENT {
+				return newParseError(ctx, t, "expected DEFINER or INVOKER")
+			}
+			opts.sqlSecurity = strings.ToUpper(t.Value)
 		default:
-			return newParseError(ctx, t, "expected RPAREN or COMMA")
+			return nil
 		}
+		ctx.skipWhiteSpaces()
 	}
 }
 
-func (p *Parser) parseTableConstraint(ctx *parseCtx, table model.Table) error {
-	if t := ctx.next(); t.Type != CONSTRAINT {
-		return newParseError(ctx, t, "expected CONSTRAINT")
+// parseCreateOptionValue parses `= value`, and returns the value as it
+// was written in the input. The value ends at the first whitespace, so
+// that definers such as `root`@`localhost` are kept as a whole
+func (p *Parser) parseCreateOptionValue(ctx *parseCtx) (string, error) {
+	ctx.skipWhiteSpaces()
+	if t := ctx.next(); t.Type != EQUAL {
+		return "", newParseError(ctx, t, "expected EQUAL")
 	}
 	ctx.skipWhiteSpaces()
 
-	var sym string
-	switch t := ctx.peek(); t.Type {
-	case IDENT, BACKTICK_IDENT:
-		// TODO: should be smarter
-		// (lestrrat): I don't understand. How?
-		sym = t.Value
+	first := ctx.peek()
+	switch first.Type {
+	case SPACE, COMMENT_IDENT, SEMICOLON, EOF:
+		return "", newParseError(ctx, first, "expected value")
+	}
+	for {
+		switch t := ctx.peek(); t.Type {
+		case SPACE, COMMENT_IDENT, SEMICOLON, EOF:
+			return string(ctx.input[first.Pos:t.Pos]), nil
+		}
 		ctx.advance()
-		ctx.skipWhiteSpaces()
 	}
+}
 
-	var index model.Index
-	switch t := ctx.peek(); t.Type {
-	case PRIMARY:
-		index = model.NewIndex(model.IndexKindPrimaryKey, table.ID())
-		if err := p.parseColumnIndexPrimaryKey(ctx, index); err != nil {
-			return err
+// https://dev.mysql.com/doc/refman/8.0/en/create-view.html
+func (p *Parser) parseCreateView(ctx *parseCtx, opts *createOptions) (model.View, error) {
+	if t := ctx.next(); !isKeyword(t, "VIEW") {
+		return nil, errors.New(`expected VIEW`)
+	}
+
+	var view model.View
+	ctx.skipWhiteSpaces()
+	switch t := ctx.next(); t.Type {
+	case IDENT, BACKTICK_IDENT:
+		if err := p.checkIdent(ctx, t); err != nil {
+			return nil, err
 		}
-	case UNIQUE:
-		index = model.NewIndex(model.IndexKindUnique, table.ID())
-		if err := p.parseColumnIndexUniqueKey(ctx, index); err != nil {
-			return err
+		schema, name, err := p.parseQualifiedName(ctx, t)
+		if err != nil {
+			return nil, err
 		}
-	case FOREIGN:
-		index = model.NewIndex(model.IndexKindForeignKey, table.ID())
-		if err := p.parseColumnIndexForeignKey(ctx, index); err != nil {
-			return err
+		if schema == "" {
+			schema = ctx.database
 		}
+		view = model.NewView(name)
+		view.SetSchema(schema)
 	default:
-		return newParseError(ctx, t, "not supported")
+		return nil, newParseError(ctx, t, "expected IDENT or BACKTICK_IDENT")
 	}
 
-	if len(sym) > 0 {
-		index.SetSymbol(sym)
+	view.SetOrReplace(opts.orReplace)
+	if opts.algorithm != "" {
+		view.SetAlgorithm(opts.algorithm)
+	}
+	if opts.definer != "" {
+		view.SetDefiner(opts.definer)
+	}
+	if opts.sqlSecurity != "" {
+		view.SetSQLSecurity(opts.sqlSecurity)
 	}
 
-	table.AddIndex(index)
-	return nil
-}
+	ctx.skipWhiteSpaces()
+	if ctx.peek().Type == LPAREN {
+		ctx.advance()
+	COLUMNS:
+		for {
+			ctx.skipWhiteSpaces()
+			switch t := ctx.next(); t.Type {
+			case IDENT, BACKTICK_IDENT:
+				if err := p.checkIdent(ctx, t); err != nil {
+					return nil, err
+				}
+				view.AddColumns(t.Value)
+			default:
+				return nil, newParseError(ctx, t, "expected IDENT or BACKTICK_IDENT")
+			}
+			ctx.skipWhiteSpaces()
+			switch t := ctx.next(); t.Type {
+			case COMMA:
+			case RPAREN:
+				break COLUMNS
+			default:
+				return nil, newParseError(ctx, t, "expected COMMA or RPAREN")
+			}
+		}
+		ctx.skipWhiteSpaces()
+	}
 
-func (p *Parser) parseTablePrimaryKey(ctx *parseCtx, table model.Table) error {
-	index := model.NewIndex(model.IndexKindPrimaryKey, table.ID())
-	if err := p.parseColumnIndexPrimaryKey(ctx, index); err != nil {
-		return err
+	if t := ctx.next(); t.Type != AS {
+		return nil, newParseError(ctx, t, "expected AS")
 	}
-	table.AddIndex(index)
-	return nil
-}
 
-func (p *Parser) parseTableUniqueKey(ctx *parseCtx, table model.Table) error {
-	index := model.NewIndex(model.IndexKindUnique, table.ID())
-	if err := p.parseColumnIndexUniqueKey(ctx, index); err != nil {
-		return err
+	toks, err := p.parseStatementText(ctx)
+	if err != nil {
+		return nil, err
 	}
-	table.AddIndex(index)
-	return nil
-}
 
-func (p *Parser) parseTableIndex(ctx *parseCtx, table model.Table) error {
-	index := model.NewIndex(model.IndexKindNormal, table.ID())
-	if err := p.parseColumnIndexKey(ctx, index); err != nil {
-		return err
+	// WITH [CASCADED | LOCAL] CHECK OPTION
+	if n := len(toks); n >= 3 && isKeyword(toks[n-1].tok, "OPTION") && toks[n-2].tok.Type == CHECK {
+		option := "CASCADED"
+		i := n - 3
+		if isKeyword(toks[i].tok, "CASCADED") || isKeyword(toks[i].tok, "LOCAL") {
+			option = strings.ToUpper(toks[i].text)
+			i--
+		}
+		if i >= 0 && toks[i].tok.Type == WITH {
+			view.SetCheckOption(option)
+			toks = toks[:i]
+		}
 	}
-	table.AddIndex(index)
-	return nil
-}
 
-func (p *Parser) parseTableFulltextIndex(ctx *parseCtx, table model.Table) error {
-	index := model.NewIndex(model.IndexKindFullText, table.ID())
-	if err := p.parseColumnIndexFullTextKey(ctx, index); err != nil {
-		return err
+	if len(toks) == 0 {
+		return nil, newParseError(ctx, ctx.peek(), "expected view definition")
 	}
-	table.AddIndex(index)
-	return nil
+	view.SetDefinition(joinStatementText(toks))
+	return view, nil
 }
 
-func (p *Parser) parseTableSpatialIndex(ctx *parseCtx, table model.Table) error {
-	index := model.NewIndex(model.IndexKindSpatial, table.ID())
-	if err := p.parseColumnIndexSpatialKey(ctx, index); err != nil {
-		return err
+// https://dev.mysql.com/doc/refman/8.0/en/create-trigger.html
+func (p *Parser) parseCreateTrigger(ctx *parseCtx, opts *createOptions) (model.Trigger, error) {
+	if t := ctx.next(); !isKeyword(t, "TRIGGER") {
+		return nil, errors.New(`expected TRIGGER`)
 	}
-	table.AddIndex(index)
-	return nil
-}
 
-func (p *Parser) parseTableForeignKey(ctx *parseCtx, table model.Table) error {
-	index := model.NewIndex(model.IndexKindForeignKey, table.ID())
-	if err := p.parseColumnIndexForeignKey(ctx, index); err != nil {
-		return err
+	var trigger model.Trigger
+	ctx.skipWhiteSpaces()
+	switch t := ctx.next(); t.Type {
+	case IDENT, BACKTICK_IDENT:
+		if err := p.checkIdent(ctx, t); err != nil {
+			return nil, err
+		}
+		schema, name, err := p.parseQualifiedName(ctx, t)
+		if err != nil {
+			return nil, err
+		}
+		trigger = model.NewTrigger(name)
+		trigger.SetSchema(schema)
+	default:
+		return nil, newParseError(ctx, t, "expected IDENT or BACKTICK_IDENT")
+	}
+	if opts.definer != "" {
+		trigger.SetDefiner(opts.definer)
 	}
-	table.AddIndex(index)
-	return nil
-}
 
-func (p *Parser) parseTableColumn(ctx *parseCtx, table model.Table) error {
-	t := ctx.next()
-	switch t.Type {
+	ctx.skipWhiteSpaces()
+	switch t := ctx.next(); {
+	case isKeyword(t, "BEFORE"), isKeyword(t, "AFTER"):
+		trigger.SetTiming(strings.ToUpper(t.Value))
+	default:
+		return nil, newParseError(ctx, t, "expected BEFORE or AFTER")
+	}
+
+	ctx.skipWhiteSpaces()
+	switch t := ctx.next(); {
+	case isKeyword(t, "INSERT"), isKeyword(t, "UPDATE"), isKeyword(t, "DELETE"):
+		trigger.SetEvent(strings.ToUpper(t.Value))
+	default:
+		return nil, newParseError(ctx, t, "expected INSERT, UPDATE or DELETE")
+	}
+
+	ctx.skipWhiteSpaces()
+	if t := ctx.next(); t.Type != ON {
+		return nil, newParseError(ctx, t, "expected ON")
+	}
+	ctx.skipWhiteSpaces()
+	switch t := ctx.next(); t.Type {
 	case IDENT, BACKTICK_IDENT:
+		if err := p.checkIdent(ctx, t); err != nil {
+			return nil, err
+		}
+		// the trigger belongs to the database of the table, which
+		// must be the same as the database that the trigger is
+		// qualified by, if any
+		schema, name, err := p.parseQualifiedName(ctx, t)
+		if err != nil {
+			return nil, err
+		}
+		if trigger.Schema() == "" {
+			if schema == "" {
+				schema = ctx.database
+			}
+			trigger.SetSchema(schema)
+		}
+		trigger.SetTableName(name)
 	default:
-		return newParseError(ctx, t, "expcted IDENT or BACKTICK_IDENT")
+		return nil, newParseError(ctx, t, "expected IDENT or BACKTICK_IDENT")
 	}
 
-	col := model.NewTableColumn(t.Value)
-	if err := p.parseTableColumnSpec(ctx, col); err != nil {
-		return err
+	for _, word := range []string{"FOR", "EACH", "ROW"} {
+		ctx.skipWhiteSpaces()
+		if t := ctx.next(); !isKeyword(t, word) {
+			return nil, newParseError(ctx, t, "expected %s", word)
+		}
 	}
-	table.AddColumn(col)
-	return nil
+
+	ctx.skipWhiteSpaces()
+	if t := ctx.peek(); isKeyword(t, "FOLLOWS") || isKeyword(t, "PRECEDES") {
+		ctx.advance()
+		ctx.skipWhiteSpaces()
+		switch other := ctx.next(); other.Type {
+		case IDENT, BACKTICK_IDENT:
+			trigger.SetOrder(strings.ToUpper(t.Value), other.Value)
+		default:
+			return nil, newParseError(ctx, other, "expected IDENT or BACKTICK_IDENT")
+		}
+	}
+
+	toks, err := p.parseStatementText(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if len(toks) == 0 {
+		return nil, newParseError(ctx, ctx.peek(), "expected trigger body")
+	}
+	trigger.SetBody(joinStatementText(toks))
+	return trigger, nil
 }
 
-func (p *Parser) parseTableColumnSpec(ctx *parseCtx, col model.TableColumn) error {
-	var coltyp model.ColumnType
-	var colopt int
+// https://dev.mysql.com/doc/refman/8.0/en/create-procedure.html
+func (p *Parser) parseCreateRoutine(ctx *parseCtx, opts *createOptions) (model.Routine, error) {
+	var function bool
+	switch t := ctx.next(); {
+	case isKeyword(t, "PROCEDURE"):
+	case isKeyword(t, "FUNCTION"):
+		function = true
+	default:
+		return nil, errors.New(`expected PROCEDURE or FUNCTION`)
+	}
 
+	var routine model.Routine
 	ctx.skipWhiteSpaces()
 	switch t := ctx.next(); t.Type {
-	case BIT:
-		coltyp = model.ColumnTypeBit
-		colopt = coloptSize
-	case TINYINT:
-		coltyp = model.ColumnTypeTinyInt
-		colopt = coloptFlagDigit
-	case SMALLINT:
-		coltyp = model.ColumnTypeSmallInt
-		colopt = coloptFlagDigit
-	case MEDIUMINT:
-		coltyp = model.ColumnTypeMediumInt
-		colopt = coloptFlagDigit
-	case INT:
-		coltyp = model.ColumnTypeInt
-		colopt = coloptFlagDigit
-	case INTEGER:
-		coltyp = model.ColumnTypeInteger
-		colopt = coloptFlagDigit
-	case BIGINT:
-		coltyp = model.ColumnTypeBigInt
-		colopt = coloptFlagDigit
-	case REAL:
-		coltyp = model.ColumnTypeReal
-		colopt = coloptFlagDecimal
-	case DOUBLE:
-		coltyp = model.ColumnTypeDouble
-		colopt = coloptFlagDecimal
-	case FLOAT:
-		coltyp = model.ColumnTypeFloat
-		colopt = coloptFlagDecimal
-	case DECIMAL:
-		coltyp = model.ColumnTypeDecimal
-		colopt = coloptFlagDecimalOptional
-	case NUMERIC:
-		coltyp = model.ColumnTypeNumeric
-		colopt = coloptFlagDecimalOptional
-	case DATE:
-		coltyp = model.ColumnTypeDate
-		colopt = coloptFlagNone
-	case TIME:
-		coltyp = model.ColumnTypeTime
-		colopt = coloptFlagTime
-	case TIMESTAMP:
-		coltyp = model.ColumnTypeTimestamp
-		colopt = coloptFlagTime
-	case DATETIME:
-		coltyp = model.ColumnTypeDateTime
-		colopt = coloptFlagTime
-	case YEAR:
-		coltyp = model.ColumnTypeYear
-		colopt = coloptFlagNone
-	case CHAR:
-		coltyp = model.ColumnTypeChar
-		colopt = coloptFlagChar
-	case VARCHAR:
-		coltyp = model.ColumnTypeVarChar
-		colopt = coloptFlagChar
-	case BINARY:
-		coltyp = model.ColumnTypeBinary
-		colopt = coloptFlagBinary
-	case VARBINARY:
-		coltyp = model.ColumnTypeVarBinary
-		colopt = coloptFlagBinary
-	case TINYBLOB:
-		coltyp = model.ColumnTypeTinyBlob
-		colopt = coloptFlagNone
-	case BLOB:
-		coltyp = model.ColumnTypeBlob
-		colopt = coloptFlagNone
-	case MEDIUMBLOB:
-		coltyp = model.ColumnTypeMediumBlob
-		colopt = coloptFlagNone
-	case LONGBLOB:
-		coltyp = model.ColumnTypeLongBlob
-		colopt = coloptFlagNone
-	case TINYTEXT:
-		coltyp = model.ColumnTypeTinyText
-		colopt = coloptFlagChar
-	case TEXT:
-		coltyp = model.ColumnTypeText
-		colopt = coloptFlagChar
-	case MEDIUMTEXT:
-		coltyp = model.ColumnTypeMediumText
-		colopt = coloptFlagChar
-	case LONGTEXT:
-		coltyp = model.ColumnTypeLongText
-		colopt = coloptFlagChar
-	case ENUM:
-		coltyp = model.ColumnTypeEnum
-		colopt = coloptFlagEnum
-	case SET:
-		coltyp = model.ColumnTypeSet
-		colopt = coloptFlagSet
-	case BOOLEAN:
-		coltyp = model.ColumnTypeBoolean
-		colopt = coloptFlagNone
-	case BOOL:
-		coltyp = model.ColumnTypeBool
-		colopt = coloptFlagNone
-	case JSON:
-		coltyp = model.ColumnTypeJSON
-		colopt = coloptFlagNone
-	case GEOMETRY:
-		coltyp = model.ColumnTypeGEOMETRY
-		colopt = coloptFlagNone
+	case IDENT, BACKTICK_IDENT:
+		if err := p.checkIdent(ctx, t); err != nil {
+			return nil, err
+		}
+		schema, name, err := p.parseQualifiedName(ctx, t)
+		if err != nil {
+			return nil, err
+		}
+		if schema == "" {
+			schema = ctx.database
+		}
+		if function {
+			routine = model.NewFunction(name)
+		} else {
+			routine = model.NewProcedure(name)
+		}
+		routine.SetSchema(schema)
 	default:
-		return newParseError(ctx, t, "unsupported type in column specification")
+		return nil, newParseError(ctx, t, "expected IDENT or BACKTICK_IDENT")
+	}
+	if opts.definer != "" {
+		routine.SetDefiner(opts.definer)
 	}
 
-	col.SetType(coltyp)
-	return p.parseColumnOption(ctx, col, colopt)
+	if err := p.parseRoutineParameters(ctx, routine); err != nil {
+		return nil, err
+	}
+
+	if function {
+		ctx.skipWhiteSpaces()
+		if t := ctx.next(); !isKeyword(t, "RETURNS") {
+			return nil, newParseError(ctx, t, "expected RETURNS")
+		}
+		typ, err := p.parseRoutineType(ctx)
+		if err != nil {
+			return nil, err
+		}
+		routine.SetReturns(typ)
+	}
+
+	if err := p.parseRoutineCharacteristics(ctx, routine); err != nil {
+		return nil, err
+	}
+
+	toks, err := p.parseStatementText(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if len(toks) == 0 {
+		return nil, newParseError(ctx, ctx.peek(), "expected routine body")
+	}
+	routine.SetBody(joinStatementText(toks))
+	return routine, nil
 }
 
-func (p *Parser) parseCreateTableOptionValue(ctx *parseCtx, table model.Table, name string, follow ...TokenType) error {
+func (p *Parser) parseRoutineParameters(ctx *parseCtx, routine model.Routine) error {
 	ctx.skipWhiteSpaces()
-	if t := ctx.peek(); t.Type == EQUAL {
+	if t := ctx.next(); t.Type != LPAREN {
+		return newParseError(ctx, t, "expected LPAREN")
+	}
+	ctx.skipWhiteSpaces()
+	if ctx.peek().Type == RPAREN {
 		ctx.advance()
-		ctx.skipWhiteSpaces()
+		return nil
 	}
 
-	t := ctx.next()
-	for _, typ := range follow {
-		if typ != t.Type {
-			continue
+	for {
+		// IN, OUT and INOUT are reserved words, so they are never the
+		// names of the parameters
+		var direction string
+		ctx.skipWhiteSpaces()
+		if t := ctx.peek(); !routine.IsFunction() && (isKeyword(t, "IN") || isKeyword(t, "OUT") || isKeyword(t, "INOUT")) {
+			ctx.advance()
+			direction = strings.ToUpper(t.Value)
 		}
-		var quotes bool
-		switch t.Type {
-		case SINGLE_QUOTE_IDENT, DOUBLE_QUOTE_IDENT:
-			quotes = true
+
+		ctx.skipWhiteSpaces()
+		var name string
+		switch t := ctx.next(); t.Type {
+		case IDENT, BACKTICK_IDENT:
+			if err := p.checkIdent(ctx, t); err != nil {
+				return err
+			}
+			name = t.Value
+		default:
+			return newParseError(ctx, t, "expected IDENT or BACKTICK_IDENT")
 		}
-		table.AddOption(model.NewTableOption(name, t.Value, quotes))
-		return nil
+
+		typ, err := p.parseRoutineType(ctx)
+		if err != nil {
+			return err
+		}
+		param := model.NewRoutineParameter(name, typ)
+		if direction != "" {
+			param.SetDirection(direction)
+		}
+		routine.AddParameters(param)
+
+		ctx.skipWhiteSpaces()
+		switch t := ctx.next(); t.Type {
+		case COMMA:
+		case RPAREN:
+			return nil
+		default:
+			return newParseError(ctx, t, "expected COMMA or RPAREN")
+		}
+	}
+}
+
+// parseRoutineType parses the data type of a parameter or the return
+// value of a routine, and returns it as text. The names of the types and
+// the attributes are written in upper case, and CHARACTER SET is written
+// as CHARSET, as is done by SHOW CREATE PROCEDURE
+func (p *Parser) parseRoutineType(ctx *parseCtx) (string, error) {
+	ctx.skipWhiteSpaces()
+	t := ctx.next()
+	if !isWord(t) {
+		return "", newParseError(ctx, t, "expected data type")
+	}
+	typ := strings.ToUpper(t.Value)
+
+	ctx.skipWhiteSpaces()
+	if ctx.peek().Type == LPAREN {
+		text, err := p.parseParenthesizedText(ctx)
+		if err != nil {
+			return "", err
+		}
+		typ += "(" + strings.Join(strings.Fields(text), " ") + ")"
+	}
+
+	for {
+		ctx.skipWhiteSpaces()
+		switch t := ctx.peek(); {
+		case t.Type == UNSIGNED, t.Type == ZEROFILL, t.Type == BINARY, isKeyword(t, "SIGNED"):
+			ctx.advance()
+			typ += " " + strings.ToUpper(t.Value)
+		case t.Type == CHARSET, t.Type == CHARACTER, t.Type == COLLATE:
+			ctx.advance()
+			keyword := "COLLATE"
+			if t.Type != COLLATE {
+				keyword = "CHARSET"
+			}
+			if t.Type == CHARACTER {
+				ctx.skipWhiteSpaces()
+				if t := ctx.next(); t.Type != SET {
+					return "", newParseError(ctx, t, "expected SET")
+				}
+			}
+			ctx.skipWhiteSpaces()
+			v := ctx.next()
+			switch {
+			case isWord(v), v.Type == BACKTICK_IDENT, v.Type == SINGLE_QUOTE_IDENT, v.Type == DOUBLE_QUOTE_IDENT:
+			default:
+				return "", newParseError(ctx, v, "expected %s name", strings.ToLower(keyword))
+			}
+			typ += " " + keyword + " " + v.Value
+		default:
+			return typ, nil
+		}
+	}
+}
+
+func (p *Parser) parseRoutineCharacteristics(ctx *parseCtx, routine model.Routine) error {
+	expectWords := func(words ...string) error {
+		for _, word := range words {
+			ctx.skipWhiteSpaces()
+			if t := ctx.next(); !isKeyword(t, word) {
+				return newParseError(ctx, t, "expected %s", word)
+			}
+		}
+		return nil
+	}
+
+	for {
+		ctx.skipWhiteSpaces()
+		t := ctx.peek()
+		var err error
+		switch {
+		case t.Type == COMMENT:
+			ctx.advance()
+			ctx.skipWhiteSpaces()
+			switch t := ctx.next(); t.Type {
+			case SINGLE_QUOTE_IDENT, DOUBLE_QUOTE_IDENT:
+				routine.SetComment(t.Value)
+			default:
+				return newParseError(ctx, t, "expected SINGLE_QUOTE_IDENT or DOUBLE_QUOTE_IDENT")
+			}
+		case isKeyword(t, "LANGUAGE"):
+			ctx.advance()
+			err = expectWords("SQL")
+		case t.Type == NOT:
+			ctx.advance()
+			err = expectWords("DETERMINISTIC")
+			routine.SetDeterministic(false)
+		case isKeyword(t, "DETERMINISTIC"):
+			ctx.advance()
+			routine.SetDeterministic(true)
+		case isKeyword(t, "CONTAINS"):
+			ctx.advance()
+			err = expectWords("SQL")
+			routine.SetDataAccess("CONTAINS SQL")
+		case isKeyword(t, "NO"):
+			ctx.advance()
+			err = expectWords("SQL")
+			routine.SetDataAccess("NO SQL")
+		case isKeyword(t, "READS"):
+			ctx.advance()
+			err = expectWords("SQL", "DATA")
+			routine.SetDataAccess("READS SQL DATA")
+		case isKeyword(t, "MODIFIES"):
+			ctx.advance()
+			err = expectWords("SQL", "DATA")
+			routine.SetDataAccess("MODIFIES SQL DATA")
+		case isKeyword(t, "SQL"):
+			ctx.advance()
+			if err := expectWords("SECURITY"); err != nil {
+				return err
+			}
+			ctx.skipWhiteSpaces()
+			switch t := ctx.next(); {
+			case isKeyword(t, "DEFINER"), isKeyword(t, "INVOKER"):
+				routine.SetSQLSecurity(strings.ToUpper(t.Value))
+			default:
+				return newParseError(ctx, t, "expected DEFINER or INVOKER")
+			}
+		default:
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+	}
+}
+
+// https://dev.mysql.com/doc/refman/8.0/en/create-event.html
+func (p *Parser) parseCreateEvent(ctx *parseCtx, opts *createOptions) (model.Event, error) {
+	if t := ctx.next(); !isKeyword(t, "EVENT") {
+		return nil, errors.New(`expected EVENT`)
+	}
+
+	var event model.Event
+	ctx.skipWhiteSpaces()
+	switch t := ctx.next(); t.Type {
+	case IDENT, BACKTICK_IDENT:
+		if err := p.checkIdent(ctx, t); err != nil {
+			return nil, err
+		}
+		schema, name, err := p.parseQualifiedName(ctx, t)
+		if err != nil {
+			return nil, err
+		}
+		if schema == "" {
+			schema = ctx.database
+		}
+		event = model.NewEvent(name)
+		event.SetSchema(schema)
+	default:
+		return nil, newParseError(ctx, t, "expected IDENT or BACKTICK_IDENT")
+	}
+	if opts.definer != "" {
+		event.SetDefiner(opts.definer)
+	}
+
+	for _, word := range []string{"ON", "SCHEDULE"} {
+		ctx.skipWhiteSpaces()
+		if t := ctx.next(); !isKeyword(t, word) {
+			return nil, newParseError(ctx, t, "expected %s", word)
+		}
+	}
+	if err := p.parseEventSchedule(ctx, event); err != nil {
+		return nil, err
+	}
+
+	for {
+		ctx.skipWhiteSpaces()
+		switch t := ctx.next(); {
+		case t.Type == ON:
+			ctx.skipWhiteSpaces()
+			if t := ctx.next(); !isKeyword(t, "COMPLETION") {
+				return nil, newParseError(ctx, t, "expected COMPLETION")
+			}
+			ctx.skipWhiteSpaces()
+			preserve := true
+			if ctx.peek().Type == NOT {
+				ctx.advance()
+				ctx.skipWhiteSpaces()
+				preserve = false
+			}
+			if t := ctx.next(); !isKeyword(t, "PRESERVE") {
+				return nil, newParseError(ctx, t, "expected PRESERVE")
+			}
+			event.SetPreserve(preserve)
+		case isKeyword(t, "ENABLE"):
+			event.SetStatus("ENABLE")
+		case isKeyword(t, "DISABLE"):
+			ctx.skipWhiteSpaces()
+			if ctx.peek().Type != ON {
+				event.SetStatus("DISABLE")
+				continue
+			}
+			ctx.advance()
+			ctx.skipWhiteSpaces()
+			switch t := ctx.next(); {
+			case isKeyword(t, "SLAVE"), isKeyword(t, "REPLICA"):
+				event.SetStatus("DISABLE ON " + strings.ToUpper(t.Value))
+			default:
+				return nil, newParseError(ctx, t, "expected SLAVE or REPLICA")
+			}
+		case t.Type == COMMENT:
+			ctx.skipWhiteSpaces()
+			switch t := ctx.next(); t.Type {
+			case SINGLE_QUOTE_IDENT, DOUBLE_QUOTE_IDENT:
+				event.SetComment(t.Value)
+			default:
+				return nil, newParseError(ctx, t, "expected SINGLE_QUOTE_IDENT or DOUBLE_QUOTE_IDENT")
+			}
+		case isKeyword(t, "DO"):
+			toks, err := p.parseStatementText(ctx)
+			if err != nil {
+				return nil, err
+			}
+			if len(toks) == 0 {
+				return nil, newParseError(ctx, ctx.peek(), "expected event body")
+			}
+			event.SetBody(joinStatementText(toks))
+			return event, nil
+		default:
+			return nil, newParseError(ctx, t, "expected ON COMPLETION, ENABLE, DISABLE, COMMENT or DO")
+		}
+	}
+}
+
+// parseEventSchedule parses the schedule of an event after ON SCHEDULE.
+// The keywords and the names of the functions in the expressions are
+// written in upper case
+func (p *Parser) parseEventSchedule(ctx *parseCtx, event model.Event) error {
+	// the clauses that may follow the expressions
+	stop := func(t *Token) bool {
+		for _, word := range []string{"STARTS", "ENDS", "ON", "ENABLE", "DISABLE", "COMMENT", "DO"} {
+			if isKeyword(t, word) {
+				return true
+			}
+		}
+		return false
+	}
+	expression := func(clause string) (string, error) {
+		toks, err := p.parseTextUntil(ctx, stop)
+		if err != nil {
+			return "", err
+		}
+		if len(toks) == 0 {
+			return "", newParseError(ctx, ctx.peek(), "expected expression after %s", clause)
+		}
+		for i, t := range toks {
+			if isWord(t.tok) {
+				toks[i].text = strings.ToUpper(t.text)
+			}
+		}
+		return joinStatementText(toks), nil
+	}
+
+	ctx.skipWhiteSpaces()
+	switch t := ctx.next(); {
+	case isKeyword(t, "AT"):
+		v, err := expression("AT")
+		if err != nil {
+			return err
+		}
+		event.SetAt(v)
+		return nil
+	case isKeyword(t, "EVERY"):
+		v, err := expression("EVERY")
+		if err != nil {
+			return err
+		}
+		event.SetEvery(v)
+	default:
+		return newParseError(ctx, t, "expected AT or EVERY")
+	}
+
+	ctx.skipWhiteSpaces()
+	if isKeyword(ctx.peek(), "STARTS") {
+		ctx.advance()
+		v, err := expression("STARTS")
+		if err != nil {
+			return err
+		}
+		event.SetStarts(v)
+	}
+
+	ctx.skipWhiteSpaces()
+	if isKeyword(ctx.peek(), "ENDS") {
+		ctx.advance()
+		v, err := expression("ENDS")
+		if err != nil {
+			return err
+		}
+		event.SetEnds(v)
+	}
+	return nil
+}
+
+// statementToken is a token of the text of a statement that the parser
+// does not interpret, along with the text of the token as it was written
+// in the input
+type statementToken struct {
+	tok    *Token
+	text   string
+	spaced bool // true if preceded by whitespace or comments
+}
+
+// parseStatementText consumes the tokens up to the end of the statement,
+// and returns them without the whitespace and the comments
+func (p *Parser) parseStatementText(ctx *parseCtx) ([]statementToken, error) {
+	toks, err := p.parseTextUntil(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	// SEMICOLON or EOF
+	ctx.advance()
+	return toks, nil
+}
+
+// parseTextUntil consumes the tokens up to the first token outside of
+// parenthesis for which stop returns true, or up to the end of the
+// statement, and returns them without the whitespace and the comments.
+// The token that ends the text is not consumed
+func (p *Parser) parseTextUntil(ctx *parseCtx, stop func(*Token) bool) ([]statementToken, error) {
+	var toks []statementToken
+	var spaced bool
+	var depth int
+	for {
+		t := ctx.peek()
+		if n := len(toks); n > 0 && toks[n-1].text == "" {
+			end := t.Pos
+			if end < toks[n-1].tok.Pos {
+				// the lexer has stopped without emitting EOF
+				end = len(ctx.input)
+			}
+			toks[n-1].text = string(ctx.input[toks[n-1].tok.Pos:end])
+		}
+		switch t.Type {
+		case SPACE, COMMENT_IDENT:
+			spaced = true
+		case SEMICOLON, EOF:
+			return toks, nil
+		case ILLEGAL:
+			// an unterminated quote ends the input
+			if strings.ContainsAny(t.Value[:1], "'\"`") {
+				return nil, newParseError(ctx, t, "unterminated quote")
+			}
+			fallthrough
+		default:
+			if depth == 0 && stop != nil && stop(t) {
+				return toks, nil
+			}
+			switch t.Type {
+			case LPAREN:
+				depth++
+			case RPAREN:
+				depth--
+			}
+			toks = append(toks, statementToken{tok: t, spaced: spaced})
+			spaced = false
+		}
+		ctx.advance()
+	}
+}
+
+// joinStatementText writes the tokens separated by single spaces where
+// the input had whitespace, except inside parenthesis and before commas
+// and semicolons
+func joinStatementText(toks []statementToken) string {
+	var buf strings.Builder
+	for i, t := range toks {
+		if i > 0 && t.spaced {
+			switch {
+			case toks[i-1].tok.Type == LPAREN:
+			case t.tok.Type == RPAREN, t.tok.Type == COMMA, t.tok.Type == INNER_SEMICOLON:
+			default:
+				buf.WriteByte(' ')
+			}
+		}
+		buf.WriteString(t.text)
+	}
+	return buf.String()
+}
+
+// https://dev.mysql.com/doc/refman/8.0/en/create-database.html
+func (p *Parser) parseCreateDatabase(ctx *parseCtx) (model.Database, error) {
+	if t := ctx.next(); t.Type != DATABASE {
+		return nil, errors.New(`expected DATABASE`)
+	}
+
+	ctx.skipWhiteSpaces()
+
+	var notexists bool
+	if ctx.peek().Type == IF {
+		ctx.advance()
+		if _, err := p.parseIdents(ctx, NOT, EXISTS); err != nil {
+			return nil, err
+		}
+		notexists = true
+	}
+
+	ctx.skipWhiteSpaces()
+
+	var database model.Database
+	switch t := ctx.next(); t.Type {
+	case IDENT, BACKTICK_IDENT:
+		if err := p.checkIdent(ctx, t); err != nil {
+			return nil, err
+		}
+		database = model.NewDatabase(t.Value)
+	default:
+		return nil, newParseError(ctx, t, "expected IDENT, BACKTICK_IDENT")
+	}
+
+	database.SetIfNotExists(notexists)
+
+	for {
+		ctx.skipWhiteSpaces()
+		switch t := ctx.next(); {
+		case t.Type == DEFAULT:
+			// DEFAULT is optional for all of the options
+		case t.Type == CHARACTER, t.Type == CHARSET:
+			if t.Type == CHARACTER {
+				ctx.skipWhiteSpaces()
+				if t := ctx.next(); t.Type != SET {
+					return nil, newParseError(ctx, t, "expected SET")
+				}
+			}
+			v, err := p.parseDatabaseOptionValue(ctx)
+			if err != nil {
+				return nil, err
+			}
+			database.SetCharacterSet(v)
+		case t.Type == COLLATE:
+			v, err := p.parseDatabaseOptionValue(ctx)
+			if err != nil {
+				return nil, err
+			}
+			database.SetCollation(v)
+		case isKeyword(t, "ENCRYPTION"):
+			v, err := p.parseDatabaseOptionValue(ctx)
+			if err != nil {
+				return nil, err
+			}
+			database.SetEncryption(strings.ToUpper(v))
+		case t.Type == SEMICOLON, t.Type == EOF:
+			return database, nil
+		default:
+			return nil, newParseError(ctx, t, "expected CHARACTER SET, COLLATE, ENCRYPTION, SEMICOLON or EOF")
+		}
+	}
+}
+
+// parseDatabaseOptionValue parses the value of a database option, which
+// may be preceded by `=`
+func (p *Parser) parseDatabaseOptionValue(ctx *parseCtx) (string, error) {
+	ctx.skipWhiteSpaces()
+	if ctx.peek().Type == EQUAL {
+		ctx.advance()
+		ctx.skipWhiteSpaces()
+	}
+	switch t := ctx.next(); {
+	case isWord(t), t.Type == BACKTICK_IDENT, t.Type == SINGLE_QUOTE_IDENT, t.Type == DOUBLE_QUOTE_IDENT:
+		return t.Value, nil
+	default:
+		return "", newParseError(ctx, t, "expected IDENT, BACKTICK_IDENT or SINGLE_QUOTE_IDENT")
+	}
+}
+
+// https://dev.mysql.com/doc/refman/8.0/en/use.html
+func (p *Parser) parseUse(ctx *parseCtx) error {
+	if t := ctx.next(); t.Type != USE {
+		return newParseError(ctx, t, "expected USE")
+	}
+
+	ctx.skipWhiteSpaces()
+	switch t := ctx.next(); t.Type {
+	case IDENT, BACKTICK_IDENT:
+		if err := p.checkIdent(ctx, t); err != nil {
+			return err
+		}
+		ctx.database = t.Value
+	default:
+		return newParseError(ctx, t, "expected IDENT or BACKTICK_IDENT")
+	}
+
+	ctx.skipWhiteSpaces()
+	switch t := ctx.next(); t.Type {
+	case SEMICOLON, EOF:
+		return nil
+	default:
+		return newParseError(ctx, t, "expected SEMICOLON or EOF")
+	}
+}
+
+// parseQualifiedName parses the rest of a name that may be qualified by
+// the name of a database (`db`.`name`), given its first part. The part
+// after the period is always an identifier, even if it is a reserved
+// word. If the name is not qualified, the database is empty
+func (p *Parser) parseQualifiedName(ctx *parseCtx, t *Token) (string, string, error) {
+	if ctx.peek().Type != DOT {
+		return "", t.Value, nil
+	}
+	ctx.advance()
+
+	switch n := ctx.next(); {
+	case n.Type == BACKTICK_IDENT, isWord(n):
+		return t.Value, n.Value, nil
+	default:
+		return "", "", newParseError(ctx, n, "expected IDENT or BACKTICK_IDENT")
+	}
+}
+
+// http://dev.mysql.com/doc/refman/5.6/en/create-table.html
+func (p *Parser) parseCreateTable(ctx *parseCtx) (model.Table, error) {
+	if t := ctx.next(); t.Type != TABLE {
+		return nil, errors.New(`expected TABLE`)
+	}
+
+	var table model.Table
+
+	ctx.skipWhiteSpaces()
+	var temporary bool
+	if t := ctx.peek(); t.Type == TEMPORARY {
+		ctx.advance()
+		ctx.skipWhiteSpaces()
+		temporary = true
+	}
+
+	// IF NOT EXISTS
+	var notexists bool
+	if ctx.peek().Type == IF {
+		ctx.advance()
+		if _, err := p.parseIdents(ctx, NOT, EXISTS); err != nil {
+			return nil, err
+		}
+		ctx.skipWhiteSpaces()
+		notexists = true
+	}
+
+	switch t := ctx.next(); t.Type {
+	case IDENT, BACKTICK_IDENT:
+		if err := p.checkIdent(ctx, t); err != nil {
+			return nil, err
+		}
+		schema, name, err := p.parseQualifiedName(ctx, t)
+		if err != nil {
+			return nil, err
+		}
+		if schema == "" {
+			schema = ctx.database
+		}
+		table = model.NewTable(name)
+		table.SetSchema(schema)
+	default:
+		return nil, newParseError(ctx, t, "expected IDENT or BACKTICK_IDENT")
+	}
+	table.SetTemporary(temporary)
+	table.SetIfNotExists(notexists)
+
+	ctx.skipWhiteSpaces()
+	switch t := ctx.peek(); t.Type {
+	case LIKE:
+		// CREATE TABLE foo LIKE bar
+		ctx.advance()
+		ctx.skipWhiteSpaces()
+		switch t := ctx.next(); t.Type {
+		case IDENT, BACKTICK_IDENT:
+			if err := p.checkIdent(ctx, t); err != nil {
+				return nil, err
+			}
+			table.SetLikeTable(t.Value)
+		default:
+			return nil, newParseError(ctx, t, "expected table name after LIKE")
+		}
+
+		ctx.skipWhiteSpaces()
+		switch t := ctx.peek(); t.Type {
+		case EOF, SEMICOLON:
+			ctx.advance()
+		}
+		return table, nil
+	case IF:
+		ctx.advance()
+		if _, err := p.parseIdents(ctx, NOT, EXISTS); err != nil {
+			return nil, newParseError(ctx, t, "should NOT EXISTS")
+		}
+		ctx.skipWhiteSpaces()
+		table.SetIfNotExists(true)
+	}
+
+	if t := ctx.next(); t.Type != LPAREN {
+		return nil, newParseError(ctx, t, "expected LPAREN")
+	}
+
+	if err := p.parseCreateTableFields(ctx, table); err != nil {
+		return nil, err
+	}
+
+	// references to the tables in the same database are not qualified,
+	// as SHOW CREATE TABLE does
+	for idx := range table.Indexes() {
+		if ref := idx.Reference(); ref != nil && ref.Schema() == table.Schema() {
+			ref.SetSchema("")
+		}
+	}
+
+	table, _ = table.Normalize()
+	return table, nil
+}
+
+// Start parsing after `CREATE TABLE *** (`
+func (p *Parser) parseCreateTableFields(ctx *parseCtx, stmt model.Table) error {
+	for {
+		ctx.skipWhiteSpaces()
+		if err := p.parseCreateTableField(ctx, stmt); err != nil {
+			return err
+		}
+
+		ctx.skipWhiteSpaces()
+		switch t := ctx.peek(); t.Type {
+		case RPAREN:
+			ctx.advance()
+			if err := p.parseCreateTableOptions(ctx, stmt); err != nil {
+				return err
+			}
+			ctx.skipWhiteSpaces()
+			if t := ctx.peek(); t.Type == PARTITION {
+				if err := p.parsePartitioning(ctx, stmt); err != nil {
+					return err
+				}
+			}
+			if !p.eol(ctx) {
+				return newParseError(ctx, t, "expected EOL")
+			}
+			return nil
+		case COMMA:
+			ctx.advance()
+			// Expecting another table field, keep looping
+		default:
+			return newParseError(ctx, t, "expected RPAREN or COMMA")
+		}
+	}
+}
+
+// parseCreateTableField parses a single column, index or constraint
+// definition, and adds it to the table
+func (p *Parser) parseCreateTableField(ctx *parseCtx, stmt model.Table) error {
+	switch t := ctx.peek(); t.Type {
+	case CONSTRAINT:
+		return p.parseTableConstraint(ctx, stmt)
+	case PRIMARY:
+		return p.parseTablePrimaryKey(ctx, stmt)
+	case UNIQUE:
+		return p.parseTableUniqueKey(ctx, stmt)
+	case INDEX, KEY:
+		// TODO. separate to KEY and INDEX
+		return p.parseTableIndex(ctx, stmt)
+	case FULLTEXT:
+		return p.parseTableFulltextIndex(ctx, stmt)
+	case SPATIAL:
+		return p.parseTableSpatialIndex(ctx, stmt)
+	case FOREIGN:
+		return p.parseTableForeignKey(ctx, stmt)
+	case CHECK:
+		return p.parseTableCheckConstraint(ctx, stmt)
+	case IDENT, BACKTICK_IDENT:
+		return p.parseTableColumn(ctx, stmt)
+	default:
+		return newParseError(ctx, t, "unexpected create table field token: %s", t.Type)
+	}
+}
+
+func (p *Parser) parseTableConstraint(ctx *parseCtx, table model.Table) error {
+	if t := ctx.next(); t.Type != CONSTRAINT {
+		return newParseError(ctx, t, "expected CONSTRAINT")
+	}
+	ctx.skipWhiteSpaces()
+
+	var sym string
+	switch t := ctx.peek(); t.Type {
+	case IDENT, BACKTICK_IDENT:
+		if err := p.checkIdent(ctx, t); err != nil {
+			return err
+		}
+		// TODO: should be smarter
+		// (lestrrat): I don't understand. How?
+		sym = t.Value
+		ctx.advance()
+		ctx.skipWhiteSpaces()
+	}
+
+	var index model.Index
+	switch t := ctx.peek(); t.Type {
+	case PRIMARY:
+		index = model.NewIndex(model.IndexKindPrimaryKey, table.ID())
+		if err := p.parseColumnIndexPrimaryKey(ctx, index); err != nil {
+			return err
+		}
+	case UNIQUE:
+		index = model.NewIndex(model.IndexKindUnique, table.ID())
+		if err := p.parseColumnIndexUniqueKey(ctx, index); err != nil {
+			return err
+		}
+	case FOREIGN:
+		index = model.NewIndex(model.IndexKindForeignKey, table.ID())
+		if err := p.parseColumnIndexForeignKey(ctx, index); err != nil {
+			return err
+		}
+	case CHECK:
+		// CHECK constraints are not indexes. The symbol is the name of
+		// the constraint itself
+		check := model.NewCheckConstraint(table.ID())
+		if err := p.parseCheckConstraint(ctx, check); err != nil {
+			return err
+		}
+		if len(sym) > 0 {
+			check.SetName(sym)
+		}
+		table.AddCheckConstraint(check)
+		return nil
+	default:
+		return newParseError(ctx, t, "not supported")
+	}
+
+	if len(sym) > 0 {
+		index.SetSymbol(sym)
+	}
+
+	table.AddIndex(index)
+	return nil
+}
+
+func (p *Parser) parseTablePrimaryKey(ctx *parseCtx, table model.Table) error {
+	index := model.NewIndex(model.IndexKindPrimaryKey, table.ID())
+	if err := p.parseColumnIndexPrimaryKey(ctx, index); err != nil {
+		return err
+	}
+	table.AddIndex(index)
+	return nil
+}
+
+func (p *Parser) parseTableUniqueKey(ctx *parseCtx, table model.Table) error {
+	index := model.NewIndex(model.IndexKindUnique, table.ID())
+	if err := p.parseColumnIndexUniqueKey(ctx, index); err != nil {
+		return err
+	}
+	table.AddIndex(index)
+	return nil
+}
+
+func (p *Parser) parseTableIndex(ctx *parseCtx, table model.Table) error {
+	index := model.NewIndex(model.IndexKindNormal, table.ID())
+	if err := p.parseColumnIndexKey(ctx, index); err != nil {
+		return err
+	}
+	table.AddIndex(index)
+	return nil
+}
+
+func (p *Parser) parseTableFulltextIndex(ctx *parseCtx, table model.Table) error {
+	index := model.NewIndex(model.IndexKindFullText, table.ID())
+	if err := p.parseColumnIndexFullTextKey(ctx, index); err != nil {
+		return err
+	}
+	table.AddIndex(index)
+	return nil
+}
+
+func (p *Parser) parseTableSpatialIndex(ctx *parseCtx, table model.Table) error {
+	index := model.NewIndex(model.IndexKindSpatial, table.ID())
+	if err := p.parseColumnIndexSpatialKey(ctx, index); err != nil {
+		return err
+	}
+	table.AddIndex(index)
+	return nil
+}
+
+func (p *Parser) parseTableForeignKey(ctx *parseCtx, table model.Table) error {
+	index := model.NewIndex(model.IndexKindForeignKey, table.ID())
+	if err := p.parseColumnIndexForeignKey(ctx, index); err != nil {
+		return err
+	}
+	table.AddIndex(index)
+	return nil
+}
+
+func (p *Parser) parseTableCheckConstraint(ctx *parseCtx, table model.Table) error {
+	check := model.NewCheckConstraint(table.ID())
+	if err := p.parseCheckConstraint(ctx, check); err != nil {
+		return err
+	}
+	table.AddCheckConstraint(check)
+	return nil
+}
+
+func (p *Parser) parseTableColumn(ctx *parseCtx, table model.Table) error {
+	t := ctx.next()
+	switch t.Type {
+	case IDENT, BACKTICK_IDENT:
+		if err := p.checkIdent(ctx, t); err != nil {
+			return err
+		}
+	default:
+		return newParseError(ctx, t, "expcted IDENT or BACKTICK_IDENT")
+	}
+
+	col := model.NewTableColumn(t.Value)
+	if err := p.parseTableColumnSpec(ctx, col); err != nil {
+		return err
+	}
+	table.AddColumn(col)
+	return nil
+}
+
+func (p *Parser) parseTableColumnSpec(ctx *parseCtx, col model.TableColumn) error {
+	var coltyp model.ColumnType
+	var colopt int
+
+	ctx.skipWhiteSpaces()
+	switch t := ctx.next(); t.Type {
+	case BIT:
+		coltyp = model.ColumnTypeBit
+		colopt = coloptSize
+	case TINYINT:
+		coltyp = model.ColumnTypeTinyInt
+		colopt = coloptFlagDigit
+	case SMALLINT:
+		coltyp = model.ColumnTypeSmallInt
+		colopt = coloptFlagDigit
+	case MEDIUMINT:
+		coltyp = model.ColumnTypeMediumInt
+		colopt = coloptFlagDigit
+	case INT:
+		coltyp = model.ColumnTypeInt
+		colopt = coloptFlagDigit
+	case INTEGER:
+		coltyp = model.ColumnTypeInteger
+		colopt = coloptFlagDigit
+	case BIGINT:
+		coltyp = model.ColumnTypeBigInt
+		colopt = coloptFlagDigit
+	case REAL:
+		coltyp = model.ColumnTypeReal
+		colopt = coloptFlagDecimal
+	case DOUBLE:
+		coltyp = model.ColumnTypeDouble
+		colopt = coloptFlagDecimal
+	case FLOAT:
+		coltyp = model.ColumnTypeFloat
+		colopt = coloptFlagDecimal
+	case DECIMAL:
+		coltyp = model.ColumnTypeDecimal
+		colopt = coloptFlagDecimalOptional
+	case NUMERIC:
+		coltyp = model.ColumnTypeNumeric
+		colopt = coloptFlagDecimalOptional
+	case DATE:
+		coltyp = model.ColumnTypeDate
+		colopt = coloptFlagNone
+	case TIME:
+		coltyp = model.ColumnTypeTime
+		colopt = coloptFlagTime
+	case TIMESTAMP:
+		coltyp = model.ColumnTypeTimestamp
+		colopt = coloptFlagTime
+	case DATETIME:
+		coltyp = model.ColumnTypeDateTime
+		colopt = coloptFlagTime
+	case YEAR:
+		coltyp = model.ColumnTypeYear
+		colopt = coloptFlagNone
+	case CHAR:
+		coltyp = model.ColumnTypeChar
+		colopt = coloptFlagChar
+	case VARCHAR:
+		coltyp = model.ColumnTypeVarChar
+		colopt = coloptFlagChar
+	case BINARY:
+		coltyp = model.ColumnTypeBinary
+		colopt = coloptFlagBinary
+	case VARBINARY:
+		coltyp = model.ColumnTypeVarBinary
+		colopt = coloptFlagBinary
+	case TINYBLOB:
+		coltyp = model.ColumnTypeTinyBlob
+		colopt = coloptFlagNone
+	case BLOB:
+		coltyp = model.ColumnTypeBlob
+		colopt = coloptFlagNone
+	case MEDIUMBLOB:
+		coltyp = model.ColumnTypeMediumBlob
+		colopt = coloptFlagNone
+	case LONGBLOB:
+		coltyp = model.ColumnTypeLongBlob
+		colopt = coloptFlagNone
+	case TINYTEXT:
+		coltyp = model.ColumnTypeTinyText
+		colopt = coloptFlagChar
+	case TEXT:
+		coltyp = model.ColumnTypeText
+		colopt = coloptFlagChar
+	case MEDIUMTEXT:
+		coltyp = model.ColumnTypeMediumText
+		colopt = coloptFlagChar
+	case LONGTEXT:
+		coltyp = model.ColumnTypeLongText
+		colopt = coloptFlagChar
+	case ENUM:
+		coltyp = model.ColumnTypeEnum
+		colopt = coloptFlagEnum
+	case SET:
+		coltyp = model.ColumnTypeSet
+		colopt = coloptFlagSet
+	case BOOLEAN:
+		coltyp = model.ColumnTypeBoolean
+		colopt = coloptFlagNone
+	case BOOL:
+		coltyp = model.ColumnTypeBool
+		colopt = coloptFlagNone
+	case JSON:
+		coltyp = model.ColumnTypeJSON
+		colopt = coloptFlagNone
+	case GEOMETRY:
+		coltyp = model.ColumnTypeGEOMETRY
+		colopt = coloptFlagNone
+	case IDENT:
+		// the types that only MariaDB supports are not keywords, so that
+		// they can still be used as identifiers
+		switch strings.ToUpper(t.Value) {
+		case "UUID":
+			coltyp = model.ColumnTypeUUID
+		case "INET6":
+			coltyp = model.ColumnTypeINET6
+		case "INET4":
+			coltyp = model.ColumnTypeINET4
+		default:
+			return newParseError(ctx, t, "unsupported type in column specification")
+		}
+		colopt = coloptFlagNone
+		if p.version != nil && !p.version.SupportsColumnType(coltyp) {
+			return newParseError(ctx, t, "%s columns are not supported by %s", coltyp, p.version)
+		}
+	default:
+		return newParseError(ctx, t, "unsupported type in column specification")
+	}
+
+	col.SetType(coltyp)
+	return p.parseColumnOption(ctx, col, colopt)
+}
+
+func (p *Parser) parseCreateTableOptionValue(ctx *parseCtx, table model.Table, name string, follow ...TokenType) error {
+	opt, err := p.parseOptionValue(ctx, name, follow...)
+	if err != nil {
+		return err
+	}
+	table.AddOption(opt)
+	return nil
+}
+
+// parseOptionValue parses the value part of `name [=] value` style
+// options, such as those used in table and partition options
+func (p *Parser) parseOptionValue(ctx *parseCtx, name string, follow ...TokenType) (model.TableOption, error) {
+	ctx.skipWhiteSpaces()
+	if t := ctx.peek(); t.Type == EQUAL {
+		ctx.advance()
+		ctx.skipWhiteSpaces()
+	}
+
+	t := ctx.next()
+	for _, typ := range follow {
+		if typ != t.Type {
+			continue
+		}
+		var quotes bool
+		switch t.Type {
+		case SINGLE_QUOTE_IDENT, DOUBLE_QUOTE_IDENT:
+			quotes = true
+		}
+		return model.NewTableOption(name, t.Value, quotes), nil
+	}
+	return nil, newParseError(ctx, t, "expected %v", follow)
+}
+
+func (p *Parser) parseCreateTableOptions(ctx *parseCtx, table model.Table) error {
+	ctx.skipWhiteSpaces()
+	switch t := ctx.peek(); t.Type {
+	case EOF:
+		// no table options, end of input
+		ctx.advance()
+		return nil
+	case SEMICOLON, PARTITION:
+		// no table options, end of statement
+		return nil
+	}
+
+	for {
+		ctx.skipWhiteSpaces()
+		switch t := ctx.next(); t.Type {
+		case COMMA:
+			// no op, continue to next option
+			continue
+		default:
+			if err := p.parseTableOption(ctx, table, t); err != nil {
+				return err
+			}
+		}
+
+		ctx.skipWhiteSpaces()
+		// except for the case where we continue to the next option (COMMA)
+		// we should expect the end of this statement
+		switch t := ctx.peek(); t.Type {
+		case EOF:
+			// end of table options, end of input
+			ctx.advance()
+			return nil
+		case SEMICOLON, PARTITION:
+			// end of table options, end of statement or
+			// beginning of partition options
+			return nil
+		}
+	}
+}
+
+// parseTableOption parses the value of the table option that starts
+// with the given token, and adds it to the table
+func (p *Parser) parseTableOption(ctx *parseCtx, table model.Table, t *Token) error {
+	switch t.Type {
+	case ENGINE:
+		if err := p.parseCreateTableOptionValue(ctx, table, "ENGINE", IDENT, BACKTICK_IDENT); err != nil {
+			return err
+		}
+	case AUTO_INCREMENT:
+		if err := p.parseCreateTableOptionValue(ctx, table, "AUTO_INCREMENT", NUMBER); err != nil {
+			return err
+		}
+	case AVG_ROW_LENGTH:
+		if err := p.parseCreateTableOptionValue(ctx, table, "AVG_ROW_LENGTH", NUMBER); err != nil {
+			return err
+		}
+	case DEFAULT:
+		var name string
+		ctx.skipWhiteSpaces()
+		switch t := ctx.next(); t.Type {
+		case CHARSET:
+			name = "DEFAULT CHARACTER SET"
+		case CHARACTER:
+			ctx.skipWhiteSpaces()
+			if t := ctx.next(); t.Type != SET {
+				return newParseError(ctx, t, "expected SET")
+			}
+			name = "DEFAULT CHARACTER SET"
+		case COLLATE:
+			name = "DEFAULT COLLATE"
+		default:
+			return newParseError(ctx, t, "expected CHARACTER or COLLATE")
+		}
+		if err := p.parseCreateTableOptionValue(ctx, table, name, IDENT, BACKTICK_IDENT); err != nil {
+			return err
+		}
+	case CHARACTER:
+		ctx.skipWhiteSpaces()
+		if t := ctx.next(); t.Type != SET {
+			return newParseError(ctx, t, "expected SET")
+		}
+		if err := p.parseCreateTableOptionValue(ctx, table, "DEFAULT CHARACTER SET", IDENT, BACKTICK_IDENT); err != nil {
+			return err
+		}
+	case COLLATE:
+		if err := p.parseCreateTableOptionValue(ctx, table, "DEFAULT COLLATE", IDENT, BACKTICK_IDENT); err != nil {
+			return err
+		}
+	case CHECKSUM:
+		if err := p.parseCreateTableOptionValue(ctx, table, "CHECKSUM", NUMBER); err != nil {
+			return err
+		}
+	case COMMENT:
+		if err := p.parseCreateTableOptionValue(ctx, table, "COMMENT", SINGLE_QUOTE_IDENT, DOUBLE_QUOTE_IDENT); err != nil {
+			return err
+		}
+	case CONNECTION:
+		if err := p.parseCreateTableOptionValue(ctx, table, "CONNECTION", SINGLE_QUOTE_IDENT, DOUBLE_QUOTE_IDENT); err != nil {
+			return err
+		}
+	case DATA:
+		ctx.skipWhiteSpaces()
+		if t := ctx.next(); t.Type != DIRECTORY {
+			return newParseError(ctx, t, "expected DIRECTORY")
+		}
+		if err := p.parseCreateTableOptionValue(ctx, table, "DATA DIRECTORY", SINGLE_QUOTE_IDENT, DOUBLE_QUOTE_IDENT); err != nil {
+			return err
+		}
+	case DELAY_KEY_WRITE:
+		if err := p.parseCreateTableOptionValue(ctx, table, "DELAY_KEY_WRITE", NUMBER); err != nil {
+			return err
+		}
+	case INDEX:
+		ctx.skipWhiteSpaces()
+		if t := ctx.next(); t.Type != DIRECTORY {
+			return newParseError(ctx, t, "should DIRECTORY")
+		}
+		if err := p.parseCreateTableOptionValue(ctx, table, "INDEX DIRECTORY", SINGLE_QUOTE_IDENT, DOUBLE_QUOTE_IDENT); err != nil {
+			return err
+		}
+	case INSERT_METHOD:
+		if err := p.parseCreateTableOptionValue(ctx, table, "INSERT_METHOD", IDENT); err != nil {
+			return err
+		}
+	case KEY_BLOCK_SIZE:
+		if err := p.parseCreateTableOptionValue(ctx, table, "KEY_BLOCK_SIZE", NUMBER); err != nil {
+			return err
+		}
+	case MAX_ROWS:
+		if err := p.parseCreateTableOptionValue(ctx, table, "MAX_ROWS", NUMBER); err != nil {
+			return err
+		}
+	case MIN_ROWS:
+		if err := p.parseCreateTableOptionValue(ctx, table, "MIN_ROWS", NUMBER); err != nil {
+			return err
+		}
+	case PACK_KEYS:
+		if err := p.parseCreateTableOptionValue(ctx, table, "PACK_KEYS", NUMBER, IDENT); err != nil {
+			return err
+		}
+	case PASSWORD:
+		if err := p.parseCreateTableOptionValue(ctx, table, "PASSWORD", SINGLE_QUOTE_IDENT, DOUBLE_QUOTE_IDENT); err != nil {
+			return err
+		}
+	case ROW_FORMAT:
+		if err := p.parseCreateTableOptionValue(ctx, table, "ROW_FORMAT", DEFAULT, DYNAMIC, FIXED, COMPRESSED, REDUNDANT, COMPACT); err != nil {
+			return err
+		}
+	case STATS_AUTO_RECALC:
+		if err := p.parseCreateTableOptionValue(ctx, table, "STATS_AUTO_RECALC", NUMBER, DEFAULT); err != nil {
+			return err
+		}
+	case STATS_PERSISTENT:
+		if err := p.parseCreateTableOptionValue(ctx, table, "STATS_PERSISTENT", NUMBER, DEFAULT); err != nil {
+			return err
+		}
+	case STATS_SAMPLE_PAGES:
+		if err := p.parseCreateTableOptionValue(ctx, table, "STATS_SAMPLE_PAGES", NUMBER); err != nil {
+			return err
+		}
+	case WITH:
+		for _, word := range []string{"SYSTEM", "VERSIONING"} {
+			ctx.skipWhiteSpaces()
+			if t := ctx.next(); t.Type != IDENT || strings.ToUpper(t.Value) != word {
+				return newParseError(ctx, t, "expected WITH SYSTEM VERSIONING")
+			}
+		}
+		if p.version != nil && !p.version.SupportsSystemVersioning() {
+			return newParseError(ctx, t, "system-versioned tables are not supported by %s", p.version)
+		}
+		table.SetSystemVersioned(true)
+	case IDENT:
+		// the options that only MariaDB supports are not keywords
+		name := strings.ToUpper(t.Value)
+		var follow []TokenType
+		switch name {
+		case "ENCRYPTION_KEY_ID", "PAGE_CHECKSUM", "PAGE_COMPRESSED", "PAGE_COMPRESSION_LEVEL", "TRANSACTIONAL":
+			follow = []TokenType{NUMBER}
+		case "ENCRYPTED":
+			follow = []TokenType{IDENT}
+		default:
+			return newParseError(ctx, t, "unexpected token in table options: "+t.Type.String())
+		}
+		if p.version != nil && !p.version.SupportsTableOption(name) {
+			return newParseError(ctx, t, "table option %s is not supported by %s", name, p.version)
+		}
+		if err := p.parseCreateTableOptionValue(ctx, table, name, follow...); err != nil {
+			return err
+		}
+	case TABLESPACE:
+		return newParseError(ctx, t, "unsupported option TABLESPACE")
+	case UNION:
+		return newParseError(ctx, t, "unsupported option UNION")
+	default:
+		return newParseError(ctx, t, "unexpected token in table options: "+t.Type.String())
+	}
+	return nil
+}
+
+// https://dev.mysql.com/doc/refman/8.0/en/alter-table.html
+func (p *Parser) parseAlterTable(ctx *parseCtx) (model.AlterTable, error) {
+	if t := ctx.next(); t.Type != ALTER {
+		return nil, newParseError(ctx, t, "expected ALTER")
+	}
+
+	ctx.skipWhiteSpaces()
+	if t := ctx.next(); t.Type != TABLE {
+		return nil, newParseError(ctx, t, "expected TABLE")
+	}
+
+	alter, err := p.parseAlterTableName(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	for {
+		spec, err := p.parseAlterTableSpec(ctx, alter)
+		if err != nil {
+			return nil, err
+		}
+		if spec != nil {
+			alter.AddSpecs(spec)
+		}
+
+		ctx.skipWhiteSpaces()
+		switch t := ctx.next(); t.Type {
+		case COMMA:
+			// Expecting another alter specification, keep looping
+		case SEMICOLON, EOF:
+			return alter, nil
+		default:
+			return nil, newParseError(ctx, t, "expected COMMA, SEMICOLON or EOF")
+		}
+	}
+}
+
+// parseAlterTableName parses the name of the table, and creates an
+// AlterTable for it
+func (p *Parser) parseAlterTableName(ctx *parseCtx) (model.AlterTable, error) {
+	ctx.skipWhiteSpaces()
+	switch t := ctx.next(); t.Type {
+	case IDENT, BACKTICK_IDENT:
+		if err := p.checkIdent(ctx, t); err != nil {
+			return nil, err
+		}
+		schema, name, err := p.parseQualifiedName(ctx, t)
+		if err != nil {
+			return nil, err
+		}
+		if schema == "" {
+			schema = ctx.database
+		}
+		alter := model.NewAlterTable(name)
+		alter.SetSchema(schema)
+		return alter, nil
+	default:
+		return nil, newParseError(ctx, t, "expected IDENT or BACKTICK_IDENT")
+	}
+}
+
+// parseAlterTableSpec parses a single alter specification. The columns,
+// indexes and table options are parsed into a scratch table, in the same
+// way as those of CREATE TABLE. The ALGORITHM and LOCK clauses do not
+// change the table, and nil is returned for them
+func (p *Parser) parseAlterTableSpec(ctx *parseCtx, alter model.AlterTable) (model.AlterTableSpec, error) {
+	scratch := model.NewTable(alter.Name())
+	scratch.SetSchema(alter.Schema())
+
+	ctx.skipWhiteSpaces()
+	switch t := ctx.next(); {
+	case t.Type == ADD:
+		ctx.skipWhiteSpaces()
+		if t := ctx.peek(); t.Type == COLUMN {
+			ctx.advance()
+			ctx.skipWhiteSpaces()
+			if t := ctx.peek(); t.Type != IDENT && t.Type != BACKTICK_IDENT {
+				return nil, newParseError(ctx, t, "expected IDENT or BACKTICK_IDENT")
+			}
+		}
+		if err := p.parseCreateTableField(ctx, scratch); err != nil {
+			return nil, err
+		}
+		if col, ok := <-scratch.Columns(); ok {
+			col, _ = col.Normalize()
+			spec := model.NewAlterTableSpec(model.AlterTableSpecAddColumn)
+			spec.SetColumn(col)
+			return spec, p.parseColumnPosition(ctx, spec)
+		}
+		if idx, ok := <-scratch.Indexes(); ok {
+			return model.NewAlterTableSpec(model.AlterTableSpecAddIndex).SetIndex(idx), nil
+		}
+		check := <-scratch.CheckConstraints()
+		return model.NewAlterTableSpec(model.AlterTableSpecAddCheckConstraint).SetCheckConstraint(check), nil
+	case t.Type == DROP:
+		return p.parseAlterTableDrop(ctx)
+	case isKeyword(t, "MODIFY"), t.Type == CHANGE:
+		ctx.skipWhiteSpaces()
+		if t := ctx.peek(); t.Type == COLUMN {
+			ctx.advance()
+			ctx.skipWhiteSpaces()
+		}
+		var name string
+		if t.Type == CHANGE {
+			n, err := p.parseAlterTableIdent(ctx)
+			if err != nil {
+				return nil, err
+			}
+			name = n
+			ctx.skipWhiteSpaces()
+		}
+		if err := p.parseTableColumn(ctx, scratch); err != nil {
+			return nil, err
+		}
+		col, _ := (<-scratch.Columns()).Normalize()
+		if name == "" {
+			name = col.Name()
+		}
+		spec := model.NewAlterTableSpec(model.AlterTableSpecModifyColumn)
+		spec.SetName(name)
+		spec.SetColumn(col)
+		return spec, p.parseColumnPosition(ctx, spec)
+	case t.Type == RENAME:
+		return p.parseAlterTableRename(ctx)
+	case t.Type == ALGORITHM, isKeyword(t, "LOCK"):
+		ctx.skipWhiteSpaces()
+		if t := ctx.peek(); t.Type == EQUAL {
+			ctx.advance()
+			ctx.skipWhiteSpaces()
+		}
+		if v := ctx.next(); !isWord(v) {
+			return nil, newParseError(ctx, v, "expected %s value", strings.ToUpper(t.Value))
+		}
+		return nil, nil
+	default:
+		// table options may be separated by spaces as well as commas
+		for {
+			if err := p.parseTableOption(ctx, scratch, t); err != nil {
+				return nil, err
+			}
+			ctx.skipWhiteSpaces()
+			switch ctx.peek().Type {
+			case COMMA, SEMICOLON, EOF:
+				spec := model.NewAlterTableSpec(model.AlterTableSpecTableOptions)
+				for opt := range scratch.Options() {
+					spec.AddOption(opt)
+				}
+				return spec, nil
+			}
+			t = ctx.next()
+		}
+	}
+}
+
+// parseColumnPosition parses the optional `FIRST` or `AFTER col` that
+// follows the column definition in ADD COLUMN, MODIFY COLUMN and
+// CHANGE COLUMN
+func (p *Parser) parseColumnPosition(ctx *parseCtx, spec model.AlterTableSpec) error {
+	ctx.skipWhiteSpaces()
+	switch t := ctx.peek(); {
+	case t.Type == FIRST:
+		ctx.advance()
+		spec.SetFirst(true)
+	case isKeyword(t, "AFTER"):
+		ctx.advance()
+		ctx.skipWhiteSpaces()
+		name, err := p.parseAlterTableIdent(ctx)
+		if err != nil {
+			return err
+		}
+		spec.SetAfter(name)
+	}
+	return nil
+}
+
+func (p *Parser) parseAlterTableDrop(ctx *parseCtx) (model.AlterTableSpec, error) {
+	var kind model.AlterTableSpecKind
+
+	ctx.skipWhiteSpaces()
+	switch t := ctx.peek(); t.Type {
+	case COLUMN:
+		ctx.advance()
+		kind = model.AlterTableSpecDropColumn
+	case INDEX, KEY:
+		ctx.advance()
+		kind = model.AlterTableSpecDropIndex
+	case PRIMARY:
+		if _, err := p.parseIdents(ctx, PRIMARY, KEY); err != nil {
+			return nil, err
+		}
+		return model.NewAlterTableSpec(model.AlterTableSpecDropPrimaryKey), nil
+	case FOREIGN:
+		if _, err := p.parseIdents(ctx, FOREIGN, KEY); err != nil {
+			return nil, err
+		}
+		kind = model.AlterTableSpecDropForeignKey
+	case CHECK:
+		ctx.advance()
+		kind = model.AlterTableSpecDropCheckConstraint
+	case CONSTRAINT:
+		ctx.advance()
+		kind = model.AlterTableSpecDropConstraint
+	default:
+		kind = model.AlterTableSpecDropColumn
+	}
+
+	ctx.skipWhiteSpaces()
+	name, err := p.parseAlterTableIdent(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return model.NewAlterTableSpec(kind).SetName(name), nil
+}
+
+func (p *Parser) parseAlterTableRename(ctx *parseCtx) (model.AlterTableSpec, error) {
+	var kind model.AlterTableSpecKind
+
+	ctx.skipWhiteSpaces()
+	switch t := ctx.peek(); t.Type {
+	case COLUMN:
+		kind = model.AlterTableSpecRenameColumn
+	case INDEX, KEY:
+		kind = model.AlterTableSpecRenameIndex
+	default:
+		// RENAME [TO | AS] new_tbl_name
+		if t.Type == TO || t.Type == AS {
+			ctx.advance()
+			ctx.skipWhiteSpaces()
+		}
+		switch t := ctx.next(); t.Type {
+		case IDENT, BACKTICK_IDENT:
+			if err := p.checkIdent(ctx, t); err != nil {
+				return nil, err
+			}
+			schema, name, err := p.parseQualifiedName(ctx, t)
+			if err != nil {
+				return nil, err
+			}
+			if schema == "" {
+				schema = ctx.database
+			}
+			spec := model.NewAlterTableSpec(model.AlterTableSpecRename)
+			spec.SetNewName(name)
+			spec.SetNewSchema(schema)
+			return spec, nil
+		default:
+			return nil, newParseError(ctx, t, "expected IDENT or BACKTICK_IDENT")
+		}
+	}
+	ctx.advance()
+
+	ctx.skipWhiteSpaces()
+	name, err := p.parseAlterTableIdent(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	ctx.skipWhiteSpaces()
+	if t := ctx.next(); t.Type != TO {
+		return nil, newParseError(ctx, t, "expected TO")
+	}
+
+	ctx.skipWhiteSpaces()
+	newName, err := p.parseAlterTableIdent(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return model.NewAlterTableSpec(kind).SetName(name).SetNewName(newName), nil
+}
+
+// parseDrop parses `DROP [TEMPORARY] TABLE [IF EXISTS] tbl [, tbl] ...`,
+// creating a DropTable for each of the tables if WithDropTables was
+// specified. In that case `DROP INDEX` and the statements that drop
+// views, triggers, routines, events and databases are parsed as well.
+// Otherwise, and for the other DROP statements, the statement is skipped
+// and nil is returned
+func (p *Parser) parseDrop(ctx *parseCtx) ([]model.Stmt, error) {
+	if t := ctx.next(); t.Type != DROP {
+		return nil, newParseError(ctx, t, "expected DROP")
+	}
+
+	ctx.skipWhiteSpaces()
+	var temporary bool
+	if t := ctx.peek(); t.Type == TEMPORARY {
+		ctx.advance()
+		ctx.skipWhiteSpaces()
+		temporary = true
+	}
+
+	switch t := ctx.peek(); {
+	case !p.dropTables:
+		// We don't do anything about these
+		ctx.skipStatement()
+		return nil, nil
+	case t.Type == TABLE:
+		ctx.advance()
+	case temporary:
+		return nil, newParseError(ctx, t, "expected TABLE")
+	case t.Type == INDEX:
+		return p.parseDropIndex(ctx)
+	case t.Type == DATABASE, isKeyword(t, "SCHEMA"), isKeyword(t, "VIEW"), isKeyword(t, "TRIGGER"),
+		isKeyword(t, "PROCEDURE"), isKeyword(t, "FUNCTION"), isKeyword(t, "EVENT"):
+		return p.parseDropObject(ctx)
+	default:
+		// neither about the objects that are not part of the schema,
+		// such as users
+		ctx.skipStatement()
+		return nil, nil
+	}
+
+	ctx.skipWhiteSpaces()
+	var ifexists bool
+	if ctx.peek().Type == IF {
+		ctx.advance()
+		if _, err := p.parseIdents(ctx, EXISTS); err != nil {
+			return nil, err
+		}
+		ctx.skipWhiteSpaces()
+		ifexists = true
+	}
+
+	var stmts []model.Stmt
+	for {
+		ctx.skipWhiteSpaces()
+		switch t := ctx.next(); t.Type {
+		case IDENT, BACKTICK_IDENT:
+			if err := p.checkIdent(ctx, t); err != nil {
+				return nil, err
+			}
+			schema, name, err := p.parseQualifiedName(ctx, t)
+			if err != nil {
+				return nil, err
+			}
+			if schema == "" {
+				schema = ctx.database
+			}
+			drop := model.NewDropTable(name)
+			drop.SetSchema(schema)
+			drop.SetTemporary(temporary)
+			drop.SetIfExists(ifexists)
+			stmts = append(stmts, drop)
+		default:
+			return nil, newParseError(ctx, t, "expected IDENT or BACKTICK_IDENT")
+		}
+
+		ctx.skipWhiteSpaces()
+		switch t := ctx.next(); t.Type {
+		case COMMA:
+			// Expecting another table, keep looping
+		case RESTRICT, CASCADE:
+			// these do nothing in MySQL
+			if !p.eol(ctx) {
+				return nil, newParseError(ctx, t, "expected EOL")
+			}
+			return stmts, nil
+		case SEMICOLON, EOF:
+			return stmts, nil
+		default:
+			return nil, newParseError(ctx, t, "expected COMMA, SEMICOLON or EOF")
+		}
+	}
+}
+
+// parseDropIndex parses `DROP INDEX idx ON tbl`. As dropping an index is
+// the same as altering the table with `DROP INDEX`, an AlterTable is
+// created for the table
+func (p *Parser) parseDropIndex(ctx *parseCtx) ([]model.Stmt, error) {
+	if t := ctx.next(); t.Type != INDEX {
+		return nil, newParseError(ctx, t, "expected INDEX")
+	}
+
+	ctx.skipWhiteSpaces()
+	name, err := p.parseAlterTableIdent(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	ctx.skipWhiteSpaces()
+	if t := ctx.next(); t.Type != ON {
+		return nil, newParseError(ctx, t, "expected ON")
+	}
+	alter, err := p.parseAlterTableName(ctx)
+	if err != nil {
+		return nil, err
+	}
+	alter.AddSpecs(model.NewAlterTableSpec(model.AlterTableSpecDropIndex).SetName(name))
+
+	if err := p.parseIndexLockOptions(ctx); err != nil {
+		return nil, err
+	}
+	return []model.Stmt{alter}, nil
+}
+
+// parseDropObject parses the DROP statements for the objects other than
+// tables and indexes, such as `DROP VIEW [IF EXISTS] v [, v] ...` or
+// `DROP PROCEDURE [IF EXISTS] p`, creating a DropObject for each of the
+// objects. `DROP SCHEMA` is the same as `DROP DATABASE`
+func (p *Parser) parseDropObject(ctx *parseCtx) ([]model.Stmt, error) {
+	kind := strings.ToUpper(ctx.next().Value)
+	if kind == "SCHEMA" {
+		kind = "DATABASE"
+	}
+
+	ctx.skipWhiteSpaces()
+	var ifexists bool
+	if ctx.peek().Type == IF {
+		ctx.advance()
+		if _, err := p.parseIdents(ctx, EXISTS); err != nil {
+			return nil, err
+		}
+		ctx.skipWhiteSpaces()
+		ifexists = true
+	}
+
+	var stmts []model.Stmt
+	for {
+		ctx.skipWhiteSpaces()
+		switch t := ctx.next(); t.Type {
+		case IDENT, BACKTICK_IDENT:
+			if err := p.checkIdent(ctx, t); err != nil {
+				return nil, err
+			}
+			drop := model.NewDropObject(kind, t.Value)
+			if kind != "DATABASE" {
+				schema, name, err := p.parseQualifiedName(ctx, t)
+				if err != nil {
+					return nil, err
+				}
+				if schema == "" {
+					schema = ctx.database
+				}
+				drop = model.NewDropObject(kind, name)
+				drop.SetSchema(schema)
+			}
+			drop.SetIfExists(ifexists)
+			stmts = append(stmts, drop)
+		default:
+			return nil, newParseError(ctx, t, "expected IDENT or BACKTICK_IDENT")
+		}
+
+		// only views may be dropped several at once
+		ctx.skipWhiteSpaces()
+		switch t := ctx.next(); {
+		case t.Type == COMMA && kind == "VIEW":
+			// Expecting another view, keep looping
+		case (t.Type == RESTRICT || t.Type == CASCADE) && kind == "VIEW":
+			// these do nothing in MySQL
+			if !p.eol(ctx) {
+				return nil, newParseError(ctx, t, "expected EOL")
+			}
+			return stmts, nil
+		case t.Type == SEMICOLON, t.Type == EOF:
+			return stmts, nil
+		default:
+			return nil, newParseError(ctx, t, "expected SEMICOLON or EOF")
+		}
+	}
+}
+
+// parseRenameTable parses `RENAME TABLE tbl TO new_tbl [, tbl2 TO new_tbl2] ...`.
+// As renaming a table is the same as altering it with `RENAME TO`, an
+// AlterTable is created for each of the tables
+func (p *Parser) parseRenameTable(ctx *parseCtx) ([]model.Stmt, error) {
+	if _, err := p.parseIdents(ctx, RENAME, TABLE); err != nil {
+		return nil, err
+	}
+
+	parseName := func() (string, string, error) {
+		ctx.skipWhiteSpaces()
+		switch t := ctx.next(); t.Type {
+		case IDENT, BACKTICK_IDENT:
+			if err := p.checkIdent(ctx, t); err != nil {
+				return "", "", err
+			}
+			schema, name, err := p.parseQualifiedName(ctx, t)
+			if err != nil {
+				return "", "", err
+			}
+			if schema == "" {
+				schema = ctx.database
+			}
+			return schema, name, nil
+		default:
+			return "", "", newParseError(ctx, t, "expected IDENT or BACKTICK_IDENT")
+		}
+	}
+
+	var stmts []model.Stmt
+	for {
+		schema, name, err := parseName()
+		if err != nil {
+			return nil, err
+		}
+		if _, err := p.parseIdents(ctx, TO); err != nil {
+			return nil, err
+		}
+		newSchema, newName, err := parseName()
+		if err != nil {
+			return nil, err
+		}
+
+		alter := model.NewAlterTable(name)
+		alter.SetSchema(schema)
+		alter.AddSpecs(model.NewAlterTableSpec(model.AlterTableSpecRename).SetNewName(newName).SetNewSchema(newSchema))
+		stmts = append(stmts, alter)
+
+		ctx.skipWhiteSpaces()
+		switch t := ctx.next(); t.Type {
+		case COMMA:
+			// Expecting another table, keep looping
+		case SEMICOLON, EOF:
+			return stmts, nil
+		default:
+			return nil, newParseError(ctx, t, "expected COMMA, SEMICOLON or EOF")
+		}
+	}
+}
+
+// parseAlterTableIdent parses the name of a column, an index or a
+// constraint that is referred to by an alter specification
+func (p *Parser) parseAlterTableIdent(ctx *parseCtx) (string, error) {
+	switch t := ctx.next(); t.Type {
+	case IDENT, BACKTICK_IDENT:
+		if err := p.checkIdent(ctx, t); err != nil {
+			return "", err
+		}
+		return t.Value, nil
+	default:
+		return "", newParseError(ctx, t, "expected IDENT or BACKTICK_IDENT")
+	}
+}
+
+// https://dev.mysql.com/doc/refman/5.7/en/create-table.html#create-table-partitioning
+func (p *Parser) parsePartitioning(ctx *parseCtx, table model.Table) error {
+	if _, err := p.parseIdents(ctx, PARTITION, BY); err != nil {
+		return err
+	}
+
+	partitioning := model.NewPartitioning(model.PartitionTypeNone)
+	if err := p.parsePartitionMethod(ctx, partitioning, true); err != nil {
+		return err
+	}
+
+	ctx.skipWhiteSpaces()
+	if t := ctx.peek(); t.Type == PARTITIONS {
+		ctx.advance()
+		ctx.skipWhiteSpaces()
+		t := ctx.next()
+		if t.Type != NUMBER {
+			return newParseError(ctx, t, "expected NUMBER")
+		}
+		partitioning.SetPartitionCount(t.Value)
+	}
+
+	ctx.skipWhiteSpaces()
+	if t := ctx.peek(); t.Type == SUBPARTITION {
+		ctx.advance()
+		if _, err := p.parseIdents(ctx, BY); err != nil {
+			return err
+		}
+
+		subpartitioning := model.NewPartitioning(model.PartitionTypeNone)
+		if err := p.parsePartitionMethod(ctx, subpartitioning, false); err != nil {
+			return err
+		}
+
+		ctx.skipWhiteSpaces()
+		if t := ctx.peek(); t.Type == SUBPARTITIONS {
+			ctx.advance()
+			ctx.skipWhiteSpaces()
+			t := ctx.next()
+			if t.Type != NUMBER {
+				return newParseError(ctx, t, "expected NUMBER")
+			}
+			subpartitioning.SetPartitionCount(t.Value)
+		}
+		partitioning.SetSubPartitioning(subpartitioning)
+	}
+
+	ctx.skipWhiteSpaces()
+	if t := ctx.peek(); t.Type == LPAREN {
+		ctx.advance()
+		for {
+			def, err := p.parsePartitionDefinition(ctx, PARTITION)
+			if err != nil {
+				return err
+			}
+
+			switch partitioning.Type() {
+			case model.PartitionTypeRange, model.PartitionTypeList:
+				if !def.HasValues() {
+					return newParseError(ctx, ctx.peek(), "partition '%s' requires a VALUES clause", def.Name())
+				}
+			default:
+				if def.HasValues() {
+					return newParseError(ctx, ctx.peek(), "partition '%s' may not have a VALUES clause", def.Name())
+				}
+			}
+			partitioning.AddDefinition(def)
+
+			ctx.skipWhiteSpaces()
+			if done, err := p.parsePartitionDefinitionsDelimiter(ctx); err != nil {
+				return err
+			} else if done {
+				break
+			}
+		}
+	}
+
+	table.SetPartitioning(partitioning)
+	return nil
+}
+
+// parsePartitionMethod parses the partitioning type and its expression.
+// RANGE and LIST are only allowed when allowRangeList is true, as they
+// cannot be used for subpartitions.
+func (p *Parser) parsePartitionMethod(ctx *parseCtx, partitioning model.Partitioning, allowRangeList bool) error {
+	ctx.skipWhiteSpaces()
+	t := ctx.next()
+	if t.Type == LINEAR {
+		partitioning.SetLinear(true)
+		ctx.skipWhiteSpaces()
+		t = ctx.next()
+		switch t.Type {
+		case HASH, KEY:
+		default:
+			return newParseError(ctx, t, "expected HASH or KEY")
+		}
+	}
+
+	switch t.Type {
+	case HASH:
+		partitioning.SetType(model.PartitionTypeHash)
+	case KEY:
+		partitioning.SetType(model.PartitionTypeKey)
+		ctx.skipWhiteSpaces()
+		if t := ctx.peek(); t.Type == ALGORITHM {
+			ctx.advance()
+			ctx.skipWhiteSpaces()
+			if t := ctx.peek(); t.Type == EQUAL {
+				ctx.advance()
+				ctx.skipWhiteSpaces()
+			}
+			t := ctx.next()
+			if t.Type != NUMBER {
+				return newParseError(ctx, t, "expected NUMBER")
+			}
+			partitioning.SetAlgorithm(t.Value)
+		}
+	case RANGE, LIST:
+		if !allowRangeList {
+			return newParseError(ctx, t, "expected HASH or KEY")
+		}
+		if t.Type == RANGE {
+			partitioning.SetType(model.PartitionTypeRange)
+		} else {
+			partitioning.SetType(model.PartitionTypeList)
+		}
+		ctx.skipWhiteSpaces()
+		if t := ctx.peek(); t.Type == COLUMNS {
+			ctx.advance()
+			partitioning.SetColumns(true)
+		}
+	default:
+		if allowRangeList {
+			return newParseError(ctx, t, "expected RANGE, LIST, HASH or KEY")
+		}
+		return newParseError(ctx, t, "expected HASH or KEY")
+	}
+
+	expr, err := p.parseParenthesizedText(ctx)
+	if err != nil {
+		return err
 	}
-	return newParseError(ctx, t, "expected %v", follow)
+	partitioning.SetExpression(expr)
+	return nil
 }
 
-func (p *Parser) parseCreateTableOptions(ctx *parseCtx, table model.Table) error {
+// parsePartitionDefinition parses a single partition definition, or
+// a subpartition definition if typ is SUBPARTITION
+func (p *Parser) parsePartitionDefinition(ctx *parseCtx, typ TokenType) (model.PartitionDefinition, error) {
 	ctx.skipWhiteSpaces()
-	switch t := ctx.peek(); t.Type {
-	case EOF:
-		// no table options, end of input
-		ctx.advance()
-		return nil
-	case SEMICOLON:
-		// no table options, end of statement
-		return nil
+	if t := ctx.next(); t.Type != typ {
+		return nil, newParseError(ctx, t, "expected %s", typ)
 	}
 
-	for {
+	var def model.PartitionDefinition
+	ctx.skipWhiteSpaces()
+	switch t := ctx.next(); t.Type {
+	case IDENT, BACKTICK_IDENT:
+		def = model.NewPartitionDefinition(t.Value)
+	default:
+		return nil, newParseError(ctx, t, "expected IDENT or BACKTICK_IDENT")
+	}
+
+	ctx.skipWhiteSpaces()
+	if t := ctx.peek(); t.Type == VALUES && typ == PARTITION {
+		ctx.advance()
 		ctx.skipWhiteSpaces()
 		switch t := ctx.next(); t.Type {
-		case ENGINE:
-			if err := p.parseCreateTableOptionValue(ctx, table, "ENGINE", IDENT, BACKTICK_IDENT); err != nil {
-				return err
-			}
-		case AUTO_INCREMENT:
-			if err := p.parseCreateTableOptionValue(ctx, table, "AUTO_INCREMENT", NUMBER); err != nil {
-				return err
-			}
-		case AVG_ROW_LENGTH:
-			if err := p.parseCreateTableOptionValue(ctx, table, "AVG_ROW_LENGTH", NUMBER); err != nil {
-				return err
+		case LESS:
+			if _, err := p.parseIdents(ctx, THAN); err != nil {
+				return nil, err
 			}
-		case DEFAULT:
-			var name string
 			ctx.skipWhiteSpaces()
-			switch t := ctx.next(); t.Type {
-			case CHARSET:
-				name = "DEFAULT CHARACTER SET"
-			case CHARACTER:
-				ctx.skipWhiteSpaces()
-				if t := ctx.next(); t.Type != SET {
-					return newParseError(ctx, t, "expected SET")
+			if t := ctx.peek(); t.Type == MAXVALUE {
+				ctx.advance()
+				def.SetValues("LESS THAN MAXVALUE")
+			} else {
+				expr, err := p.parseParenthesizedText(ctx)
+				if err != nil {
+					return nil, err
 				}
-				name = "DEFAULT CHARACTER SET"
-			case COLLATE:
-				name = "DEFAULT COLLATE"
-			default:
-				return newParseError(ctx, t, "expected CHARACTER or COLLATE")
+				def.SetValues("LESS THAN (" + expr + ")")
 			}
-			if err := p.parseCreateTableOptionValue(ctx, table, name, IDENT, BACKTICK_IDENT); err != nil {
-				return err
+		case IN:
+			expr, err := p.parseParenthesizedText(ctx)
+			if err != nil {
+				return nil, err
 			}
-		case CHARACTER:
+			def.SetValues("IN (" + expr + ")")
+		default:
+			return nil, newParseError(ctx, t, "expected LESS THAN or IN")
+		}
+	}
+
+	for {
+		var opt model.TableOption
+		var err error
+
+		ctx.skipWhiteSpaces()
+		switch t := ctx.peek(); t.Type {
+		case STORAGE:
+			ctx.advance()
 			ctx.skipWhiteSpaces()
-			if t := ctx.next(); t.Type != SET {
-				return newParseError(ctx, t, "expected SET")
-			}
-			if err := p.parseCreateTableOptionValue(ctx, table, "DEFAULT CHARACTER SET", IDENT, BACKTICK_IDENT); err != nil {
-				return err
-			}
-		case COLLATE:
-			if err := p.parseCreateTableOptionValue(ctx, table, "DEFAULT COLLATE", IDENT, BACKTICK_IDENT); err != nil {
-				return err
-			}
-		case CHECKSUM:
-			if err := p.parseCreateTableOptionValue(ctx, table, "CHECKSUM", NUMBER); err != nil {
-				return err
+			if t := ctx.next(); t.Type != ENGINE {
+				return nil, newParseError(ctx, t, "expected ENGINE")
 			}
+			opt, err = p.parseOptionValue(ctx, "ENGINE", IDENT, BACKTICK_IDENT)
+		case ENGINE:
+			ctx.advance()
+			opt, err = p.parseOptionValue(ctx, "ENGINE", IDENT, BACKTICK_IDENT)
 		case COMMENT:
-			if err := p.parseCreateTableOptionValue(ctx, table, "COMMENT", SINGLE_QUOTE_IDENT, DOUBLE_QUOTE_IDENT); err != nil {
-				return err
-			}
-		case CONNECTION:
-			if err := p.parseCreateTableOptionValue(ctx, table, "CONNECTION", SINGLE_QUOTE_IDENT, DOUBLE_QUOTE_IDENT); err != nil {
-				return err
-			}
-		case DATA:
-			ctx.skipWhiteSpaces()
-			if t := ctx.next(); t.Type != DIRECTORY {
-				return newParseError(ctx, t, "expected DIRECTORY")
-			}
-			if err := p.parseCreateTableOptionValue(ctx, table, "DATA DIRECTORY", SINGLE_QUOTE_IDENT, DOUBLE_QUOTE_IDENT); err != nil {
-				return err
-			}
-		case DELAY_KEY_WRITE:
-			if err := p.parseCreateTableOptionValue(ctx, table, "DATA_KEY_WRITE", NUMBER); err != nil {
-				return err
-			}
-		case INDEX:
+			ctx.advance()
+			opt, err = p.parseOptionValue(ctx, "COMMENT", SINGLE_QUOTE_IDENT, DOUBLE_QUOTE_IDENT)
+		case DATA, INDEX:
+			ctx.advance()
 			ctx.skipWhiteSpaces()
 			if t := ctx.next(); t.Type != DIRECTORY {
-				return newParseError(ctx, t, "should DIRECTORY")
-			}
-			if err := p.parseCreateTableOptionValue(ctx, table, "INDEX DIRECTORY", SINGLE_QUOTE_IDENT, DOUBLE_QUOTE_IDENT); err != nil {
-				return err
-			}
-		case INSERT_METHOD:
-			if err := p.parseCreateTableOptionValue(ctx, table, "INSERT_METHOD", IDENT); err != nil {
-				return err
-			}
-		case KEY_BLOCK_SIZE:
-			if err := p.parseCreateTableOptionValue(ctx, table, "KEY_BLOCK_SIZE", NUMBER); err != nil {
-				return err
+				return nil, newParseError(ctx, t, "expected DIRECTORY")
 			}
+			opt, err = p.parseOptionValue(ctx, strings.ToUpper(t.Value)+" DIRECTORY", SINGLE_QUOTE_IDENT, DOUBLE_QUOTE_IDENT)
 		case MAX_ROWS:
-			if err := p.parseCreateTableOptionValue(ctx, table, "MAX_ROWS", NUMBER); err != nil {
-				return err
-			}
+			ctx.advance()
+			opt, err = p.parseOptionValue(ctx, "MAX_ROWS", NUMBER)
 		case MIN_ROWS:
-			if err := p.parseCreateTableOptionValue(ctx, table, "MIN_ROWS", NUMBER); err != nil {
-				return err
-			}
-		case PACK_KEYS:
-			if err := p.parseCreateTableOptionValue(ctx, table, "PACK_KEYS", NUMBER, IDENT); err != nil {
-				return err
-			}
-		case PASSWORD:
-			if err := p.parseCreateTableOptionValue(ctx, table, "PASSWORD", SINGLE_QUOTE_IDENT, DOUBLE_QUOTE_IDENT); err != nil {
-				return err
-			}
-		case ROW_FORMAT:
-			if err := p.parseCreateTableOptionValue(ctx, table, "ROW_FORMAT", DEFAULT, DYNAMIC, FIXED, COMPRESSED, REDUNDANT, COMPACT); err != nil {
-				return err
-			}
-		case STATS_AUTO_RECALC:
-			if err := p.parseCreateTableOptionValue(ctx, table, "STATS_AUTO_RECALC", NUMBER, DEFAULT); err != nil {
-				return err
-			}
-		case STATS_PERSISTENT:
-			if err := p.parseCreateTableOptionValue(ctx, table, "STATS_PERSISTENT", NUMBER, DEFAULT); err != nil {
-				return err
+			ctx.advance()
+			opt, err = p.parseOptionValue(ctx, "MIN_ROWS", NUMBER)
+		case TABLESPACE:
+			ctx.advance()
+			opt, err = p.parseOptionValue(ctx, "TABLESPACE", IDENT, BACKTICK_IDENT)
+		case NODEGROUP:
+			ctx.advance()
+			opt, err = p.parseOptionValue(ctx, "NODEGROUP", NUMBER)
+		case LPAREN:
+			if typ != PARTITION {
+				return nil, newParseError(ctx, t, "unexpected LPAREN")
 			}
-		case STATS_SAMPLE_PAGES:
-			if err := p.parseCreateTableOptionValue(ctx, table, "STATS_SAMPLE_PAGES", NUMBER); err != nil {
-				return err
+			ctx.advance()
+			for {
+				sub, err := p.parsePartitionDefinition(ctx, SUBPARTITION)
+				if err != nil {
+					return nil, err
+				}
+				def.AddSubPartition(sub)
+
+				ctx.skipWhiteSpaces()
+				if done, err := p.parsePartitionDefinitionsDelimiter(ctx); err != nil {
+					return nil, err
+				} else if done {
+					break
+				}
 			}
-		case TABLESPACE:
-			return newParseError(ctx, t, "unsupported option TABLESPACE")
-		case UNION:
-			return newParseError(ctx, t, "unsupported option UNION")
-		case COMMA:
-			// no op, continue to next option
 			continue
 		default:
-			return newParseError(ctx, t, "unexpected token in table options: "+t.Type.String())
+			return def, nil
 		}
 
-		ctx.skipWhiteSpaces()
-		// except for the case where we continue to the next option (COMMA)
-		// we should expect the end of this statement
-		switch t := ctx.peek(); t.Type {
-		case EOF:
-			// end of table options, end of input
-			ctx.advance()
-			return nil
-		case SEMICOLON:
-			// end of table options, end of statement
-			return nil
+		if err != nil {
+			return nil, err
 		}
+		def.AddOption(opt)
+	}
+}
+
+// parsePartitionDefinitionsDelimiter consumes the token following a
+// partition definition. It returns true if that was the end of the
+// list of partition definitions
+func (p *Parser) parsePartitionDefinitionsDelimiter(ctx *parseCtx) (bool, error) {
+	switch t := ctx.next(); t.Type {
+	case COMMA:
+		return false, nil
+	case RPAREN:
+		return true, nil
+	default:
+		return false, newParseError(ctx, t, "expected COMMA or RPAREN")
 	}
 }
 
@@ -816,7 +2828,7 @@ func (p *Parser) parseCreateTableOptions(ctx *parseCtx, table model.Table) error
 // seem to state otherwise.
 //
 func (p *Parser) parseColumnOption(ctx *parseCtx, col model.TableColumn, f int) error {
-	f = f | coloptNull | coloptDefault | coloptAutoIncrement | coloptKey | coloptComment
+	f = f | coloptNull | coloptDefault | coloptAutoIncrement | coloptKey | coloptComment | coloptGenerated
 	pos := 0
 	check := func(_f int) bool {
 		if pos > _f {
@@ -942,17 +2954,56 @@ func (p *Parser) parseColumnOption(ctx *parseCtx, col model.TableColumn, f int)
 				return newParseError(ctx, t, "expected ON UPDATE")
 			}
 			ctx.skipWhiteSpaces()
-			v := ctx.next()
-			col.SetAutoUpdate(v.Value)
+			switch v := ctx.next(); v.Type {
+			case CURRENT_TIMESTAMP:
+				fsp, err := p.parseTimestampPrecision(ctx)
+				if err != nil {
+					return err
+				}
+				col.SetAutoUpdate(strings.ToUpper(v.Value) + fsp)
+			default:
+				col.SetAutoUpdate(v.Value)
+			}
 		case DEFAULT:
 			if !check(coloptDefault) {
 				return newParseError(ctx, t, "cannot apply DEFAULT")
 			}
 			ctx.skipWhiteSpaces()
 			switch t := ctx.next(); t.Type {
-			case IDENT, SINGLE_QUOTE_IDENT, DOUBLE_QUOTE_IDENT:
+			case IDENT:
+				if ctx.peek().Type != LPAREN {
+					col.SetDefault(t.Value, true)
+					break
+				}
+				// a function call, such as `DEFAULT uuid()`. MariaDB
+				// writes expressions without the parentheses around them
+				if p.version != nil && !p.version.SupportsExpressionDefaults() {
+					return newParseError(ctx, t, "expressions as default values are not supported by %s", p.version)
+				}
+				args, err := p.parseParenthesizedText(ctx)
+				if err != nil {
+					return err
+				}
+				col.SetDefault(t.Value+"("+args+")", false)
+			case SINGLE_QUOTE_IDENT, DOUBLE_QUOTE_IDENT:
 				col.SetDefault(t.Value, true)
-			case NUMBER, CURRENT_TIMESTAMP, NULL, TRUE, FALSE:
+			case CURRENT_TIMESTAMP:
+				fsp, err := p.parseTimestampPrecision(ctx)
+				if err != nil {
+					return err
+				}
+				col.SetDefault(strings.ToUpper(t.Value)+fsp, false)
+			case LPAREN:
+				if p.version != nil && !p.version.SupportsExpressionDefaults() {
+					return newParseError(ctx, t, "expressions as default values are not supported by %s", p.version)
+				}
+				ctx.rewind()
+				expr, err := p.parseParenthesizedText(ctx)
+				if err != nil {
+					return err
+				}
+				col.SetDefault("("+expr+")", false)
+			case NUMBER, NULL, TRUE, FALSE:
 				col.SetDefault(strings.ToUpper(t.Value), false)
 			case NOW:
 				now := t.Value
@@ -994,6 +3045,23 @@ func (p *Parser) parseColumnOption(ctx *parseCtx, col model.TableColumn, f int)
 				return newParseError(ctx, t, "expected PRIMARY KEY")
 			}
 			col.SetPrimary(true)
+		case GENERATED:
+			if !check(coloptGenerated) {
+				return newParseError(ctx, t, "cannot apply GENERATED ALWAYS")
+			}
+			if _, err := p.parseIdents(ctx, ALWAYS, AS); err != nil {
+				return err
+			}
+			if err := p.parseColumnGeneration(ctx, col); err != nil {
+				return err
+			}
+		case AS:
+			if !check(coloptGenerated) {
+				return newParseError(ctx, t, "cannot apply AS")
+			}
+			if err := p.parseColumnGeneration(ctx, col); err != nil {
+				return err
+			}
 		case COMMENT:
 			if !check(coloptComment) {
 				return newParseError(ctx, t, "cannot apply COMMENT")
@@ -1005,10 +3073,38 @@ func (p *Parser) parseColumnOption(ctx *parseCtx, col model.TableColumn, f int)
 			default:
 				return newParseError(ctx, t, "should SINGLE_QUOTE_IDENT")
 			}
-		case COMMA:
+		case CHECK:
+			if p.version != nil && !p.version.SupportsCheckConstraints() {
+				return newParseError(ctx, t, "CHECK constraints are not supported by %s", p.version)
+			}
+			expr, err := p.parseParenthesizedText(ctx)
+			if err != nil {
+				return err
+			}
+			col.SetCheckExpression(expr)
+		case IDENT:
+			// VISIBLE and INVISIBLE are not keywords, so that they can
+			// still be used as identifiers
+			switch strings.ToUpper(t.Value) {
+			case "INVISIBLE":
+				if p.version != nil && !p.version.SupportsInvisibleColumns() {
+					return newParseError(ctx, t, "invisible columns are not supported by %s", p.version)
+				}
+				col.SetInvisible(true)
+			case "VISIBLE":
+				col.SetInvisible(false)
+			case "AFTER":
+				// the position of the column in ALTER TABLE
+				ctx.rewind()
+				return nil
+			default:
+				return newParseError(ctx, t, "unexpected column option %s", t.Type)
+			}
+		case COMMA, RPAREN:
 			ctx.rewind()
 			return nil
-		case RPAREN:
+		case FIRST, SEMICOLON, EOF:
+			// the end of the column definition in ALTER TABLE
 			ctx.rewind()
 			return nil
 		default:
@@ -1017,6 +3113,54 @@ func (p *Parser) parseColumnOption(ctx *parseCtx, col model.TableColumn, f int)
 	}
 }
 
+// parseTimestampPrecision parses the optional fractional seconds precision
+// that follows CURRENT_TIMESTAMP, and returns it in parentheses. MariaDB
+// writes `current_timestamp()`, which is the same as CURRENT_TIMESTAMP,
+// so an empty string is returned for it
+func (p *Parser) parseTimestampPrecision(ctx *parseCtx) (string, error) {
+	ctx.skipWhiteSpaces()
+	if ctx.peek().Type != LPAREN {
+		return "", nil
+	}
+	ctx.advance()
+
+	ctx.skipWhiteSpaces()
+	var fsp string
+	if t := ctx.peek(); t.Type == NUMBER {
+		ctx.advance()
+		ctx.skipWhiteSpaces()
+		fsp = t.Value
+	}
+	if t := ctx.next(); t.Type != RPAREN {
+		return "", newParseError(ctx, t, "expected RPAREN")
+	}
+	if fsp == "" {
+		return "", nil
+	}
+	return "(" + fsp + ")", nil
+}
+
+// Start parsing after `[GENERATED ALWAYS] AS`
+// https://dev.mysql.com/doc/refman/5.7/en/create-table-generated-columns.html
+func (p *Parser) parseColumnGeneration(ctx *parseCtx, col model.TableColumn) error {
+	expr, err := p.parseParenthesizedText(ctx)
+	if err != nil {
+		return err
+	}
+	col.SetGenerationExpression(expr)
+
+	ctx.skipWhiteSpaces()
+	switch t := ctx.peek(); t.Type {
+	case VIRTUAL:
+		ctx.advance()
+		col.SetGenerationStorage(model.GenerationStorageVirtual)
+	case STORED:
+		ctx.advance()
+		col.SetGenerationStorage(model.GenerationStorageStored)
+	}
+	return nil
+}
+
 func (ctx *parseCtx) parseSetOrEnum(setter func([]string) model.TableColumn) error {
 	var values []string
 OUTER:
@@ -1091,6 +3235,26 @@ func (p *Parser) parseColumnIndexCommon(ctx *parseCtx, index model.Index) error
 		return err
 	}
 
+	return p.parseColumnIndexVisibility(ctx, index)
+}
+
+func (p *Parser) parseColumnIndexVisibility(ctx *parseCtx, index model.Index) error {
+	// VISIBLE and INVISIBLE are not keywords, so that they can still be
+	// used as identifiers
+	ctx.skipWhiteSpaces()
+	if t := ctx.peek(); t.Type == IDENT {
+		switch strings.ToUpper(t.Value) {
+		case "INVISIBLE":
+			if p.version != nil && !p.version.SupportsInvisibleIndexes() {
+				return newParseError(ctx, t, "invisible indexes are not supported by %s", p.version)
+			}
+			ctx.advance()
+			index.SetInvisible(true)
+		case "VISIBLE":
+			ctx.advance()
+		}
+	}
+
 	return nil
 }
 
@@ -1182,6 +3346,36 @@ func (p *Parser) parseColumnIndexForeignKey(ctx *parseCtx, index model.Index) er
 	return nil
 }
 
+// https://dev.mysql.com/doc/refman/8.0/en/create-table-check-constraints.html
+func (p *Parser) parseCheckConstraint(ctx *parseCtx, check model.CheckConstraint) error {
+	ctx.skipWhiteSpaces()
+	if t := ctx.next(); t.Type != CHECK {
+		return newParseError(ctx, t, "expected CHECK")
+	} else if p.version != nil && !p.version.SupportsCheckConstraints() {
+		return newParseError(ctx, t, "CHECK constraints are not supported by %s", p.version)
+	}
+
+	expr, err := p.parseParenthesizedText(ctx)
+	if err != nil {
+		return err
+	}
+	check.SetExpression(expr)
+
+	ctx.skipWhiteSpaces()
+	switch t := ctx.peek(); t.Type {
+	case NOT:
+		ctx.advance()
+		ctx.skipWhiteSpaces()
+		if t := ctx.next(); t.Type != ENFORCED {
+			return newParseError(ctx, t, "expected ENFORCED")
+		}
+		check.SetEnforced(false)
+	case ENFORCED:
+		ctx.advance()
+	}
+	return nil
+}
+
 func (p *Parser) parseReferenceOption(ctx *parseCtx, set func(model.ReferenceOption) model.Reference) error {
 	ctx.skipWhiteSpaces()
 	switch t := ctx.next(); t.Type {
@@ -1218,7 +3412,15 @@ func (p *Parser) parseColumnReference(ctx *parseCtx, index model.Index) error {
 	ctx.skipWhiteSpaces()
 	switch t := ctx.next(); t.Type {
 	case BACKTICK_IDENT, IDENT:
-		r.SetTableName(t.Value)
+		if err := p.checkIdent(ctx, t); err != nil {
+			return err
+		}
+		schema, name, err := p.parseQualifiedName(ctx, t)
+		if err != nil {
+			return err
+		}
+		r.SetSchema(schema)
+		r.SetTableName(name)
 	default:
 		return newParseError(ctx, t, "expected IDENT or BACKTICK_IDENT")
 	}
@@ -1278,6 +3480,9 @@ func (p *Parser) parseColumnIndexName(ctx *parseCtx, index model.Index) error {
 	ctx.skipWhiteSpaces()
 	switch t := ctx.peek(); t.Type {
 	case BACKTICK_IDENT, IDENT:
+		if err := p.checkIdent(ctx, t); err != nil {
+			return err
+		}
 		ctx.advance()
 		index.SetName(t.Value)
 	}
@@ -1330,16 +3535,31 @@ func (p *Parser) parseColumnIndexColumns(ctx *parseCtx, container interface {
 OUTER:
 	for {
 		ctx.skipWhiteSpaces()
-		t := ctx.next()
-		if !(t.Type == IDENT || t.Type == BACKTICK_IDENT) {
+		var col model.IndexColumn
+		switch t := ctx.peek(); t.Type {
+		case LPAREN:
+			// functional key part
+			if p.version != nil && !p.version.SupportsFunctionalIndexes() {
+				return newParseError(ctx, t, "functional key parts are not supported by %s", p.version)
+			}
+			expr, err := p.parseParenthesizedText(ctx)
+			if err != nil {
+				return err
+			}
+			col = model.NewIndexExpression(expr)
+		case IDENT, BACKTICK_IDENT:
+			if err := p.checkIdent(ctx, t); err != nil {
+				return err
+			}
+			ctx.advance()
+			col = model.NewIndexColumn(t.Value)
+		default:
 			return newParseError(ctx, t, "should IDENT or BACKTICK_IDENT")
 		}
-
-		col := model.NewIndexColumn(t.Value)
 		cols = append(cols, col)
 
 		ctx.skipWhiteSpaces()
-		switch t = ctx.next(); t.Type {
+		switch t := ctx.next(); t.Type {
 		case LPAREN:
 			t := ctx.next()
 			if t.Type != NUMBER {
@@ -1356,7 +3576,7 @@ OUTER:
 		}
 
 		// optional sort direction
-		switch t = ctx.peek(); t.Type {
+		switch t := ctx.peek(); t.Type {
 		case ASC:
 			ctx.advance()
 			col.SetSortDirection(model.SortDirectionAscending)
@@ -1366,7 +3586,7 @@ OUTER:
 		}
 
 		ctx.skipWhiteSpaces()
-		switch t = ctx.next(); t.Type {
+		switch t := ctx.next(); t.Type {
 		case COMMA:
 			// search next
 			continue
@@ -1423,9 +3643,47 @@ func (p *Parser) parseColumnIndexOptionValue(ctx *parseCtx, index model.Index, n
 	return newParseError(ctx, t, "expected %v", follow)
 }
 
+// parseParenthesizedText consumes a parenthesized expression, and returns
+// the text between the outermost parenthesis as it was written in the
+// input. This is used for expressions that we do not interpret, such as
+// those in CHECK constraints.
+func (p *Parser) parseParenthesizedText(ctx *parseCtx) (string, error) {
+	ctx.skipWhiteSpaces()
+	lparen := ctx.next()
+	if lparen.Type != LPAREN {
+		return "", newParseError(ctx, lparen, "expected LPAREN")
+	}
+
+	depth := 1
+	for {
+		switch t := ctx.next(); t.Type {
+		case LPAREN:
+			depth++
+		case RPAREN:
+			depth--
+			if depth == 0 {
+				return strings.TrimSpace(string(ctx.input[lparen.Pos+1 : t.Pos])), nil
+			}
+		case EOF:
+			return "", newParseError(ctx, t, "expected RPAREN")
+		}
+	}
+}
+
 // Skips over whitespaces. Once this method returns, you can be
 // certain that next call to ctx.next()/peek() will result in a
 // non-space token
+// skipStatement skips the tokens up to and including the semicolon that
+// ends the current statement
+func (pctx *parseCtx) skipStatement() {
+	for {
+		switch t := pctx.next(); t.Type {
+		case SEMICOLON, EOF:
+			return
+		}
+	}
+}
+
 func (pctx *parseCtx) skipWhiteSpaces() {
 	for {
 		switch t := pctx.peek(); t.Type {
@@ -1438,6 +3696,15 @@ func (pctx *parseCtx) skipWhiteSpaces() {
 	}
 }
 
+// checkIdent returns an error if the token is an unquoted identifier that
+// is a reserved word in the server version that the input is meant for
+func (p *Parser) checkIdent(ctx *parseCtx, t *Token) error {
+	if p.version == nil || t.Type != IDENT || !p.version.IsReservedWord(t.Value) {
+		return nil
+	}
+	return newParseError(ctx, t, "%s is a reserved word in %s, and must be quoted", t.Value, p.version)
+}
+
 func (p *Parser) parseIdents(ctx *parseCtx, idents ...TokenType) ([]string, error) {
 	strs := []string{}
 	for _, ident := range idents {
@@ -20,9 +20,11 @@ func init() {
 }
 
 type Spec struct {
-	Input  string
-	Error  bool
-	Expect string
+	Input      string
+	Error      bool
+	Expect     string
+	Version    string
+	DropTables bool
 }
 
 func TestParser(t *testing.T) {
@@ -34,19 +36,113 @@ func TestParser(t *testing.T) {
 		})
 	}
 
-	// create database are ignored
 	parse("CreateDatabase", &Spec{
-		Input: "create DATABASE hoge",
+		Input:  "create DATABASE hoge",
+		Expect: "CREATE DATABASE `hoge`;",
 	})
 	parse("CreateDatabaseIfNotExists", &Spec{
-		Input: "create DATABASE IF NOT EXISTS hoge",
+		Input:  "create DATABASE IF NOT EXISTS hoge",
+		Expect: "CREATE DATABASE IF NOT EXISTS `hoge`;",
+	})
+	parse("CreateDatabaseOptions", &Spec{
+		Input:  "CREATE DATABASE `hoge` DEFAULT CHARACTER SET utf8mb4 COLLATE utf8mb4_0900_ai_ci DEFAULT ENCRYPTION='N'",
+		Expect: "CREATE DATABASE `hoge` DEFAULT CHARACTER SET = utf8mb4 DEFAULT COLLATE = utf8mb4_0900_ai_ci ENCRYPTION = 'N';",
+	})
+	parse("CreateDatabaseCharsetWithEqual", &Spec{
+		Input:  "create database hoge charset = latin1 collate = 'latin1_bin' encryption 'y';",
+		Expect: "CREATE DATABASE `hoge` DEFAULT CHARACTER SET = latin1 DEFAULT COLLATE = latin1_bin ENCRYPTION = 'Y';",
+	})
+	parse("CreateDatabaseInvalidOption", &Spec{
+		Input: "create database hoge engine = InnoDB",
+		Error: true,
 	})
 	parse("CreateDatabase17", &Spec{
 		Input: "create DATABASE 17",
 		Error: true,
 	})
 	parse("MultipleCreateDatabase", &Spec{
-		Input: "create DATABASE hoge; create database fuga;",
+		Input:  "create DATABASE hoge; create database fuga;",
+		Expect: "CREATE DATABASE `hoge`;CREATE DATABASE `fuga`;",
+	})
+	parse("CreateTableQualifiedName", &Spec{
+		Input:  "create table app.hoge ( id int not null, user_id int not null, foreign key (user_id) references `auth`.`user` (id), foreign key (id) references app.fuga (id) )",
+		Expect: "CREATE TABLE `app`.`hoge` (\n`id` INT (11) NOT NULL,\n`user_id` INT (11) NOT NULL,\nFOREIGN KEY (`user_id`) REFERENCES `auth`.`user` (`id`),\nFOREIGN KEY (`id`) REFERENCES `fuga` (`id`)\n)",
+	})
+	parse("UseDatabase", &Spec{
+		Input:  "create table hoge ( id int not null ); use `app`; create table hoge ( id int not null ); create table other.hoge ( id int not null ); use fuga",
+		Expect: "CREATE TABLE `hoge` (\n`id` INT (11) NOT NULL\n)CREATE TABLE `app`.`hoge` (\n`id` INT (11) NOT NULL\n)CREATE TABLE `other`.`hoge` (\n`id` INT (11) NOT NULL\n)",
+	})
+	parse("UseWithoutDatabase", &Spec{
+		Input: "use;",
+		Error: true,
+	})
+	parse("AlterTableColumns", &Spec{
+		Input:  "alter table hoge add column name varchar(255) not null after id, add age int first, drop column foo, drop bar, modify id bigint not null, change column old_name new_name text, rename column a to b",
+		Expect: "ALTER TABLE `hoge` ADD COLUMN `name` VARCHAR (255) NOT NULL AFTER `id`, ADD COLUMN `age` INT (11) DEFAULT NULL FIRST, DROP COLUMN `foo`, DROP COLUMN `bar`, MODIFY COLUMN `id` BIGINT (20) NOT NULL, CHANGE COLUMN `old_name` `new_name` TEXT, RENAME COLUMN `a` TO `b`",
+	})
+	parse("AlterTableIndexes", &Spec{
+		Input:  "use app; alter table hoge add index idx_name (name), add constraint fk_user foreign key (user_id) references user (id), add primary key (id), drop index idx_old, drop key idx_other, drop primary key, drop foreign key fk_old, rename index a to b",
+		Expect: "ALTER TABLE `app`.`hoge` ADD INDEX `idx_name` (`name`), ADD CONSTRAINT `fk_user` FOREIGN KEY (`user_id`) REFERENCES `user` (`id`), ADD PRIMARY KEY (`id`), DROP INDEX `idx_old`, DROP INDEX `idx_other`, DROP PRIMARY KEY, DROP FOREIGN KEY `fk_old`, RENAME INDEX `a` TO `b`",
+	})
+	parse("AlterTableCheckConstraints", &Spec{
+		Input:  "alter table hoge add constraint chk_age check (age > 0), add check (id > 0) not enforced, drop check chk_old, drop constraint c",
+		Expect: "ALTER TABLE `hoge` ADD CONSTRAINT `chk_age` CHECK (age > 0), ADD CHECK (id > 0) NOT ENFORCED, DROP CHECK `chk_old`, DROP CONSTRAINT `c`",
+	})
+	parse("AlterTableOptions", &Spec{
+		Input:  "alter table hoge engine=InnoDB default charset utf8mb4, comment 'hoge', algorithm=inplace, lock=none; alter table fuga rename to app.piyo",
+		Expect: "ALTER TABLE `hoge` ENGINE = InnoDB DEFAULT CHARACTER SET = utf8mb4, COMMENT = 'hoge'ALTER TABLE `fuga` RENAME TO `app`.`piyo`",
+	})
+	parse("DropTables", &Spec{
+		Input:      "use app; drop table if exists hoge, other.fuga; drop temporary table piyo cascade; drop user bob",
+		Expect:     "DROP TABLE IF EXISTS `app`.`hoge`DROP TABLE IF EXISTS `other`.`fuga`DROP TEMPORARY TABLE `app`.`piyo`",
+		DropTables: true,
+	})
+	parse("DropObjects", &Spec{
+		Input:      "use app; drop view if exists v, other.w cascade; drop trigger trg; drop procedure if exists p; drop function other.f; drop event e; drop schema other",
+		Expect:     "DROP VIEW IF EXISTS `app`.`v`DROP VIEW IF EXISTS `other`.`w`DROP TRIGGER `app`.`trg`DROP PROCEDURE IF EXISTS `app`.`p`DROP FUNCTION `other`.`f`DROP EVENT `app`.`e`DROP DATABASE `other`",
+		DropTables: true,
+	})
+	parse("DropObjectsSkipped", &Spec{
+		Input:  "drop view v; drop index idx on hoge; drop database app",
+		Expect: "",
+	})
+	parse("DropSeveralProcedures", &Spec{
+		Input:      "drop procedure p, q",
+		Error:      true,
+		DropTables: true,
+	})
+	parse("DropIndex", &Spec{
+		Input:      "drop index idx on app.hoge algorithm = inplace lock = none; drop index `PRIMARY` on hoge",
+		Expect:     "ALTER TABLE `app`.`hoge` DROP INDEX `idx`ALTER TABLE `hoge` DROP INDEX `PRIMARY`",
+		DropTables: true,
+	})
+	parse("CreateIndex", &Spec{
+		Input:  "create index idx on hoge (a(10), b desc); create unique index uniq using btree on app.hoge (a) invisible lock = default; create fulltext index ft on hoge (body)",
+		Expect: "ALTER TABLE `hoge` ADD INDEX `idx` (`a`(10), `b` DESC)ALTER TABLE `app`.`hoge` ADD UNIQUE INDEX `uniq` USING BTREE (`a`) INVISIBLEALTER TABLE `hoge` ADD FULLTEXT INDEX `ft` (`body`)",
+	})
+	parse("CreateIndexWithoutTable", &Spec{
+		Input: "create index idx (a)",
+		Error: true,
+	})
+	parse("RenameTable", &Spec{
+		Input:  "rename table hoge to fuga, app.piyo to other.piyo",
+		Expect: "ALTER TABLE `hoge` RENAME TO `fuga`ALTER TABLE `app`.`piyo` RENAME TO `other`.`piyo`",
+	})
+	parse("DataStatements", &Spec{
+		Input:  "insert into hoge values (1, 'a;b'); create table hoge (id int not null); update hoge set id = 2; delete from hoge; replace into hoge values (3); truncate table hoge",
+		Expect: "CREATE TABLE `hoge` (\n`id` INT (11) NOT NULL\n)",
+	})
+	parse("TransactionStatements", &Spec{
+		Input:  "begin; start transaction; create table hoge (id int not null); commit; rollback",
+		Expect: "CREATE TABLE `hoge` (\n`id` INT (11) NOT NULL\n)",
+	})
+	parse("AlterTableUnsupported", &Spec{
+		Input: "alter table hoge add partition (partition p1 values less than (10))",
+		Error: true,
+	})
+	parse("AlterDatabase", &Spec{
+		Input: "alter database hoge character set utf8mb4",
+		Error: true,
 	})
 	parse("CreateTableIntegerNoWidth", &Spec{
 		Input:  "create table hoge_table ( id integer unsigned not null)",
@@ -297,16 +393,241 @@ primary key (id, c)
 			"/*!40101 SET character_set_client = @saved_cs_client */;",
 		Expect: "CREATE TABLE `test_tb` (\n`t_id` CHAR (17) NOT NULL,\n`t_type` SMALLINT (6) NOT NULL,\n`cur_date` DATETIME NOT NULL\n) ENGINE = InnoDB, DEFAULT CHARACTER SET = utf8",
 	})
+	parse("CheckConstraint", &Spec{
+		Input:  "CREATE TABLE foo (a INT NOT NULL, b INT NOT NULL, CHECK (a > 0), CONSTRAINT `b_gt_a` CHECK ((`b` > `a`)) NOT ENFORCED)",
+		Expect: "CREATE TABLE `foo` (\n`a` INT (11) NOT NULL,\n`b` INT (11) NOT NULL,\nCONSTRAINT `foo_chk_1` CHECK (a > 0),\nCONSTRAINT `b_gt_a` CHECK ((`b` > `a`)) NOT ENFORCED\n)",
+	})
+	parse("CheckConstraintWithIndex", &Spec{
+		Input:  "CREATE TABLE foo (a INT NOT NULL, PRIMARY KEY (a), CONSTRAINT `a_chk` CHECK (a in (1, 2)) ENFORCED)",
+		Expect: "CREATE TABLE `foo` (\n`a` INT (11) NOT NULL,\nPRIMARY KEY (`a`),\nCONSTRAINT `a_chk` CHECK (a in (1, 2))\n)",
+	})
+	parse("CheckConstraintUnterminated", &Spec{
+		Input: "CREATE TABLE foo (a INT NOT NULL, CHECK (a > (0)",
+		Error: true,
+	})
+	parse("GeneratedColumns", &Spec{
+		Input:  "CREATE TABLE foo (a INT NOT NULL, b INT GENERATED ALWAYS AS (a + 1) VIRTUAL, c INT AS ((`a` * 2)) STORED NOT NULL COMMENT 'double', d INT AS (a))",
+		Expect: "CREATE TABLE `foo` (\n`a` INT (11) NOT NULL,\n`b` INT (11) GENERATED ALWAYS AS (a + 1) VIRTUAL,\n`c` INT (11) GENERATED ALWAYS AS ((`a` * 2)) STORED NOT NULL COMMENT 'double',\n`d` INT (11) GENERATED ALWAYS AS (a) VIRTUAL\n)",
+	})
+	parse("GeneratedColumnWithoutAs", &Spec{
+		Input: "CREATE TABLE foo (a INT NOT NULL, b INT GENERATED ALWAYS (a + 1))",
+		Error: true,
+	})
+	parse("PartitionByRange", &Spec{
+		Input:  "CREATE TABLE foo (id INT NOT NULL, created DATE NOT NULL) ENGINE=InnoDB PARTITION BY RANGE (YEAR(created)) (PARTITION p0 VALUES LESS THAN (2000) ENGINE = InnoDB, PARTITION pmax VALUES LESS THAN MAXVALUE COMMENT 'rest')",
+		Expect: "CREATE TABLE `foo` (\n`id` INT (11) NOT NULL,\n`created` DATE NOT NULL\n) ENGINE = InnoDB\nPARTITION BY RANGE (YEAR(created))\n(PARTITION `p0` VALUES LESS THAN (2000) ENGINE = InnoDB,\nPARTITION `pmax` VALUES LESS THAN MAXVALUE COMMENT = 'rest')",
+	})
+	parse("PartitionByListColumns", &Spec{
+		Input:  "CREATE TABLE foo (region VARCHAR(10) NOT NULL) PARTITION BY LIST COLUMNS (region) (PARTITION pe VALUES IN ('east', 'north'), PARTITION pw VALUES IN ('west'))",
+		Expect: "CREATE TABLE `foo` (\n`region` VARCHAR (10) NOT NULL\n)\nPARTITION BY LIST COLUMNS (region)\n(PARTITION `pe` VALUES IN ('east', 'north'),\nPARTITION `pw` VALUES IN ('west'))",
+	})
+	parse("PartitionByHash", &Spec{
+		Input:  "CREATE TABLE foo (id INT NOT NULL) PARTITION BY LINEAR HASH (id) PARTITIONS 4;",
+		Expect: "CREATE TABLE `foo` (\n`id` INT (11) NOT NULL\n)\nPARTITION BY LINEAR HASH (id) PARTITIONS 4",
+	})
+	parse("PartitionByKeyAlgorithm", &Spec{
+		Input:  "CREATE TABLE foo (id INT NOT NULL) PARTITION BY KEY ALGORITHM=2 (id) PARTITIONS 2",
+		Expect: "CREATE TABLE `foo` (\n`id` INT (11) NOT NULL\n)\nPARTITION BY KEY ALGORITHM = 2 (id) PARTITIONS 2",
+	})
+	parse("PartitionWithSubPartitions", &Spec{
+		Input:  "CREATE TABLE foo (id INT NOT NULL, created DATE NOT NULL) PARTITION BY RANGE (YEAR(created)) SUBPARTITION BY HASH (TO_DAYS(created)) (PARTITION p0 VALUES LESS THAN (2000) (SUBPARTITION s0, SUBPARTITION s1), PARTITION p1 VALUES LESS THAN MAXVALUE (SUBPARTITION s2, SUBPARTITION s3))",
+		Expect: "CREATE TABLE `foo` (\n`id` INT (11) NOT NULL,\n`created` DATE NOT NULL\n)\nPARTITION BY RANGE (YEAR(created))\nSUBPARTITION BY HASH (TO_DAYS(created))\n(PARTITION `p0` VALUES LESS THAN (2000) (SUBPARTITION `s0`, SUBPARTITION `s1`),\nPARTITION `p1` VALUES LESS THAN MAXVALUE (SUBPARTITION `s2`, SUBPARTITION `s3`))",
+	})
+	parse("PartitionRangeWithoutValues", &Spec{
+		Input: "CREATE TABLE foo (id INT NOT NULL) PARTITION BY RANGE (id) (PARTITION p0)",
+		Error: true,
+	})
 	parse("WhiteSpacesBetweenTableOptionsAndSemicolon", &Spec{
 		Input:  "CREATE TABLE foo (id INT(10) NOT NULL) ENGINE = InnoDB, DEFAULT CHARACTER SET = utf8mb4 \n/**/ ;",
 		Expect: "CREATE TABLE `foo` (\n`id` INT (10) NOT NULL\n) ENGINE = InnoDB, DEFAULT CHARACTER SET = utf8mb4",
 	})
+
+	// server version specific syntax
+	parse("ReservedWordColumnName57", &Spec{
+		Input:   "CREATE TABLE foo (rank INT NOT NULL)",
+		Expect:  "CREATE TABLE `foo` (\n`rank` INT (11) NOT NULL\n)",
+		Version: "5.7",
+	})
+	parse("ReservedWordColumnName80", &Spec{
+		Input:   "CREATE TABLE foo (rank INT NOT NULL)",
+		Error:   true,
+		Version: "8.0",
+	})
+	parse("QuotedReservedWordColumnName80", &Spec{
+		Input:   "CREATE TABLE foo (`rank` INT NOT NULL)",
+		Expect:  "CREATE TABLE `foo` (\n`rank` INT (11) NOT NULL\n)",
+		Version: "8.0",
+	})
+	parse("CheckConstraint57", &Spec{
+		Input:   "CREATE TABLE foo (id INT NOT NULL, CONSTRAINT chk CHECK (id > 0))",
+		Error:   true,
+		Version: "5.7",
+	})
+	parse("InvisibleColumn", &Spec{
+		Input:   "CREATE TABLE foo (id INT NOT NULL, secret TEXT INVISIBLE)",
+		Expect:  "CREATE TABLE `foo` (\n`id` INT (11) NOT NULL,\n`secret` TEXT INVISIBLE\n)",
+		Version: "8.0.23",
+	})
+	parse("InvisibleColumn8022", &Spec{
+		Input:   "CREATE TABLE foo (id INT NOT NULL, secret TEXT INVISIBLE)",
+		Error:   true,
+		Version: "8.0.22",
+	})
+	parse("InvisibleIndex", &Spec{
+		Input:  "CREATE TABLE foo (id INT NOT NULL, INDEX idx_id (id) INVISIBLE)",
+		Expect: "CREATE TABLE `foo` (\n`id` INT (11) NOT NULL,\nINDEX `idx_id` (`id`) INVISIBLE\n)",
+	})
+	parse("FunctionalIndex", &Spec{
+		Input:   "CREATE TABLE foo (name VARCHAR (64) NOT NULL, INDEX idx_name ((LOWER(name))))",
+		Expect:  "CREATE TABLE `foo` (\n`name` VARCHAR (64) NOT NULL,\nINDEX `idx_name` ((LOWER(name)))\n)",
+		Version: "8.0.13",
+	})
+	parse("FunctionalIndex57", &Spec{
+		Input:   "CREATE TABLE foo (name VARCHAR (64) NOT NULL, INDEX idx_name ((LOWER(name))))",
+		Error:   true,
+		Version: "5.7",
+	})
+
+	// MariaDB
+	parse("MariaDBShowCreateTable", &Spec{
+		Input: "CREATE TABLE `foo` (\n" +
+			"  `id` uuid NOT NULL DEFAULT uuid(),\n" +
+			"  `ip` inet6 DEFAULT NULL,\n" +
+			"  `n` int(11) NOT NULL DEFAULT (1 + 1),\n" +
+			"  `j` longtext CHARACTER SET utf8mb4 COLLATE utf8mb4_bin DEFAULT NULL CHECK (json_valid(`j`)),\n" +
+			"  `h` varchar(10) DEFAULT NULL INVISIBLE,\n" +
+			"  `created_at` timestamp NOT NULL DEFAULT current_timestamp() ON UPDATE current_timestamp(),\n" +
+			"  `t` datetime(6) NOT NULL DEFAULT current_timestamp(6),\n" +
+			"  PRIMARY KEY (`id`)\n" +
+			") ENGINE=Aria DEFAULT CHARSET=utf8mb4 PAGE_CHECKSUM=1 TRANSACTIONAL=1 WITH SYSTEM VERSIONING",
+		Expect: "CREATE TABLE `foo` (\n" +
+			"`id` UUID NOT NULL DEFAULT uuid(),\n" +
+			"`ip` INET6 DEFAULT NULL,\n" +
+			"`n` INT (11) NOT NULL DEFAULT (1 + 1),\n" +
+			"`j` LONGTEXT CHARACTER SET `utf8mb4` COLLATE `utf8mb4_bin` DEFAULT NULL CHECK (json_valid(`j`)),\n" +
+			"`h` VARCHAR (10) DEFAULT NULL INVISIBLE,\n" +
+			"`created_at` TIMESTAMP ON UPDATE CURRENT_TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,\n" +
+			"`t` DATETIME (6) NOT NULL DEFAULT CURRENT_TIMESTAMP(6),\n" +
+			"PRIMARY KEY (`id`)\n" +
+			") ENGINE = Aria, DEFAULT CHARACTER SET = utf8mb4, PAGE_CHECKSUM = 1, TRANSACTIONAL = 1 WITH SYSTEM VERSIONING",
+		Version: "10.11.2-MariaDB-log",
+	})
+	parse("MariaDBReservedWord", &Spec{
+		Input:   "CREATE TABLE foo (rank INT NOT NULL, `rows` INT NOT NULL)",
+		Expect:  "CREATE TABLE `foo` (\n`rank` INT (11) NOT NULL,\n`rows` INT (11) NOT NULL\n)",
+		Version: "MariaDB 10.6",
+	})
+	parse("UUIDColumnMySQL", &Spec{
+		Input:   "CREATE TABLE foo (id UUID NOT NULL)",
+		Error:   true,
+		Version: "8.0",
+	})
+	parse("UUIDColumnMariaDB106", &Spec{
+		Input:   "CREATE TABLE foo (id UUID NOT NULL)",
+		Error:   true,
+		Version: "10.6-MariaDB",
+	})
+	parse("SystemVersioningMySQL", &Spec{
+		Input:   "CREATE TABLE foo (id INT NOT NULL) WITH SYSTEM VERSIONING",
+		Error:   true,
+		Version: "8.0",
+	})
+	parse("PageChecksumMySQL", &Spec{
+		Input:   "CREATE TABLE foo (id INT NOT NULL) PAGE_CHECKSUM=1",
+		Error:   true,
+		Version: "8.0",
+	})
+	parse("CreateView", &Spec{
+		Input:  "create view v as select a,  b\n  from t where ( a > 1 ) ;",
+		Expect: "CREATE VIEW `v` AS select a, b from t where (a > 1)",
+	})
+	parse("CreateViewShowCreateView", &Spec{
+		Input:  "CREATE ALGORITHM=UNDEFINED DEFINER=`root`@`localhost` SQL SECURITY DEFINER VIEW `v` AS select `t`.`a` AS `a`,'x;y' AS `b` from `t`",
+		Expect: "CREATE ALGORITHM = UNDEFINED DEFINER = `root`@`localhost` SQL SECURITY DEFINER VIEW `v` AS select `t`.`a` AS `a`,'x;y' AS `b` from `t`",
+	})
+	parse("CreateOrReplaceViewColumnsCheckOption", &Spec{
+		Input:  "CREATE OR REPLACE VIEW v (x, `y`) AS SELECT a, b FROM t WITH LOCAL CHECK OPTION",
+		Expect: "CREATE OR REPLACE VIEW `v` (`x`, `y`) AS SELECT a, b FROM t WITH LOCAL CHECK OPTION",
+	})
+	parse("CreateViewDefaultCheckOption", &Spec{
+		Input:  "CREATE VIEW v AS SELECT a FROM t WITH CHECK OPTION",
+		Expect: "CREATE VIEW `v` AS SELECT a FROM t WITH CASCADED CHECK OPTION",
+	})
+	parse("CreateViewWithoutDefinition", &Spec{
+		Input: "CREATE VIEW v AS ;",
+		Error: true,
+	})
+	parse("CreateTrigger", &Spec{
+		Input:  "CREATE TRIGGER trg BEFORE INSERT ON t FOR EACH ROW SET NEW.a = 1;",
+		Expect: "CREATE TRIGGER `trg` BEFORE INSERT ON `t` FOR EACH ROW SET NEW.a = 1",
+	})
+	parse("CreateTriggerQualifiedTable", &Spec{
+		Input:  "USE a; CREATE TRIGGER trg BEFORE INSERT ON b.t FOR EACH ROW SET NEW.a = 1;",
+		Expect: "CREATE TRIGGER `b`.`trg` BEFORE INSERT ON `b`.`t` FOR EACH ROW SET NEW.a = 1",
+	})
+	parse("CreateTriggerDelimiter", &Spec{
+		Input: `DELIMITER //
+CREATE DEFINER=` + "`root`@`localhost`" + ` TRIGGER trg AFTER UPDATE ON t FOR EACH ROW FOLLOWS other
+BEGIN
+  -- keep the history
+  INSERT INTO h VALUES (OLD.id, 'a;b') ;
+  SET @n = @n + 1;
+END//
+DELIMITER ;
+CREATE TABLE foo (id INT);`,
+		Expect: "CREATE DEFINER = `root`@`localhost` TRIGGER `trg` AFTER UPDATE ON `t` FOR EACH ROW FOLLOWS `other` BEGIN INSERT INTO h VALUES (OLD.id, 'a;b'); SET @n = @n + 1; END" +
+			"CREATE TABLE `foo` (\n`id` INT (11) DEFAULT NULL\n)",
+	})
+	parse("CreateTriggerInvalidEvent", &Spec{
+		Input: "CREATE TRIGGER trg BEFORE SELECT ON t FOR EACH ROW SET NEW.a = 1;",
+		Error: true,
+	})
+	parse("CreateProcedure", &Spec{
+		Input: `DELIMITER ;;
+CREATE DEFINER=` + "`root`@`%`" + ` PROCEDURE ` + "`p`" + `(IN a int unsigned, OUT b varchar(10) character set utf8mb4, INOUT c DECIMAL(10, 2))
+    READS SQL DATA
+    COMMENT 'does things'
+BEGIN
+  SELECT a INTO b;
+END;;
+DELIMITER ;`,
+		Expect: "CREATE DEFINER = `root`@`%` PROCEDURE `p` (IN `a` INT UNSIGNED, OUT `b` VARCHAR(10) CHARSET utf8mb4, INOUT `c` DECIMAL(10, 2)) COMMENT 'does things' READS SQL DATA BEGIN SELECT a INTO b; END",
+	})
+	parse("CreateFunction", &Spec{
+		Input:  "CREATE FUNCTION f(x INT) RETURNS varchar(20) CHARSET utf8mb4 NOT DETERMINISTIC LANGUAGE SQL SQL SECURITY INVOKER DETERMINISTIC RETURN CONCAT('x', x);",
+		Expect: "CREATE FUNCTION `f` (`x` INT) RETURNS VARCHAR(20) CHARSET utf8mb4 DETERMINISTIC SQL SECURITY INVOKER RETURN CONCAT('x', x)",
+	})
+	parse("CreateFunctionWithoutReturns", &Spec{
+		Input: "CREATE FUNCTION f() RETURN 1;",
+		Error: true,
+	})
+	parse("CreateEvent", &Spec{
+		Input:  "CREATE DEFINER=`root`@`localhost` EVENT `purge` ON SCHEDULE EVERY 1 day STARTS '2024-01-01 00:00:00' ON COMPLETION NOT PRESERVE DISABLE ON SLAVE COMMENT 'purge logs' DO DELETE FROM logs WHERE created_at < now() - INTERVAL 30 DAY",
+		Expect: "CREATE DEFINER = `root`@`localhost` EVENT `purge` ON SCHEDULE EVERY 1 DAY STARTS '2024-01-01 00:00:00' DISABLE ON SLAVE COMMENT 'purge logs' DO DELETE FROM logs WHERE created_at < now() - INTERVAL 30 DAY",
+	})
+	parse("CreateEventAt", &Spec{
+		Input:  "CREATE EVENT e ON SCHEDULE AT current_timestamp + interval 1 hour ON COMPLETION PRESERVE DO BEGIN END",
+		Expect: "CREATE EVENT `e` ON SCHEDULE AT CURRENT_TIMESTAMP + INTERVAL 1 HOUR ON COMPLETION PRESERVE DO BEGIN END",
+	})
+	parse("CreateEventWithoutSchedule", &Spec{
+		Input: "CREATE EVENT e DO SELECT 1",
+		Error: true,
+	})
+	parse("CreateOrReplaceTable", &Spec{
+		Input: "CREATE OR REPLACE TABLE foo (id INT)",
+		Error: true,
+	})
 }
 
 func testParse(t *testing.T, spec *Spec) {
 	t.Helper()
 
-	p := schemalex.New()
+	var options []schemalex.Option
+	if spec.Version != "" {
+		options = append(options, schemalex.WithServerVersion(spec.Version))
+	}
+
+	p := schemalex.New(append(options, schemalex.WithDropTables(spec.DropTables))...)
 	t.Logf("Parsing '%s'", spec.Input)
 	stmts, err := p.ParseString(spec.Input)
 	if spec.Error {
@@ -320,7 +641,7 @@ func testParse(t *testing.T, spec *Spec) {
 		}
 
 		var buf bytes.Buffer
-		if !assert.NoError(t, format.SQL(&buf, stmts), `format.SQL should succeed`) {
+		if !assert.NoError(t, format.SQL(&buf, stmts, options...), `format.SQL should succeed`) {
 			return
 		}
 
@@ -12,8 +12,10 @@ import (
 	"net/url"
 	"os"
 	"os/exec"
+	"regexp"
 	"strconv"
 	"strings"
+	"sync"
 
 	"github.com/go-sql-driver/mysql"
 	"github.com/schemalex/schemalex/internal/errors"
@@ -41,9 +43,28 @@ type localGitSource struct {
 	commitish string
 }
 
+// SchemaSourceFunc creates a SchemaSource from a URI with the scheme
+// that it is registered for by RegisterSchemaSource
+type SchemaSourceFunc func(*url.URL) (SchemaSource, error)
+
+var schemaSourcesMu sync.RWMutex
+var schemaSources = make(map[string]SchemaSourceFunc)
+
+// RegisterSchemaSource makes the URI scheme available to NewSchemaSource.
+// This allows the sources that are implemented in other packages, which
+// cannot be imported from this package, to be specified by URIs. For
+// example, the "migrations" scheme is registered by importing the
+// migrations package
+func RegisterSchemaSource(scheme string, fn SchemaSourceFunc) {
+	schemaSourcesMu.Lock()
+	defer schemaSourcesMu.Unlock()
+	schemaSources[strings.ToLower(scheme)] = fn
+}
+
 // NewSchemaSource creates a SchemaSource based on the given URI.
 // Currently "-" (for stdin), "local-git://...", "mysql://...", and
-// "file://..." are supported. A string that does not match any of
+// "file://..." are supported, along with the schemes registered by
+// RegisterSchemaSource. A string that does not match any of
 // the above patterns and has no scheme part is treated as a local file.
 func NewSchemaSource(uri string) (SchemaSource, error) {
 	// "-" is a special source, denoting stdin.
@@ -76,6 +97,13 @@ func NewSchemaSource(uri string) (SchemaSource, error) {
 		return NewLocalFileSource(u.Path), nil
 	}
 
+	schemaSourcesMu.RLock()
+	fn, ok := schemaSources[strings.ToLower(u.Scheme)]
+	schemaSourcesMu.RUnlock()
+	if ok {
+		return fn(u)
+	}
+
 	return nil, errors.New("invalid source")
 }
 
@@ -96,7 +124,13 @@ func NewReaderSource(src io.Reader) SchemaSource {
 //
 // Please note that the "tls" parameter MUST BE A BOOLEAN. Otherwise
 // we expect that you have already registered your tls configuration
-// manually, and that you gave us the name of that configuration
+// manually, and that you gave us the name of that configuration.
+//
+// The "databases" parameter lists the databases whose schemas are
+// written, separated by commas (e.g. "databases=app,logs"). Each one is
+// written as CREATE DATABASE followed by USE, so that the tables are
+// qualified by the databases that they belong to. Without it, only the
+// schema of the database named in the DSN is written
 func NewMySQLSource(s string) SchemaSource {
 	return mysqlSource(s)
 }
@@ -175,14 +209,29 @@ func (s mysqlSource) MySQLConfig() (*mysql.Config, error) {
 	return cfg, nil
 }
 
-func (s mysqlSource) open() (*sql.DB, error) {
+// open opens the connection to mysql, and returns it along with the list
+// of the databases given by the "databases" parameter
+func (s mysqlSource) open() (*sql.DB, []string, error) {
 	// attempt to open connection to mysql
 	cfg, err := s.MySQLConfig()
 	if err != nil {
-		return nil, errors.Wrap(err, `failed to create MySQL config from source spec`)
+		return nil, nil, errors.Wrap(err, `failed to create MySQL config from source spec`)
 	}
 
-	return sql.Open("mysql", cfg.FormatDSN())
+	// the parameters are otherwise sent to the server as system variables
+	var databases []string
+	for _, name := range strings.Split(cfg.Params["databases"], ",") {
+		if name = strings.TrimSpace(name); name != "" {
+			databases = append(databases, name)
+		}
+	}
+	delete(cfg.Params, "databases")
+
+	db, err := sql.Open("mysql", cfg.FormatDSN())
+	if err != nil {
+		return nil, nil, err
+	}
+	return db, databases, nil
 }
 
 func (s localFileSource) WriteSchema(dst io.Writer) error {
@@ -199,38 +248,224 @@ func (s localFileSource) WriteSchema(dst io.Writer) error {
 }
 
 func (s mysqlSource) WriteSchema(dst io.Writer) error {
-	db, err := s.open()
+	db, databases, err := s.open()
 	if err != nil {
 		return errors.Wrap(err, `failed to open connection to database`)
 	}
 	defer db.Close()
 
-	tableRows, err := db.Query("SHOW TABLES")
+	var buf bytes.Buffer
+	if len(databases) == 0 {
+		if err := writeDatabaseSchema(&buf, db, ""); err != nil {
+			return err
+		}
+		return NewReaderSource(&buf).WriteSchema(dst)
+	}
+
+	for _, database := range databases {
+		l, err := queryColumn(db, "SHOW CREATE DATABASE "+backquote(database), "Create Database")
+		if err != nil {
+			return err
+		}
+		if len(l) == 0 {
+			return errors.Errorf(`database '%s' not found`, database)
+		}
+		if buf.Len() > 0 {
+			buf.WriteString("\n\n")
+		}
+		buf.WriteString(unwrapVersionedComments(l[0]))
+		buf.WriteString(";\n\nUSE ")
+		buf.WriteString(backquote(database))
+		buf.WriteByte(';')
+		if err := writeDatabaseSchema(&buf, db, database); err != nil {
+			return err
+		}
+	}
+	return NewReaderSource(&buf).WriteSchema(dst)
+}
+
+// writeDatabaseSchema writes the schema of the database, or the one
+// that the connection uses if database is empty
+func writeDatabaseSchema(buf *bytes.Buffer, db *sql.DB, database string) error {
+	// qualify returns the name qualified by the database, if given
+	qualify := func(name string) string {
+		if database == "" {
+			return backquote(name)
+		}
+		return backquote(database) + "." + backquote(name)
+	}
+	var from, currentDatabase = "", "DATABASE()"
+	if database != "" {
+		from = " FROM " + backquote(database)
+		currentDatabase = "'" + strings.NewReplacer(`\`, `\\`, `'`, `\'`).Replace(database) + "'"
+	}
+
+	tableRows, err := db.Query("SHOW FULL TABLES" + from)
 	if err != nil {
-		return errors.Wrap(err, `failed to execute 'SHOW TABLES'`)
+		return errors.Wrapf(err, `failed to execute 'SHOW FULL TABLES%s'`, from)
 	}
 	defer tableRows.Close()
 
 	var table string
+	var tableType string
 	var tableSchema string
-	var buf bytes.Buffer
+	var views []string
 	for tableRows.Next() {
-		if err = tableRows.Scan(&table); err != nil {
+		if err = tableRows.Scan(&table, &tableType); err != nil {
 			return errors.Wrap(err, `failed to scan tables`)
 		}
 
-		if err = db.QueryRow("SHOW CREATE TABLE `"+table+"`").Scan(&table, &tableSchema); err != nil {
+		switch tableType {
+		case "VIEW":
+			// views are written after the tables, as they may refer to them
+			views = append(views, table)
+			continue
+		case "BASE TABLE", "SYSTEM VERSIONED":
+		default:
+			// sequences (MariaDB) are not supported
+			continue
+		}
+
+		if err = db.QueryRow("SHOW CREATE TABLE "+qualify(table)).Scan(&table, &tableSchema); err != nil {
 			return errors.Wrapf(err, `failed to execute 'SHOW CREATE TABLE "%s"'`, table)
 		}
 		if buf.Len() > 0 {
 			buf.WriteString("\n\n")
 		}
-		// TODO remove dynamic info. ex) AUTO_INCREMENT,PARTITION
+		// TODO remove dynamic info. ex) AUTO_INCREMENT
+		buf.WriteString(unwrapVersionedComments(tableSchema))
+		buf.WriteByte(';')
+	}
+	if err = tableRows.Err(); err != nil {
+		return errors.Wrap(err, `failed to read tables`)
+	}
+
+	var charset, collation string
+	for _, view := range views {
+		if err = db.QueryRow("SHOW CREATE VIEW "+qualify(view)).Scan(&view, &tableSchema, &charset, &collation); err != nil {
+			return errors.Wrapf(err, `failed to execute 'SHOW CREATE VIEW "%s"'`, view)
+		}
+		if buf.Len() > 0 {
+			buf.WriteString("\n\n")
+		}
 		buf.WriteString(tableSchema)
 		buf.WriteByte(';')
 	}
 
-	return NewReaderSource(&buf).WriteSchema(dst)
+	// routines are written before the triggers and the events, as they
+	// may call them
+	objects := []struct {
+		list         string
+		nameColumn   string
+		create       string
+		createColumn string
+	}{
+		{"SHOW PROCEDURE STATUS WHERE Db = " + currentDatabase, "Name", "SHOW CREATE PROCEDURE", "Create Procedure"},
+		{"SHOW FUNCTION STATUS WHERE Db = " + currentDatabase, "Name", "SHOW CREATE FUNCTION", "Create Function"},
+		{"SHOW TRIGGERS" + from, "Trigger", "SHOW CREATE TRIGGER", "SQL Original Statement"},
+		{"SHOW EVENTS" + from, "Name", "SHOW CREATE EVENT", "Create Event"},
+	}
+	for _, object := range objects {
+		names, err := queryColumn(db, object.list, object.nameColumn)
+		if err != nil {
+			return err
+		}
+		for _, name := range names {
+			l, err := queryColumn(db, object.create+" "+qualify(name), object.createColumn)
+			if err != nil {
+				return err
+			}
+			if len(l) == 0 {
+				continue
+			}
+			if buf.Len() > 0 {
+				buf.WriteString("\n\n")
+			}
+			writeDelimited(buf, l[0])
+		}
+	}
+	return nil
+}
+
+func backquote(s string) string {
+	return "`" + strings.Replace(s, "`", "``", -1) + "`"
+}
+
+// writeDelimited writes a statement whose body may contain semicolons,
+// changing the statement delimiter around it
+func writeDelimited(buf *bytes.Buffer, stmt string) {
+	buf.WriteString("DELIMITER ;;\n")
+	buf.WriteString(stmt)
+	buf.WriteString(";;\nDELIMITER ;")
+}
+
+// queryColumn executes the query, and returns the values of the named
+// column. This is used for the SHOW statements whose other columns vary
+// between the versions of the server
+func queryColumn(db *sql.DB, query, column string) ([]string, error) {
+	rows, err := db.Query(query)
+	if err != nil {
+		return nil, errors.Wrapf(err, `failed to execute '%s'`, query)
+	}
+	defer rows.Close()
+
+	columns, err := rows.Columns()
+	if err != nil {
+		return nil, errors.Wrapf(err, `failed to get columns of '%s'`, query)
+	}
+	index := -1
+	for i, name := range columns {
+		if strings.EqualFold(name, column) {
+			index = i
+		}
+	}
+	if index < 0 {
+		return nil, errors.Errorf(`column '%s' not found in '%s'`, column, query)
+	}
+
+	var values []string
+	dest := make([]interface{}, len(columns))
+	for rows.Next() {
+		var value sql.NullString
+		for i := range dest {
+			dest[i] = new(sql.RawBytes)
+		}
+		dest[index] = &value
+		if err := rows.Scan(dest...); err != nil {
+			return nil, errors.Wrapf(err, `failed to scan '%s'`, query)
+		}
+		values = append(values, value.String)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, errors.Wrapf(err, `failed to read '%s'`, query)
+	}
+	return values, nil
+}
+
+// versionedClauses lists the clauses that MySQL wraps in version
+// specific comments (e.g. `/*!80016 NOT ENFORCED */`) in the output of
+// SHOW CREATE TABLE, but which we want the parser to see. MariaDB uses
+// six digit versions (e.g. `/*!100301 ... */`) and `/*M!` for comments
+// that only MariaDB executes.
+var versionedClauses = []string{
+	"DEFAULT CHARACTER SET",
+	"DEFAULT ENCRYPTION",
+	"NOT ENFORCED",
+	"PARTITION BY",
+}
+
+var versionedCommentRx = regexp.MustCompile(`(?s)/\*M?!\d{5,6}\s+(.*?)\s*\*/`)
+
+func unwrapVersionedComments(s string) string {
+	return versionedCommentRx.ReplaceAllStringFunc(s, func(comment string) string {
+		clause := versionedCommentRx.FindStringSubmatch(comment)[1]
+		for _, prefix := range versionedClauses {
+			if strings.HasPrefix(clause, prefix) {
+				return clause
+			}
+		}
+		return comment
+	})
 }
 
 func (s localGitSource) WriteSchema(dst io.Writer) error {
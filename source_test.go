@@ -262,3 +262,37 @@ func TestSchemaSource(t *testing.T) {
 		})
 	}
 }
+
+func TestUnwrapVersionedComments(t *testing.T) {
+	testcases := []struct {
+		Input  string
+		Expect string
+	}{
+		{
+			Input:  "CONSTRAINT `c` CHECK ((`a` > 0)) /*!80016 NOT ENFORCED */",
+			Expect: "CONSTRAINT `c` CHECK ((`a` > 0)) NOT ENFORCED",
+		},
+		{
+			Input:  ") ENGINE=InnoDB DEFAULT CHARSET=utf8mb4\n/*!50100 PARTITION BY HASH (`id`)\nPARTITIONS 4 */",
+			Expect: ") ENGINE=InnoDB DEFAULT CHARSET=utf8mb4\nPARTITION BY HASH (`id`)\nPARTITIONS 4",
+		},
+		{
+			Input:  "KEY `created_at` (`created_at` DESC) /*!80000 INVISIBLE */",
+			Expect: "KEY `created_at` (`created_at` DESC) /*!80000 INVISIBLE */",
+		},
+		{
+			Input:  ") ENGINE=InnoDB DEFAULT CHARSET=utf8mb4\n/*!100301 PARTITION BY HASH (`id`)\nPARTITIONS 4 */",
+			Expect: ") ENGINE=InnoDB DEFAULT CHARSET=utf8mb4\nPARTITION BY HASH (`id`)\nPARTITIONS 4",
+		},
+		{
+			Input:  "CREATE DATABASE `app` /*!40100 DEFAULT CHARACTER SET utf8mb4 COLLATE utf8mb4_0900_ai_ci */ /*!80016 DEFAULT ENCRYPTION='N' */",
+			Expect: "CREATE DATABASE `app` DEFAULT CHARACTER SET utf8mb4 COLLATE utf8mb4_0900_ai_ci DEFAULT ENCRYPTION='N'",
+		},
+	}
+
+	for _, c := range testcases {
+		if !assert.Equal(t, c.Expect, unwrapVersionedComments(c.Input), "result should match") {
+			return
+		}
+	}
+}
@@ -30,19 +30,20 @@ const (
 	DOUBLE_QUOTE_IDENT
 	SINGLE_QUOTE_IDENT
 	NUMBER
-	LPAREN        // (
-	RPAREN        // )
-	COMMA         // ,
-	SEMICOLON     // ;
-	DOT           // .
-	SLASH         // /
-	ASTERISK      // *
-	DASH          // -
-	PLUS          // +
-	SINGLE_QUOTE  // '
-	DOUBLE_QUOTE  // "
-	EQUAL         // =
-	COMMENT_IDENT // // /*   */, --, #
+	LPAREN          // (
+	RPAREN          // )
+	COMMA           // ,
+	SEMICOLON       // ;
+	INNER_SEMICOLON // ; while DELIMITER is changed
+	DOT             // .
+	SLASH           // /
+	ASTERISK        // *
+	DASH            // -
+	PLUS            // +
+	SINGLE_QUOTE    // '
+	DOUBLE_QUOTE    // "
+	EQUAL           // =
+	COMMENT_IDENT   // // /*   */, --, #
 	ACTION
 	AUTO_INCREMENT
 	AVG_ROW_LENGTH
@@ -158,6 +159,34 @@ const (
 	ASC
 	DESC
 	NOW
+	ENFORCED
+	GENERATED
+	ALWAYS
+	AS
+	VIRTUAL
+	STORED
+	PARTITION
+	PARTITIONS
+	SUBPARTITION
+	SUBPARTITIONS
+	BY
+	RANGE
+	LIST
+	LINEAR
+	COLUMNS
+	ALGORITHM
+	VALUES
+	LESS
+	THAN
+	MAXVALUE
+	IN
+	NODEGROUP
+	ALTER
+	ADD
+	CHANGE
+	COLUMN
+	RENAME
+	TO
 )
 
 var keywordIdentMap = map[string]TokenType{
@@ -276,6 +305,34 @@ var keywordIdentMap = map[string]TokenType{
 	"ASC":                ASC,
 	"DESC":               DESC,
 	"NOW":                NOW,
+	"ENFORCED":           ENFORCED,
+	"GENERATED":          GENERATED,
+	"ALWAYS":             ALWAYS,
+	"AS":                 AS,
+	"VIRTUAL":            VIRTUAL,
+	"STORED":             STORED,
+	"PARTITION":          PARTITION,
+	"PARTITIONS":         PARTITIONS,
+	"SUBPARTITION":       SUBPARTITION,
+	"SUBPARTITIONS":      SUBPARTITIONS,
+	"BY":                 BY,
+	"RANGE":              RANGE,
+	"LIST":               LIST,
+	"LINEAR":             LINEAR,
+	"COLUMNS":            COLUMNS,
+	"ALGORITHM":          ALGORITHM,
+	"VALUES":             VALUES,
+	"LESS":               LESS,
+	"THAN":               THAN,
+	"MAXVALUE":           MAXVALUE,
+	"IN":                 IN,
+	"NODEGROUP":          NODEGROUP,
+	"ALTER":              ALTER,
+	"ADD":                ADD,
+	"CHANGE":             CHANGE,
+	"COLUMN":             COLUMN,
+	"RENAME":             RENAME,
+	"TO":                 TO,
 }
 
 func (t TokenType) String() string {
@@ -304,6 +361,8 @@ func (t TokenType) String() string {
 		return "COMMA"
 	case SEMICOLON:
 		return "SEMICOLON"
+	case INNER_SEMICOLON:
+		return "INNER_SEMICOLON"
 	case DOT:
 		return "DOT"
 	case SLASH:
@@ -552,6 +611,62 @@ func (t TokenType) String() string {
 		return "DESC"
 	case NOW:
 		return "NOW"
+	case ENFORCED:
+		return "ENFORCED"
+	case GENERATED:
+		return "GENERATED"
+	case ALWAYS:
+		return "ALWAYS"
+	case AS:
+		return "AS"
+	case VIRTUAL:
+		return "VIRTUAL"
+	case STORED:
+		return "STORED"
+	case PARTITION:
+		return "PARTITION"
+	case PARTITIONS:
+		return "PARTITIONS"
+	case SUBPARTITION:
+		return "SUBPARTITION"
+	case SUBPARTITIONS:
+		return "SUBPARTITIONS"
+	case BY:
+		return "BY"
+	case RANGE:
+		return "RANGE"
+	case LIST:
+		return "LIST"
+	case LINEAR:
+		return "LINEAR"
+	case COLUMNS:
+		return "COLUMNS"
+	case ALGORITHM:
+		return "ALGORITHM"
+	case VALUES:
+		return "VALUES"
+	case LESS:
+		return "LESS"
+	case THAN:
+		return "THAN"
+	case MAXVALUE:
+		return "MAXVALUE"
+	case IN:
+		return "IN"
+	case NODEGROUP:
+		return "NODEGROUP"
+	case ALTER:
+		return "ALTER"
+	case ADD:
+		return "ADD"
+	case CHANGE:
+		return "CHANGE"
+	case COLUMN:
+		return "COLUMN"
+	case RENAME:
+		return "RENAME"
+	case TO:
+		return "TO"
 	}
 	return "(invalid)"
 }
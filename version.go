@@ -0,0 +1,355 @@
+package schemalex
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/schemalex/schemalex/internal/errors"
+	"github.com/schemalex/schemalex/internal/option"
+	"github.com/schemalex/schemalex/model"
+)
+
+const optkeyServerVersion = "server-version"
+
+// WithServerVersion specifies the version of the MySQL server that the
+// schema is meant for, such as "8.0.32" or "5.7". MariaDB versions are
+// specified with a "-MariaDB" suffix (e.g. "10.6.12-MariaDB", as reported
+// by `SELECT VERSION()`), or a "MariaDB" prefix (e.g. "MariaDB 10.6").
+// It can be passed to
+// New, format.SQL and the functions in the diff package. The version
+// controls which words are reserved, which features are allowed, the
+// default collations, and the syntax of the generated statements.
+//
+// If unspecified, no version specific checks are performed
+func WithServerVersion(v string) Option {
+	return option.New(optkeyServerVersion, v)
+}
+
+// ServerVersionFromOptions returns the server version specified by
+// WithServerVersion in the list of options. The second return value is
+// false if the version is not specified
+func ServerVersionFromOptions(options ...Option) (ServerVersion, bool, error) {
+	for _, o := range options {
+		if o.Name() != optkeyServerVersion {
+			continue
+		}
+		v, err := ParseServerVersion(o.Value().(string))
+		if err != nil {
+			return ServerVersion{}, false, err
+		}
+		return v, true, nil
+	}
+	return ServerVersion{}, false, nil
+}
+
+// Flavor describes the kind of the server
+type Flavor int
+
+// List of possible Flavor values
+const (
+	FlavorMySQL Flavor = iota
+	FlavorMariaDB
+)
+
+func (f Flavor) String() string {
+	if f == FlavorMariaDB {
+		return "MariaDB"
+	}
+	return "MySQL"
+}
+
+// ServerVersion describes a version of the MySQL or MariaDB server
+type ServerVersion struct {
+	Flavor Flavor
+	Major  int
+	Minor  int
+	// Patch is -1 if the patch version was not specified, in which case
+	// the latest release of the series is assumed
+	Patch int
+}
+
+// ParseServerVersion parses a version string such as "8.0.32", "5.7"
+// or "10.6.12-MariaDB-log", as reported by `SELECT VERSION()`. The
+// string may also be prefixed by the name of the flavor, such as
+// "MySQL 8.0" or "MariaDB 10.6", which is how ServerVersion.String
+// formats the version
+func ParseServerVersion(s string) (ServerVersion, error) {
+	v := ServerVersion{Patch: -1}
+
+	num := strings.ToLower(strings.TrimSpace(s))
+	if strings.Contains(num, "mariadb") {
+		v.Flavor = FlavorMariaDB
+		num = strings.TrimPrefix(num, "mariadb")
+		// replication connections report "5.5.5-" before the actual version
+		num = strings.TrimPrefix(num, "5.5.5-")
+	} else {
+		num = strings.TrimPrefix(num, "mysql")
+	}
+	num = strings.TrimLeft(num, " -")
+
+	parts := strings.SplitN(num, ".", 3)
+	if len(parts) < 2 {
+		return v, errors.Errorf(`invalid server version %q`, s)
+	}
+
+	nums := []*int{&v.Major, &v.Minor, &v.Patch}
+	for i, part := range parts {
+		// ignore suffixes such as "-log"
+		if j := strings.IndexFunc(part, func(r rune) bool { return r < '0' || r > '9' }); j >= 0 {
+			part = part[:j]
+		}
+		n, err := strconv.Atoi(part)
+		if err != nil {
+			return v, errors.Errorf(`invalid server version %q`, s)
+		}
+		*nums[i] = n
+	}
+	return v, nil
+}
+
+// String returns the flavor and the version, such as "MySQL 8.0.32"
+func (v ServerVersion) String() string {
+	s := v.Flavor.String() + " " + strconv.Itoa(v.Major) + "." + strconv.Itoa(v.Minor)
+	if v.Patch >= 0 {
+		s += "." + strconv.Itoa(v.Patch)
+	}
+	return s
+}
+
+// IsMariaDB returns true if the version is that of MariaDB
+func (v ServerVersion) IsMariaDB() bool {
+	return v.Flavor == FlavorMariaDB
+}
+
+// AtLeast returns true if the version is the same as, or newer than,
+// the given version. The flavor is not taken into account
+func (v ServerVersion) AtLeast(major, minor, patch int) bool {
+	if v.Major != major {
+		return v.Major > major
+	}
+	if v.Minor != minor {
+		return v.Minor > minor
+	}
+	return v.Patch < 0 || v.Patch >= patch
+}
+
+// SupportsCheckConstraints returns true if CHECK constraints are
+// enforced. Older versions parse and ignore them
+func (v ServerVersion) SupportsCheckConstraints() bool {
+	if v.IsMariaDB() {
+		return v.AtLeast(10, 2, 1)
+	}
+	return v.AtLeast(8, 0, 16)
+}
+
+// SupportsFunctionalIndexes returns true if index key parts can be
+// expressions
+func (v ServerVersion) SupportsFunctionalIndexes() bool {
+	return !v.IsMariaDB() && v.AtLeast(8, 0, 13)
+}
+
+// SupportsInvisibleIndexes returns true if indexes can be INVISIBLE
+func (v ServerVersion) SupportsInvisibleIndexes() bool {
+	return !v.IsMariaDB() && v.AtLeast(8, 0, 0)
+}
+
+// SupportsInvisibleColumns returns true if columns can be INVISIBLE
+func (v ServerVersion) SupportsInvisibleColumns() bool {
+	if v.IsMariaDB() {
+		return v.AtLeast(10, 3, 3)
+	}
+	return v.AtLeast(8, 0, 23)
+}
+
+// SupportsRenameColumn returns true if ALTER TABLE supports RENAME COLUMN
+func (v ServerVersion) SupportsRenameColumn() bool {
+	if v.IsMariaDB() {
+		return v.AtLeast(10, 5, 2)
+	}
+	return v.AtLeast(8, 0, 0)
+}
+
+// SupportsExpressionDefaults returns true if the default value of a
+// column can be an expression, such as `DEFAULT (UUID())`
+func (v ServerVersion) SupportsExpressionDefaults() bool {
+	if v.IsMariaDB() {
+		return v.AtLeast(10, 2, 1)
+	}
+	return v.AtLeast(8, 0, 13)
+}
+
+// SupportsSystemVersioning returns true if tables can be created
+// WITH SYSTEM VERSIONING. Only MariaDB supports it
+func (v ServerVersion) SupportsSystemVersioning() bool {
+	return v.IsMariaDB() && v.AtLeast(10, 3, 4)
+}
+
+// SupportsColumnType returns true if columns can be of the given type
+func (v ServerVersion) SupportsColumnType(typ model.ColumnType) bool {
+	switch typ {
+	case model.ColumnTypeUUID:
+		return v.IsMariaDB() && v.AtLeast(10, 7, 0)
+	case model.ColumnTypeINET6:
+		return v.IsMariaDB() && v.AtLeast(10, 5, 0)
+	case model.ColumnTypeINET4:
+		return v.IsMariaDB() && v.AtLeast(10, 10, 0)
+	}
+	return true
+}
+
+// SupportsTableOption returns true if tables can have the given option
+func (v ServerVersion) SupportsTableOption(name string) bool {
+	if _, ok := mariaDBTableOptions[strings.ToUpper(name)]; ok {
+		return v.IsMariaDB()
+	}
+	return true
+}
+
+// mariaDBTableOptions lists the table options that only MariaDB supports
+var mariaDBTableOptions = makeWordSet(
+	"ENCRYPTED", "ENCRYPTION_KEY_ID", "PAGE_CHECKSUM", "PAGE_COMPRESSED",
+	"PAGE_COMPRESSION_LEVEL", "TRANSACTIONAL",
+)
+
+// DefaultCollation returns the collation that is used for the character
+// set when no collation is specified. An empty string is returned if the
+// character set is not known
+func (v ServerVersion) DefaultCollation(charset string) string {
+	charset = strings.ToLower(charset)
+	switch charset {
+	case "utf8mb4":
+		if !v.IsMariaDB() && v.AtLeast(8, 0, 0) {
+			return "utf8mb4_0900_ai_ci"
+		}
+		return "utf8mb4_general_ci"
+	case "utf8", "utf8mb3":
+		if v.IsMariaDB() {
+			if v.AtLeast(10, 6, 1) {
+				return "utf8mb3_general_ci"
+			}
+			return "utf8_general_ci"
+		}
+		if v.AtLeast(8, 0, 30) {
+			return "utf8mb3_general_ci"
+		}
+		return "utf8_general_ci"
+	case "latin1":
+		return "latin1_swedish_ci"
+	case "binary":
+		return "binary"
+	case "ascii", "big5", "cp1250", "cp1251", "cp1256", "cp1257", "cp850", "cp852", "cp866",
+		"dec8", "eucjpms", "euckr", "gb2312", "gbk", "geostd8", "greek", "hebrew", "hp8",
+		"keybcs2", "koi8r", "koi8u", "latin2", "latin5", "latin7", "macce", "macroman",
+		"sjis", "swe7", "tis620", "ucs2", "ujis", "utf16", "utf16le", "utf32", "armscii8":
+		return charset + "_general_ci"
+	case "cp932":
+		return "cp932_japanese_ci"
+	case "gb18030":
+		return "gb18030_chinese_ci"
+	}
+	return ""
+}
+
+// IsReservedWord returns true if the word is reserved, and therefore
+// must be quoted when it is used as an identifier
+func (v ServerVersion) IsReservedWord(word string) bool {
+	word = strings.ToUpper(word)
+	if v.IsMariaDB() {
+		if _, ok := reservedWordsMariaDB[word]; ok {
+			return true
+		}
+		if _, ok := unreservedWordsMariaDB[word]; ok {
+			return false
+		}
+		_, ok := reservedWords[word]
+		return ok
+	}
+
+	if _, ok := reservedWords[word]; ok {
+		return true
+	}
+	if !v.AtLeast(8, 0, 0) {
+		return false
+	}
+	if _, ok := reservedWords80[word]; ok {
+		return true
+	}
+	switch word {
+	case "LATERAL":
+		return v.AtLeast(8, 0, 14)
+	case "INTERSECT":
+		return v.AtLeast(8, 0, 31)
+	}
+	return false
+}
+
+// reservedWords lists the words that are reserved in MySQL 5.7 and later
+var reservedWords = makeWordSet(
+	"ACCESSIBLE", "ADD", "ALL", "ALTER", "ANALYZE", "AND", "AS", "ASC",
+	"ASENSITIVE", "BEFORE", "BETWEEN", "BIGINT", "BINARY", "BLOB", "BOTH", "BY",
+	"CALL", "CASCADE", "CASE", "CHANGE", "CHAR", "CHARACTER", "CHECK", "COLLATE",
+	"COLUMN", "CONDITION", "CONSTRAINT", "CONTINUE", "CONVERT", "CREATE", "CROSS",
+	"CURRENT_DATE", "CURRENT_TIME", "CURRENT_TIMESTAMP", "CURRENT_USER", "CURSOR",
+	"DATABASE", "DATABASES", "DAY_HOUR", "DAY_MICROSECOND", "DAY_MINUTE",
+	"DAY_SECOND", "DEC", "DECIMAL", "DECLARE", "DEFAULT", "DELAYED", "DELETE",
+	"DESC", "DESCRIBE", "DETERMINISTIC", "DISTINCT", "DISTINCTROW", "DIV", "DOUBLE",
+	"DROP", "DUAL", "EACH", "ELSE", "ELSEIF", "ENCLOSED", "ESCAPED", "EXISTS", "EXIT",
+	"EXPLAIN", "FALSE", "FETCH", "FLOAT", "FLOAT4", "FLOAT8", "FOR", "FORCE",
+	"FOREIGN", "FROM", "FULLTEXT", "GENERATED", "GET", "GRANT", "GROUP", "HAVING",
+	"HIGH_PRIORITY", "HOUR_MICROSECOND", "HOUR_MINUTE", "HOUR_SECOND", "IF",
+	"IGNORE", "IN", "INDEX", "INFILE", "INNER", "INOUT", "INSENSITIVE", "INSERT",
+	"INT", "INT1", "INT2", "INT3", "INT4", "INT8", "INTEGER", "INTERVAL", "INTO",
+	"IO_AFTER_GTIDS", "IO_BEFORE_GTIDS", "IS", "ITERATE", "JOIN", "KEY", "KEYS",
+	"KILL", "LEADING", "LEAVE", "LEFT", "LIKE", "LIMIT", "LINEAR", "LINES", "LOAD",
+	"LOCALTIME", "LOCALTIMESTAMP", "LOCK", "LONG", "LONGBLOB", "LONGTEXT", "LOOP",
+	"LOW_PRIORITY", "MASTER_BIND", "MASTER_SSL_VERIFY_SERVER_CERT", "MATCH",
+	"MAXVALUE", "MEDIUMBLOB", "MEDIUMINT", "MEDIUMTEXT", "MIDDLEINT",
+	"MINUTE_MICROSECOND", "MINUTE_SECOND", "MOD", "MODIFIES", "NATURAL", "NOT",
+	"NO_WRITE_TO_BINLOG", "NULL", "NUMERIC", "ON", "OPTIMIZE", "OPTIMIZER_COSTS",
+	"OPTION", "OPTIONALLY", "OR", "ORDER", "OUT", "OUTER", "OUTFILE", "PARTITION",
+	"PRECISION", "PRIMARY", "PROCEDURE", "PURGE", "RANGE", "READ", "READS",
+	"READ_WRITE", "REAL", "REFERENCES", "REGEXP", "RELEASE", "RENAME", "REPEAT",
+	"REPLACE", "REQUIRE", "RESIGNAL", "RESTRICT", "RETURN", "REVOKE", "RIGHT",
+	"RLIKE", "SCHEMA", "SCHEMAS", "SECOND_MICROSECOND", "SELECT", "SENSITIVE",
+	"SEPARATOR", "SET", "SHOW", "SIGNAL", "SMALLINT", "SPATIAL", "SPECIFIC", "SQL",
+	"SQLEXCEPTION", "SQLSTATE", "SQLWARNING", "SQL_BIG_RESULT",
+	"SQL_CALC_FOUND_ROWS", "SQL_SMALL_RESULT", "SSL", "STARTING", "STORED",
+	"STRAIGHT_JOIN", "TABLE", "TERMINATED", "THEN", "TINYBLOB", "TINYINT",
+	"TINYTEXT", "TO", "TRAILING", "TRIGGER", "TRUE", "UNDO", "UNION", "UNIQUE",
+	"UNLOCK", "UNSIGNED", "UPDATE", "USAGE", "USE", "USING", "UTC_DATE", "UTC_TIME",
+	"UTC_TIMESTAMP", "VALUES", "VARBINARY", "VARCHAR", "VARCHARACTER", "VARYING",
+	"VIRTUAL", "WHEN", "WHERE", "WHILE", "WITH", "WRITE", "XOR", "YEAR_MONTH",
+	"ZEROFILL",
+)
+
+// reservedWords80 lists the words that are reserved as of MySQL 8.0
+var reservedWords80 = makeWordSet(
+	"CUBE", "CUME_DIST", "DENSE_RANK", "EMPTY", "EXCEPT", "FIRST_VALUE",
+	"FUNCTION", "GROUPING", "GROUPS", "JSON_TABLE", "LAG", "LAST_VALUE", "LEAD",
+	"NTH_VALUE", "NTILE", "OF", "OVER", "PERCENT_RANK", "RANK", "RECURSIVE",
+	"ROW", "ROWS", "ROW_NUMBER", "SYSTEM", "WINDOW",
+)
+
+// reservedWordsMariaDB lists the words that are reserved in MariaDB, on
+// top of those in reservedWords
+var reservedWordsMariaDB = makeWordSet(
+	"DELETE_DOMAIN_ID", "DO_DOMAIN_IDS", "EXCEPT", "GENERAL", "IGNORE_DOMAIN_IDS",
+	"IGNORE_SERVER_IDS", "INTERSECT", "MASTER_HEARTBEAT_PERIOD", "OVER",
+	"PAGE_CHECKSUM", "RECURSIVE", "REF_SYSTEM_ID", "RETURNING", "ROWS", "SLOW",
+	"STATS_AUTO_RECALC", "STATS_PERSISTENT", "STATS_SAMPLE_PAGES", "WINDOW",
+)
+
+// unreservedWordsMariaDB lists the words in reservedWords that are not
+// reserved in MariaDB
+var unreservedWordsMariaDB = makeWordSet(
+	"GENERATED", "GET", "IO_AFTER_GTIDS", "IO_BEFORE_GTIDS", "MASTER_BIND",
+	"OPTIMIZER_COSTS", "STORED", "VIRTUAL",
+)
+
+func makeWordSet(words ...string) map[string]struct{} {
+	m := make(map[string]struct{}, len(words))
+	for _, w := range words {
+		m[w] = struct{}{}
+	}
+	return m
+}